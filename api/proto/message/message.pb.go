@@ -69,6 +69,8 @@ const (
 	MessageType_DRAND_INIT             MessageType = 10
 	MessageType_DRAND_COMMIT           MessageType = 11
 	MessageType_LOTTERY_REQUEST        MessageType = 12
+	MessageType_BLOCK_REQUEST          MessageType = 13
+	MessageType_BLOCK_RESPONSE         MessageType = 14
 )
 
 var MessageType_name = map[int32]string{
@@ -83,6 +85,8 @@ var MessageType_name = map[int32]string{
 	10: "DRAND_INIT",
 	11: "DRAND_COMMIT",
 	12: "LOTTERY_REQUEST",
+	13: "BLOCK_REQUEST",
+	14: "BLOCK_RESPONSE",
 }
 
 var MessageType_value = map[string]int32{
@@ -97,6 +101,8 @@ var MessageType_value = map[string]int32{
 	"DRAND_INIT":             10,
 	"DRAND_COMMIT":           11,
 	"LOTTERY_REQUEST":        12,
+	"BLOCK_REQUEST":          13,
+	"BLOCK_RESPONSE":         14,
 }
 
 func (x MessageType) String() string {
@@ -526,6 +532,7 @@ type ConsensusRequest struct {
 	BlockHash            []byte   `protobuf:"bytes,3,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
 	SenderPubkey         []byte   `protobuf:"bytes,4,opt,name=sender_pubkey,json=senderPubkey,proto3" json:"sender_pubkey,omitempty"`
 	Payload              []byte   `protobuf:"bytes,5,opt,name=payload,proto3" json:"payload,omitempty"`
+	Nonce                uint64   `protobuf:"varint,6,opt,name=nonce,proto3" json:"nonce,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -591,6 +598,13 @@ func (m *ConsensusRequest) GetPayload() []byte {
 	return nil
 }
 
+func (m *ConsensusRequest) GetNonce() uint64 {
+	if m != nil {
+		return m.Nonce
+	}
+	return 0
+}
+
 type DrandRequest struct {
 	SenderPubkey         []byte   `protobuf:"bytes,1,opt,name=sender_pubkey,json=senderPubkey,proto3" json:"sender_pubkey,omitempty"`
 	BlockHash            []byte   `protobuf:"bytes,2,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
@@ -784,65 +798,66 @@ func init() {
 func init() { proto.RegisterFile("message.proto", fileDescriptor_33c57e4bae7b9afd) }
 
 var fileDescriptor_33c57e4bae7b9afd = []byte{
-	// 914 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x95, 0xcd, 0x8e, 0xe2, 0x46,
-	0x10, 0xc7, 0x31, 0x30, 0x18, 0x97, 0x0d, 0xd3, 0xdb, 0x49, 0x76, 0x9d, 0xc9, 0x46, 0x19, 0xb1,
-	0x8a, 0x34, 0x5a, 0x29, 0xa3, 0x15, 0x1c, 0xa2, 0x48, 0xb9, 0x30, 0xa6, 0xb5, 0x58, 0x33, 0x63,
-	0x48, 0x63, 0x76, 0x94, 0x93, 0x65, 0xa0, 0xc5, 0x58, 0x63, 0x6c, 0xe2, 0x36, 0xb3, 0xe2, 0x85,
-	0x72, 0xc9, 0x3d, 0xe7, 0xe4, 0x79, 0xf2, 0x12, 0x51, 0xb7, 0x6d, 0xcc, 0xc7, 0x46, 0x91, 0x72,
-	0xc8, 0x8d, 0xfa, 0x57, 0xfd, 0xaa, 0xab, 0xcb, 0x5d, 0x05, 0xb4, 0x56, 0x8c, 0x73, 0x7f, 0xc9,
-	0xae, 0xd7, 0x49, 0x9c, 0xc6, 0x58, 0xcd, 0xcd, 0xce, 0xef, 0x35, 0x50, 0xef, 0xb3, 0xdf, 0xf8,
-	0x7b, 0x30, 0x38, 0x4b, 0x9e, 0x83, 0x39, 0xf3, 0xd2, 0xed, 0x9a, 0x99, 0xca, 0xa5, 0x72, 0xd5,
-	0xee, 0x7e, 0x7e, 0x5d, 0xa0, 0x93, 0xcc, 0xe9, 0x6e, 0xd7, 0x8c, 0xea, 0xbc, 0x34, 0xf0, 0x15,
-	0xd4, 0x25, 0x50, 0x3d, 0x02, 0xf2, 0xc4, 0x12, 0x90, 0x11, 0xf8, 0x35, 0x68, 0x3c, 0x58, 0x46,
-	0x7e, 0xba, 0x49, 0x98, 0x59, 0xbb, 0x54, 0xae, 0x0c, 0x5a, 0x0a, 0xb8, 0x07, 0x2a, 0x4f, 0xfd,
-	0xa7, 0x20, 0x5a, 0x9a, 0xf5, 0x4b, 0xe5, 0x4a, 0xef, 0xbe, 0x2a, 0xcf, 0xce, 0x74, 0xca, 0x7e,
-	0xd9, 0x30, 0x9e, 0x0e, 0x2b, 0xb4, 0x88, 0xc4, 0x3f, 0x80, 0x36, 0x8f, 0x23, 0xce, 0x22, 0xbe,
-	0xe1, 0xe6, 0x99, 0xc4, 0xbe, 0xdc, 0x61, 0x56, 0xe1, 0x29, 0xc1, 0x32, 0x1a, 0x7f, 0x07, 0x67,
-	0x8b, 0xc4, 0x8f, 0x16, 0x66, 0x43, 0x62, 0x5f, 0xec, 0xb0, 0x81, 0x50, 0x4b, 0x24, 0x8b, 0xc2,
-	0x3f, 0x02, 0x3c, 0x07, 0xec, 0xe3, 0xfc, 0xd1, 0x8f, 0x96, 0xcc, 0x54, 0x25, 0x73, 0xb1, 0x63,
-	0x3e, 0x04, 0xec, 0xa3, 0x25, 0x5d, 0x25, 0xb8, 0x17, 0x8f, 0x6f, 0xe0, 0x3c, 0x8c, 0xd3, 0x94,
-	0x25, 0x5b, 0x2f, 0xc9, 0x02, 0xcc, 0xe6, 0xd1, 0x25, 0xef, 0x32, 0x7f, 0xc9, 0xb7, 0xc3, 0x03,
-	0xe5, 0x46, 0x03, 0x35, 0x67, 0x3b, 0x7f, 0x28, 0xd0, 0xa4, 0x8c, 0xaf, 0xc5, 0x65, 0xfe, 0x8f,
-	0x2f, 0x47, 0x00, 0x95, 0xe5, 0x67, 0xc7, 0xca, 0x0f, 0xa8, 0x77, 0xcd, 0xd3, 0xfa, 0x33, 0xff,
-	0xb0, 0x42, 0xcf, 0xc3, 0x43, 0xe9, 0x06, 0xa0, 0x59, 0xe0, 0x9d, 0xf7, 0x70, 0x7e, 0x44, 0x60,
-	0x13, 0xd4, 0x75, 0xe8, 0x6f, 0x59, 0xc2, 0xcd, 0xea, 0x65, 0xed, 0x4a, 0xa3, 0x85, 0x89, 0x2f,
-	0xa0, 0x39, 0xf3, 0x43, 0x3f, 0x9a, 0x33, 0x6e, 0xd6, 0xa4, 0x6b, 0x67, 0x77, 0x7e, 0x53, 0xa0,
-	0x7d, 0xd8, 0x3b, 0xfc, 0x2e, 0xbf, 0x58, 0xd6, 0x89, 0xd7, 0xff, 0xd0, 0xe2, 0xeb, 0xbd, 0x0b,
-	0x7e, 0x03, 0xfa, 0x3a, 0x09, 0x9e, 0xfd, 0x94, 0x79, 0x4f, 0x6c, 0x2b, 0x3b, 0xa2, 0x51, 0xc8,
-	0xa5, 0x5b, 0xb6, 0xc5, 0x2f, 0xa1, 0xe1, 0xaf, 0xe2, 0x4d, 0x94, 0xca, 0x7b, 0xd7, 0x68, 0x6e,
-	0x75, 0xae, 0xa1, 0x2e, 0x7b, 0xa9, 0xc1, 0x19, 0x71, 0x5c, 0x42, 0x51, 0x05, 0x03, 0x34, 0x28,
-	0x99, 0x4c, 0xef, 0x5c, 0xa4, 0xe0, 0x73, 0xd0, 0xc7, 0xb6, 0x75, 0xeb, 0x3d, 0xd8, 0x8e, 0x43,
-	0x28, 0xaa, 0x76, 0x6e, 0xa1, 0x7d, 0xf8, 0x9a, 0xf1, 0x25, 0xe8, 0x69, 0xe2, 0x47, 0xdc, 0x9f,
-	0xa7, 0x41, 0x1c, 0xc9, 0x9a, 0x0d, 0xba, 0x2f, 0xe1, 0x57, 0xa0, 0x46, 0xf1, 0x82, 0x79, 0xc1,
-	0x22, 0x2f, 0xac, 0x21, 0x4c, 0x7b, 0xd1, 0xf9, 0x55, 0x01, 0x74, 0xfc, 0xc8, 0x45, 0xb4, 0x78,
-	0x78, 0x22, 0x5a, 0xe4, 0x6a, 0xd1, 0x86, 0x30, 0xed, 0x05, 0xfe, 0x0a, 0xb4, 0x59, 0x18, 0xcf,
-	0x9f, 0xbc, 0x68, 0xb3, 0x92, 0x89, 0xea, 0xb4, 0x29, 0x05, 0x67, 0xb3, 0xc2, 0x5f, 0x03, 0x64,
-	0xce, 0x47, 0x9f, 0x3f, 0x16, 0xc3, 0x29, 0x95, 0xa1, 0xcf, 0x1f, 0xf1, 0x1b, 0x68, 0x71, 0x16,
-	0x2d, 0x58, 0xe2, 0xad, 0x37, 0x33, 0xd1, 0xa1, 0xba, 0x8c, 0x30, 0x32, 0x71, 0x2c, 0x35, 0xf9,
-	0xfd, 0xfc, 0x6d, 0x18, 0xfb, 0x0b, 0x39, 0x8a, 0x06, 0x2d, 0xcc, 0x4e, 0x08, 0xc6, 0xfe, 0x54,
-	0x9d, 0xa6, 0x53, 0x3e, 0x91, 0xee, 0xb0, 0xa4, 0xea, 0x71, 0x49, 0x7b, 0xa7, 0xd5, 0x0e, 0x4f,
-	0xfb, 0xab, 0x0a, 0x2f, 0x4e, 0x06, 0xf2, 0x3f, 0xf6, 0xe5, 0xa4, 0xd2, 0xda, 0x27, 0x2a, 0x7d,
-	0x03, 0xad, 0x90, 0xf9, 0xa7, 0xdd, 0xc9, 0xc4, 0x7f, 0xeb, 0x0e, 0xfe, 0x16, 0xda, 0xe5, 0xaa,
-	0xf0, 0x78, 0xb0, 0x94, 0x2b, 0xc9, 0xa0, 0xad, 0x52, 0x9d, 0x04, 0x4b, 0xd1, 0x0f, 0x21, 0x04,
-	0x0b, 0x19, 0xa2, 0x66, 0xfd, 0xc8, 0x94, 0xdc, 0xbd, 0xea, 0x7a, 0xfe, 0x72, 0xc9, 0x83, 0x25,
-	0x97, 0xdb, 0xc5, 0xa0, 0xda, 0xaa, 0xdb, 0xcf, 0x04, 0x71, 0xcb, 0x55, 0xd7, 0x9b, 0x05, 0xe9,
-	0xca, 0x5f, 0x9b, 0x9a, 0xf4, 0x36, 0x57, 0xdd, 0x1b, 0x69, 0x4b, 0xb6, 0xb7, 0x63, 0x21, 0x67,
-	0x7b, 0xfb, 0x6c, 0xaf, 0x60, 0xf5, 0x9c, 0xed, 0x65, 0xec, 0xdb, 0x21, 0xe8, 0x7b, 0x1b, 0x06,
-	0xb7, 0x40, 0xb3, 0x46, 0xce, 0x84, 0x38, 0x93, 0xe9, 0x04, 0x55, 0xb0, 0x0e, 0xea, 0xc4, 0xed,
-	0xdf, 0xda, 0xce, 0x7b, 0xa4, 0x88, 0x21, 0x19, 0xd0, 0xbe, 0x33, 0x40, 0x55, 0x8c, 0xa1, 0x6d,
-	0xdd, 0xd9, 0xc4, 0x71, 0xbd, 0xc9, 0x74, 0x3c, 0x1e, 0x51, 0x17, 0xd5, 0xde, 0xfe, 0xa9, 0x80,
-	0xbe, 0xb7, 0x7b, 0xf0, 0x05, 0xbc, 0x74, 0xc8, 0x83, 0x33, 0x1a, 0x10, 0xef, 0x86, 0xf4, 0xad,
-	0x91, 0xe3, 0x15, 0xa9, 0x2a, 0xd8, 0x80, 0x66, 0xdf, 0x71, 0x46, 0x53, 0xc7, 0x22, 0x48, 0x11,
-	0xa7, 0x8c, 0x29, 0x19, 0xf7, 0x29, 0x41, 0x55, 0xe1, 0xca, 0x8d, 0x01, 0xaa, 0x89, 0x69, 0xb4,
-	0x46, 0xf7, 0xf7, 0xb6, 0x8b, 0xea, 0x59, 0x6d, 0xe2, 0xb7, 0x4b, 0x06, 0xe8, 0x0c, 0xb7, 0x01,
-	0x3e, 0xd8, 0xe4, 0xc1, 0x1a, 0xf6, 0x9d, 0xf7, 0x04, 0x35, 0x44, 0x16, 0x87, 0x3c, 0x08, 0x09,
-	0xa9, 0xc2, 0x29, 0x6b, 0xf5, 0x6c, 0xc7, 0x76, 0x11, 0x60, 0x04, 0x46, 0x66, 0xe7, 0xd9, 0x74,
-	0xfc, 0x19, 0x9c, 0xdf, 0x8d, 0x5c, 0x97, 0xd0, 0x9f, 0x3d, 0x4a, 0x7e, 0x9a, 0x92, 0x89, 0x8b,
-	0x8c, 0x6e, 0x1f, 0x5a, 0x56, 0x18, 0xb0, 0x28, 0xcd, 0x7b, 0x82, 0xdf, 0x81, 0x3a, 0x4e, 0xe2,
-	0x39, 0xe3, 0x1c, 0xa3, 0xe3, 0x0d, 0x7b, 0xf1, 0x62, 0xa7, 0x14, 0x4b, 0xb0, 0x53, 0x99, 0x35,
-	0xe4, 0xbf, 0x74, 0xef, 0xef, 0x00, 0x00, 0x00, 0xff, 0xff, 0x27, 0x52, 0x45, 0x7b, 0xb6, 0x07,
-	0x00, 0x00,
+	// 929 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbd, 0x55, 0x4b, 0x6f, 0xe3, 0x54,
+	0x14, 0x6e, 0x9e, 0x4e, 0x8e, 0x93, 0xd4, 0xbd, 0x0c, 0x33, 0xa6, 0x0c, 0x62, 0x64, 0x84, 0x54,
+	0x8d, 0x44, 0x85, 0x92, 0x05, 0x42, 0x62, 0x93, 0x87, 0xd5, 0x5a, 0x6d, 0x9d, 0x70, 0x9d, 0x4c,
+	0xc5, 0xca, 0x72, 0xe2, 0xab, 0xd4, 0xaa, 0x63, 0x07, 0xdb, 0x29, 0xca, 0x6f, 0x62, 0xcf, 0x8a,
+	0x05, 0x3f, 0x07, 0x89, 0x3f, 0xc1, 0xb9, 0xd7, 0x76, 0x9c, 0xc7, 0x20, 0x24, 0x16, 0xec, 0x72,
+	0xbe, 0xef, 0x7c, 0xe7, 0xe5, 0x7b, 0x4e, 0xa0, 0xbd, 0x62, 0x71, 0xec, 0x2c, 0xd9, 0xf5, 0x3a,
+	0x0a, 0x93, 0x90, 0x48, 0x99, 0xa9, 0xfd, 0x56, 0x01, 0xe9, 0x21, 0xfd, 0x4d, 0xbe, 0x83, 0x56,
+	0xcc, 0xa2, 0x17, 0x6f, 0xc1, 0xec, 0x64, 0xbb, 0x66, 0x6a, 0xe9, 0x5d, 0xe9, 0xaa, 0xd3, 0x7d,
+	0x75, 0x9d, 0x4b, 0xad, 0x94, 0x9c, 0x22, 0x47, 0xe5, 0xb8, 0x30, 0xc8, 0x15, 0x54, 0x85, 0xa0,
+	0x7c, 0x24, 0xc8, 0x02, 0x0b, 0x81, 0xf0, 0x20, 0x6f, 0xa1, 0x19, 0x7b, 0xcb, 0xc0, 0x49, 0x36,
+	0x11, 0x53, 0x2b, 0xe8, 0xde, 0xa2, 0x05, 0x40, 0x7a, 0x20, 0xc5, 0x89, 0xf3, 0xec, 0x05, 0x4b,
+	0xb5, 0x8a, 0x9c, 0xdc, 0x7d, 0x53, 0xe4, 0x4e, 0x71, 0xca, 0x7e, 0xde, 0xb0, 0x38, 0xb9, 0x3d,
+	0xa3, 0xb9, 0x27, 0xf9, 0x1e, 0x9a, 0x8b, 0x30, 0x88, 0x59, 0x10, 0x6f, 0x62, 0xb5, 0x26, 0x64,
+	0x9f, 0xed, 0x64, 0xc3, 0x9c, 0x29, 0x84, 0x85, 0x37, 0xf9, 0x06, 0x6a, 0x6e, 0xe4, 0x04, 0xae,
+	0x5a, 0x17, 0xb2, 0x4f, 0x77, 0xb2, 0x11, 0x47, 0x0b, 0x49, 0xea, 0x45, 0x7e, 0x00, 0x78, 0xf1,
+	0xd8, 0x2f, 0x8b, 0x27, 0x27, 0x58, 0x32, 0x55, 0x12, 0x9a, 0xcb, 0x9d, 0xe6, 0x03, 0x52, 0x43,
+	0x41, 0x15, 0xc2, 0x3d, 0x7f, 0x32, 0x80, 0x73, 0x3f, 0x4c, 0x12, 0x16, 0x6d, 0xed, 0x28, 0x75,
+	0x50, 0x1b, 0x47, 0x4d, 0xde, 0xa7, 0x7c, 0xa1, 0xef, 0xf8, 0x07, 0xc8, 0xa0, 0x09, 0x52, 0xa6,
+	0xd5, 0xfe, 0x28, 0x41, 0x83, 0xb2, 0x78, 0xcd, 0x9b, 0xf9, 0x3f, 0xbe, 0x9c, 0x0e, 0x4a, 0x51,
+	0x7e, 0x9a, 0x56, 0x7c, 0x40, 0xb9, 0xab, 0x9e, 0xd6, 0x9f, 0xf2, 0xd8, 0xc0, 0xb9, 0x7f, 0x08,
+	0x0d, 0x00, 0x1a, 0xb9, 0x5c, 0xbb, 0x81, 0xf3, 0x23, 0x05, 0x51, 0x41, 0x5a, 0xfb, 0xce, 0x96,
+	0x45, 0x31, 0x96, 0x54, 0xb9, 0x6a, 0xd2, 0xdc, 0x24, 0x97, 0xd0, 0x98, 0x3b, 0xbe, 0x13, 0x2c,
+	0x58, 0x8c, 0x79, 0x39, 0xb5, 0xb3, 0xb5, 0x5f, 0x4b, 0xd0, 0x39, 0x9c, 0x1d, 0xf9, 0x36, 0x6b,
+	0x2c, 0x9d, 0xc4, 0xdb, 0x7f, 0x18, 0xf1, 0xf5, 0x5e, 0x83, 0x5f, 0x82, 0xbc, 0x8e, 0xbc, 0x17,
+	0x27, 0x61, 0xf6, 0x33, 0xdb, 0x8a, 0x89, 0x34, 0x29, 0x64, 0xd0, 0x1d, 0xdb, 0x92, 0xd7, 0x50,
+	0x77, 0x56, 0xe1, 0x26, 0x48, 0x44, 0xdf, 0x15, 0x9a, 0x59, 0xda, 0x35, 0x54, 0xc5, 0x2c, 0x9b,
+	0x50, 0xd3, 0xcd, 0xa9, 0x4e, 0x95, 0x33, 0x02, 0x50, 0xa7, 0xba, 0x35, 0xbb, 0x9f, 0x2a, 0x25,
+	0x72, 0x0e, 0xf2, 0xc4, 0x18, 0xde, 0xd9, 0x8f, 0x86, 0x69, 0x22, 0x59, 0xd6, 0xee, 0xa0, 0x73,
+	0xf8, 0x9a, 0xc9, 0x3b, 0x90, 0x13, 0x7c, 0x61, 0xb1, 0xb3, 0x48, 0xbc, 0x30, 0x10, 0x35, 0xb7,
+	0xe8, 0x3e, 0x44, 0xde, 0x80, 0x14, 0x84, 0x2e, 0xb3, 0x3d, 0x37, 0x2b, 0xac, 0xce, 0x4d, 0xc3,
+	0xd5, 0x7e, 0x2f, 0x81, 0x72, 0xfc, 0xc8, 0xb9, 0x37, 0x7f, 0x78, 0xdc, 0x9b, 0xc7, 0x6a, 0xd3,
+	0x3a, 0x37, 0x0d, 0x97, 0x7c, 0x0e, 0xcd, 0xb9, 0x1f, 0x2e, 0x9e, 0xed, 0x60, 0xb3, 0x12, 0x81,
+	0xaa, 0x38, 0x45, 0x0e, 0x98, 0x9b, 0x15, 0xf9, 0x02, 0x20, 0x25, 0x9f, 0x9c, 0xf8, 0x29, 0x5f,
+	0x4e, 0x81, 0xdc, 0x22, 0x40, 0xbe, 0x82, 0x36, 0x66, 0x71, 0x59, 0x64, 0xaf, 0x37, 0x73, 0x3e,
+	0xa1, 0xaa, 0xf0, 0x68, 0xa5, 0xe0, 0x44, 0x60, 0xe2, 0xfb, 0x39, 0x5b, 0x3f, 0x74, 0x5c, 0xb1,
+	0x8a, 0x2d, 0x9a, 0x9b, 0xe4, 0x15, 0xd4, 0x82, 0x10, 0xbf, 0x96, 0xd8, 0xb5, 0x2a, 0x4d, 0x0d,
+	0xcd, 0x87, 0xd6, 0xfe, 0xae, 0x9d, 0x26, 0x29, 0x7d, 0x24, 0xc9, 0x61, 0xa1, 0xe5, 0xe3, 0x42,
+	0xf7, 0x6a, 0xa8, 0x1c, 0xd4, 0xa0, 0xfd, 0x55, 0x86, 0x8b, 0x93, 0x35, 0xfd, 0x8f, 0xd3, 0x3a,
+	0xa9, 0xb4, 0xf2, 0x91, 0x4a, 0xd1, 0xc9, 0x67, 0xce, 0xe9, 0xcc, 0x52, 0xf0, 0x5f, 0x67, 0xf6,
+	0x35, 0x74, 0x8a, 0x03, 0x62, 0xe3, 0x9d, 0x14, 0xc3, 0x6b, 0xd1, 0x76, 0x81, 0x5a, 0xde, 0x92,
+	0xcf, 0x83, 0x03, 0x9e, 0x2b, 0x5c, 0xa4, 0x74, 0x1e, 0x29, 0x92, 0xd1, 0xab, 0xae, 0xed, 0x2c,
+	0x97, 0xc8, 0xc6, 0xe2, 0xe6, 0x20, 0xbd, 0xea, 0xf6, 0x53, 0x80, 0x77, 0x89, 0xf4, 0xdc, 0x4b,
+	0x56, 0xce, 0x5a, 0x6d, 0x0a, 0xb6, 0xb1, 0xea, 0x0e, 0x84, 0x2d, 0xb4, 0xbd, 0x9d, 0x16, 0x32,
+	0x6d, 0x6f, 0x5f, 0xdb, 0xcb, 0xb5, 0x72, 0xa6, 0xed, 0xa5, 0xda, 0xf7, 0xb7, 0x20, 0xef, 0xdd,
+	0x1d, 0xd2, 0x86, 0xe6, 0x70, 0x6c, 0x5a, 0xba, 0x69, 0xcd, 0x2c, 0x5c, 0x11, 0x19, 0x24, 0x6b,
+	0xda, 0xbf, 0x33, 0xcc, 0x1b, 0xdc, 0x11, 0x5c, 0x9d, 0x11, 0xed, 0x9b, 0x23, 0xa5, 0x4c, 0x08,
+	0x74, 0x86, 0xf7, 0x06, 0x2e, 0x92, 0x6d, 0xcd, 0x26, 0x93, 0x31, 0x9d, 0x2a, 0x95, 0xf7, 0x7f,
+	0x96, 0x40, 0xde, 0xbb, 0x48, 0x78, 0x0b, 0x5e, 0x9b, 0xfa, 0xa3, 0x39, 0x1e, 0xe9, 0xf6, 0x40,
+	0xef, 0x63, 0x54, 0x3b, 0x0f, 0x75, 0x46, 0x5a, 0xd0, 0xe8, 0x9b, 0xe6, 0x78, 0x66, 0x0e, 0x75,
+	0x0c, 0x8c, 0x59, 0x26, 0x54, 0x9f, 0xf4, 0xa9, 0x8e, 0xa1, 0x91, 0xca, 0x8c, 0x91, 0x52, 0xe1,
+	0x3b, 0x3a, 0x1c, 0x3f, 0x3c, 0x18, 0x53, 0xa5, 0x9a, 0xd6, 0xc6, 0x7f, 0x4f, 0x91, 0xaa, 0x91,
+	0x0e, 0xc0, 0x07, 0x43, 0x7f, 0x1c, 0xde, 0xf6, 0xcd, 0x1b, 0x5d, 0xa9, 0xf3, 0x28, 0x98, 0x8f,
+	0x43, 0x8a, 0xc4, 0x49, 0x51, 0xab, 0x6d, 0x98, 0xa8, 0x05, 0xa2, 0xe0, 0x13, 0x16, 0x76, 0x16,
+	0x4d, 0x26, 0x9f, 0xe0, 0x5d, 0x1b, 0x63, 0x28, 0xfa, 0x93, 0x4d, 0xf5, 0x1f, 0x67, 0xba, 0x35,
+	0x55, 0x5a, 0xe4, 0x02, 0xda, 0x83, 0xfb, 0x31, 0xde, 0x81, 0x1c, 0x6a, 0xf3, 0x56, 0x73, 0xc8,
+	0x9a, 0xf0, 0xd1, 0x28, 0x9d, 0x6e, 0x1f, 0xda, 0x43, 0xdf, 0x63, 0x41, 0x92, 0x8d, 0x0e, 0x0f,
+	0x99, 0x34, 0x89, 0x42, 0xbc, 0x72, 0x31, 0x51, 0x8e, 0xcf, 0xf3, 0xe5, 0xc5, 0x0e, 0xc9, 0x2f,
+	0xa8, 0x76, 0x36, 0xaf, 0x8b, 0xbf, 0xf8, 0xde, 0xdf, 0x87, 0xa0, 0x34, 0x90, 0xf3, 0x07, 0x00,
+	0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
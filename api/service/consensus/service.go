@@ -1,6 +1,9 @@
 package consensus
 
 import (
+	"context"
+	"time"
+
 	"github.com/ethereum/go-ethereum/rpc"
 	msg_pb "github.com/harmony-one/harmony/api/proto/message"
 	"github.com/harmony-one/harmony/consensus"
@@ -8,6 +11,9 @@ import (
 	"github.com/harmony-one/harmony/internal/utils"
 )
 
+// stopTimeout is how long StopService waits for the consensus workers to finish.
+const stopTimeout = 10 * time.Second
+
 // Service is the consensus service.
 type Service struct {
 	blockChannel chan *types.Block // The channel to receive new blocks from Node
@@ -41,6 +47,11 @@ func (s *Service) StopService() {
 	utils.GetLogInstance().Info("Stopping consensus service.")
 	s.stopChan <- struct{}{}
 	<-s.stoppedChan
+	ctx, cancel := context.WithTimeout(context.Background(), stopTimeout)
+	defer cancel()
+	if err := s.consensus.Stop(ctx); err != nil {
+		utils.GetLogInstance().Warn("Consensus did not stop cleanly", "error", err)
+	}
 	utils.GetLogInstance().Info("Consensus service stopped.")
 }
 
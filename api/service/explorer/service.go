@@ -36,15 +36,18 @@ type Service struct {
 	server            *http.Server
 	messageChan       chan *msg_pb.Message
 	GetAccountBalance func(common.Address) (*big.Int, error)
+	// GetConsensusStatus returns the JSON-encodable status of the current consensus round
+	GetConsensusStatus func() interface{}
 }
 
 // New returns explorer service.
-func New(selfPeer *p2p.Peer, GetNodeIDs func() []libp2p_peer.ID, GetAccountBalance func(common.Address) (*big.Int, error)) *Service {
+func New(selfPeer *p2p.Peer, GetNodeIDs func() []libp2p_peer.ID, GetAccountBalance func(common.Address) (*big.Int, error), GetConsensusStatus func() interface{}) *Service {
 	return &Service{
-		IP:                selfPeer.IP,
-		Port:              selfPeer.Port,
-		GetNodeIDs:        GetNodeIDs,
-		GetAccountBalance: GetAccountBalance,
+		IP:                 selfPeer.IP,
+		Port:               selfPeer.Port,
+		GetNodeIDs:         GetNodeIDs,
+		GetAccountBalance:  GetAccountBalance,
+		GetConsensusStatus: GetConsensusStatus,
 	}
 }
 
@@ -105,6 +108,9 @@ func (s *Service) Run() *http.Server {
 	s.router.Path("/shard").Queries("id", "{[0-9]*?}").HandlerFunc(s.GetExplorerShard).Methods("GET")
 	s.router.Path("/shard").HandlerFunc(s.GetExplorerShard)
 
+	// Set up router for consensus health.
+	s.router.Path("/consensus-status").HandlerFunc(s.GetConsensusStatusHandler)
+
 	// Do serving now.
 	utils.GetLogInstance().Info("Listening on ", "port: ", GetExplorerPort(s.Port))
 	server := &http.Server{Addr: addr, Handler: s.router}
@@ -279,6 +285,16 @@ func (s *Service) GetExplorerNodeCount(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(len(s.GetNodeIDs()))
 }
 
+// GetConsensusStatusHandler serves /consensus-status end-point
+func (s *Service) GetConsensusStatusHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if s.GetConsensusStatus == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(s.GetConsensusStatus())
+}
+
 // GetExplorerShard serves /shard end-point
 func (s *Service) GetExplorerShard(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
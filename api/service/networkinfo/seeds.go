@@ -0,0 +1,225 @@
+package networkinfo
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	ma "github.com/multiformats/go-multiaddr"
+
+	"github.com/harmony-one/harmony/internal/utils"
+)
+
+// NetworkSeeds are the seeds a node of a network bootstraps from when none of its
+// bootnodes can be reached.
+type NetworkSeeds struct {
+	// DNS are the domains listing the bootnodes of the network
+	DNS []string
+	// Signer is the address of the key the DNS seed lists must be signed with, if any
+	Signer string
+	// RequireSigned refuses the DNS seed lists unless they are signed by Signer, so that
+	// the DNS seeds are not used at all without a Signer
+	RequireSigned bool
+	// Fallback are the bootnodes compiled in, for when the DNS seeds cannot be resolved
+	Fallback []string
+}
+
+// Networks are the seeds of the known networks. The seed lists of mainnet and testnet are
+// only used if they are signed. Their signer is not published, so they have no seed domains
+// of their own: their nodes bootstrap from -bootnodes, or from the domains given with
+// -dns_seeds, signed by the key given with -seed_signer.
+var Networks = map[string]NetworkSeeds{
+	"mainnet":  {RequireSigned: true},
+	"testnet":  {RequireSigned: true},
+	"localnet": {Fallback: utils.DefaultBootNodeAddrStrings},
+}
+
+// Seeds are the seeds of the network of the node, set from Networks.
+var Seeds NetworkSeeds
+
+// DNS records of a seed domain
+const (
+	// TXT records of _dnsaddr.<domain> listing the bootnodes, as dnsaddr=<multiaddr>, and
+	// the signature of the list, as sig=<hex>
+	seedTXTPrefix  = "_dnsaddr."
+	seedAddrPrefix = "dnsaddr="
+	seedSigPrefix  = "sig="
+	// SRV records of _harmony._tcp.<domain> pointing at the bootnodes, whose peer IDs are
+	// in the TXT records of their names, as p2p=<peer ID>
+	seedSRVService = "harmony"
+	seedSRVProto   = "tcp"
+	seedPeerPrefix = "p2p="
+)
+
+// SeedResolver resolves the DNS seeds of a network into bootnodes.
+type SeedResolver struct {
+	// Signer is the address of the key the seed lists must be signed with. The lists are
+	// accepted unsigned if it is empty, unless RequireSigned is set.
+	Signer        common.Address
+	RequireSigned bool
+
+	lookupTXT  func(name string) ([]string, error)
+	lookupSRV  func(service, proto, name string) (string, []*net.SRV, error)
+	lookupHost func(host string) ([]string, error)
+}
+
+// NewSeedResolver creates a resolver of the seed lists of a network, signed as seeds
+// require, using the DNS resolver of the system.
+func NewSeedResolver(seeds NetworkSeeds) (*SeedResolver, error) {
+	resolver := &SeedResolver{
+		RequireSigned: seeds.RequireSigned,
+		lookupTXT:     net.LookupTXT,
+		lookupSRV:     net.LookupSRV,
+		lookupHost:    net.LookupHost,
+	}
+	if seeds.Signer != "" {
+		if !common.IsHexAddress(seeds.Signer) {
+			return nil, fmt.Errorf("invalid seed signer %q", seeds.Signer)
+		}
+		resolver.Signer = common.HexToAddress(seeds.Signer)
+	}
+	return resolver, nil
+}
+
+// Resolve returns the bootnodes listed by the domains. The domains which cannot be
+// resolved, or whose list is not signed by the signer, are skipped.
+func (resolver *SeedResolver) Resolve(domains []string) utils.AddrList {
+	var addrs utils.AddrList
+	for _, domain := range domains {
+		seeds, err := resolver.resolveDomain(domain)
+		if err != nil {
+			utils.GetLogInstance().Warn("Cannot resolve DNS seed", "domain", domain, "error", err)
+			continue
+		}
+		utils.GetLogInstance().Info("Resolved DNS seed", "domain", domain, "numSeeds", len(seeds))
+		addrs = append(addrs, seeds...)
+	}
+	return addrs
+}
+
+// resolveDomain returns the bootnodes listed by the TXT and SRV records of domain.
+func (resolver *SeedResolver) resolveDomain(domain string) ([]ma.Multiaddr, error) {
+	var addrs []ma.Multiaddr
+	var sig string
+	txts, txtErr := resolver.lookupTXT(seedTXTPrefix + domain)
+	for _, txt := range txts {
+		switch {
+		case strings.HasPrefix(txt, seedAddrPrefix):
+			addr, err := ma.NewMultiaddr(strings.TrimPrefix(txt, seedAddrPrefix))
+			if err != nil {
+				return nil, fmt.Errorf("invalid seed %q: %v", txt, err)
+			}
+			addrs = append(addrs, addr)
+		case strings.HasPrefix(txt, seedSigPrefix):
+			sig = strings.TrimPrefix(txt, seedSigPrefix)
+		}
+	}
+	srvAddrs, srvErr := resolver.resolveSRV(domain)
+	addrs = append(addrs, srvAddrs...)
+	if len(addrs) == 0 {
+		if txtErr != nil {
+			return nil, txtErr
+		}
+		if srvErr != nil {
+			return nil, srvErr
+		}
+		return nil, fmt.Errorf("no seeds")
+	}
+
+	if resolver.Signer == (common.Address{}) {
+		if resolver.RequireSigned {
+			return nil, fmt.Errorf("no seed signer configured to verify the list")
+		}
+		utils.GetLogInstance().Warn("DNS seed list not verified, no seed signer configured", "domain", domain)
+		return addrs, nil
+	}
+	if err := verifySeedList(addrs, sig, resolver.Signer); err != nil {
+		return nil, err
+	}
+	return addrs, nil
+}
+
+// resolveSRV returns the bootnodes the SRV records of domain point at.
+func (resolver *SeedResolver) resolveSRV(domain string) ([]ma.Multiaddr, error) {
+	_, srvs, err := resolver.lookupSRV(seedSRVService, seedSRVProto, domain)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []ma.Multiaddr
+	for _, srv := range srvs {
+		peerID := ""
+		txts, err := resolver.lookupTXT(srv.Target)
+		if err != nil {
+			return nil, err
+		}
+		for _, txt := range txts {
+			if strings.HasPrefix(txt, seedPeerPrefix) {
+				peerID = strings.TrimPrefix(txt, seedPeerPrefix)
+			}
+		}
+		if peerID == "" {
+			return nil, fmt.Errorf("no peer ID for seed %s", srv.Target)
+		}
+		ips, err := resolver.lookupHost(srv.Target)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			family := "ip4"
+			if strings.Contains(ip, ":") {
+				family = "ip6"
+			}
+			addr, err := ma.NewMultiaddr(fmt.Sprintf("/%s/%s/tcp/%d/p2p/%s", family, ip, srv.Port, peerID))
+			if err != nil {
+				return nil, fmt.Errorf("invalid seed %s: %v", srv.Target, err)
+			}
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs, nil
+}
+
+// seedListHash is the hash a seed list is signed over: that of its sorted addresses, one
+// per line, so that it does not depend on the order of the DNS records.
+func seedListHash(addrs []ma.Multiaddr) []byte {
+	lines := make([]string, len(addrs))
+	for i, addr := range addrs {
+		lines[i] = addr.String()
+	}
+	sort.Strings(lines)
+	return crypto.Keccak256([]byte(strings.Join(lines, "\n")))
+}
+
+// SignSeedList returns the signature of the seed list addrs with key, to publish in the
+// sig= TXT record of the seed domain.
+func SignSeedList(addrs []ma.Multiaddr, key *ecdsa.PrivateKey) (string, error) {
+	sig, err := crypto.Sign(seedListHash(addrs), key)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sig), nil
+}
+
+// verifySeedList checks that sig is the signature of the seed list addrs by signer.
+func verifySeedList(addrs []ma.Multiaddr, sig string, signer common.Address) error {
+	if sig == "" {
+		return fmt.Errorf("seed list not signed")
+	}
+	sigBytes, err := hex.DecodeString(sig)
+	if err != nil {
+		return fmt.Errorf("invalid seed list signature: %v", err)
+	}
+	pubKey, err := crypto.SigToPub(seedListHash(addrs), sigBytes)
+	if err != nil {
+		return fmt.Errorf("invalid seed list signature: %v", err)
+	}
+	if crypto.PubkeyToAddress(*pubKey) != signer {
+		return fmt.Errorf("seed list not signed by %s", signer.Hex())
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package networkinfo
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testSeedA = "/ip4/10.0.0.1/tcp/9876/p2p/QmaCpDMGvV2BGHeYERUEnRQAwe3N8SzbUtfsmvsqQLuvuJ"
+	testSeedB = "/ip4/10.0.0.2/tcp/9000/p2p/QmNnooDu7bfjPFoTZYxMNLWUQJyrVwtbZg5gBMjTezGAJN"
+)
+
+// testSeedResolver returns a resolver of the given DNS records.
+func testSeedResolver(txts map[string][]string, srvs []*net.SRV, hosts map[string][]string) *SeedResolver {
+	return &SeedResolver{
+		lookupTXT: func(name string) ([]string, error) {
+			if records, ok := txts[name]; ok {
+				return records, nil
+			}
+			return nil, errors.New("no such host")
+		},
+		lookupSRV: func(service, proto, name string) (string, []*net.SRV, error) {
+			if len(srvs) == 0 {
+				return "", nil, errors.New("no such host")
+			}
+			return "", srvs, nil
+		},
+		lookupHost: func(host string) ([]string, error) {
+			return hosts[host], nil
+		},
+	}
+}
+
+func TestSeedResolver(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	seedA, _ := ma.NewMultiaddr(testSeedA)
+	seedB, _ := ma.NewMultiaddr(testSeedB)
+	sig, err := SignSeedList([]ma.Multiaddr{seedB, seedA}, key)
+	assert.Nil(t, err)
+	forged, _ := SignSeedList([]ma.Multiaddr{seedA, seedB}, other)
+
+	// Seed B is listed through an SRV record, seed A through a TXT record.
+	srvs := []*net.SRV{{Target: "b.seeds.example", Port: 9000}}
+	hosts := map[string][]string{"b.seeds.example": {"10.0.0.2"}}
+	records := func(sig string) map[string][]string {
+		return map[string][]string{
+			"_dnsaddr.seeds.example": {"dnsaddr=" + testSeedA, "sig=" + sig},
+			"b.seeds.example":        {"p2p=QmNnooDu7bfjPFoTZYxMNLWUQJyrVwtbZg5gBMjTezGAJN"},
+		}
+	}
+
+	resolver := testSeedResolver(records(sig), srvs, hosts)
+	resolver.Signer = crypto.PubkeyToAddress(key.PublicKey)
+	addrs := resolver.Resolve([]string{"seeds.example", "missing.example"})
+	if assert.Len(t, addrs, 2) {
+		assert.True(t, addrs[0].Equal(seedA))
+		assert.True(t, addrs[1].Equal(seedB))
+	}
+
+	resolver = testSeedResolver(records(forged), srvs, hosts)
+	resolver.Signer = crypto.PubkeyToAddress(key.PublicKey)
+	assert.Empty(t, resolver.Resolve([]string{"seeds.example"}), "list signed by another key")
+
+	resolver = testSeedResolver(records(""), srvs, hosts)
+	resolver.Signer = crypto.PubkeyToAddress(key.PublicKey)
+	assert.Empty(t, resolver.Resolve([]string{"seeds.example"}), "unsigned list")
+
+	resolver = testSeedResolver(records(""), nil, hosts)
+	assert.Len(t, resolver.Resolve([]string{"seeds.example"}), 1, "no signer configured")
+
+	resolver = testSeedResolver(records(sig), srvs, hosts)
+	resolver.RequireSigned = true
+	assert.Empty(t, resolver.Resolve([]string{"seeds.example"}), "no signer configured for a network requiring signed lists")
+}
+
+func TestNewSeedResolver(t *testing.T) {
+	_, err := NewSeedResolver(NetworkSeeds{Signer: "not an address"})
+	assert.NotNil(t, err)
+	resolver, err := NewSeedResolver(NetworkSeeds{Signer: "0x0000000000000000000000000000000000000001"})
+	assert.Nil(t, err)
+	assert.Equal(t, byte(1), resolver.Signer[19])
+	for _, network := range []string{"mainnet", "testnet"} {
+		resolver, err = NewSeedResolver(Networks[network])
+		assert.Nil(t, err)
+		assert.True(t, resolver.RequireSigned, "%s accepts unsigned seed lists", network)
+		seeds := Networks[network]
+		assert.True(t, len(seeds.DNS) == 0 || seeds.Signer != "", "%s lists seed domains it cannot verify", network)
+	}
+}
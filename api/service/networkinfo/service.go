@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/rpc"
@@ -13,6 +14,8 @@ import (
 	"github.com/harmony-one/harmony/p2p"
 	libp2pdis "github.com/libp2p/go-libp2p-discovery"
 	libp2pdht "github.com/libp2p/go-libp2p-kad-dht"
+	libp2pnet "github.com/libp2p/go-libp2p-net"
+	libp2ppeer "github.com/libp2p/go-libp2p-peer"
 	peerstore "github.com/libp2p/go-libp2p-peerstore"
 	manet "github.com/multiformats/go-multiaddr-net"
 )
@@ -31,6 +34,9 @@ type Service struct {
 	discovery   *libp2pdis.RoutingDiscovery
 	messageChan chan *msg_pb.Message
 	started     bool
+	// peers found on the rendezvous, redialed when the group runs short of peers
+	groupPeers map[libp2ppeer.ID]peerstore.PeerInfo
+	findCancel context.CancelFunc
 }
 
 // ConnectionRetry set the number of retry of connection to bootnode in case the initial connection is failed
@@ -38,6 +44,10 @@ var (
 	// retry for 10 minutes and give up then
 	ConnectionRetry = 300
 
+	// TargetPeerCount is the number of connected peers of the group the service keeps
+	// dialing and looking for, 0 for no target.
+	TargetPeerCount = 16
+
 	// context
 	ctx context.Context
 )
@@ -48,6 +58,10 @@ const (
 
 	// register to bootnode every ticker
 	dhtTicker = 6 * time.Hour
+
+	// check the connected peers of the group every ticker
+	redialTicker = time.Minute
+	dialTimeout  = 10 * time.Second
 )
 
 // New returns role conversion service.
@@ -70,6 +84,7 @@ func New(h p2p.Host, rendezvous p2p.GroupID, peerChan chan p2p.Peer, bootnodes u
 		bootnodes:   bootnodes,
 		discovery:   nil,
 		started:     false,
+		groupPeers:  make(map[libp2ppeer.ID]peerstore.PeerInfo),
 	}
 }
 
@@ -95,32 +110,22 @@ func (s *Service) Init() error {
 		return fmt.Errorf("error bootstrap dht: %s", err)
 	}
 
-	var wg sync.WaitGroup
 	if s.bootnodes == nil {
 		// TODO: should've passed in bootnodes through constructor.
 		s.bootnodes = utils.BootNodes
 	}
 
+	// The seeds are only tried while none of the bootnodes can be reached.
 	connected := false
-	for _, peerAddr := range s.bootnodes {
-		peerinfo, _ := peerstore.InfoFromP2pAddr(peerAddr)
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for i := 0; i < ConnectionRetry; i++ {
-				if err := s.Host.GetP2PHost().Connect(ctx, *peerinfo); err != nil {
-					utils.GetLogInstance().Warn("can't connect to bootnode", "error", err, "try", i)
-					time.Sleep(waitInRetry)
-				} else {
-					utils.GetLogInstance().Info("connected to bootnode", "node", *peerinfo, "try", i)
-					// it is okay if any bootnode is connected
-					connected = true
-					break
-				}
-			}
-		}()
+	if len(s.bootnodes) == 0 && len(Seeds.DNS) == 0 && len(Seeds.Fallback) == 0 {
+		return fmt.Errorf("[FATAL] no bootnodes nor seeds")
+	}
+	for i := 0; i < ConnectionRetry && !connected; i++ {
+		connected = s.connectBootnodes(s.bootnodes, i) || s.connectSeeds(i)
+		if !connected {
+			time.Sleep(waitInRetry)
+		}
 	}
-	wg.Wait()
 
 	if !connected {
 		return fmt.Errorf("[FATAL] error connecting to bootnodes")
@@ -135,6 +140,52 @@ func (s *Service) Init() error {
 	return nil
 }
 
+// connectBootnodes tries once to connect to each of bootnodes in parallel, telling whether
+// any is connected.
+func (s *Service) connectBootnodes(bootnodes utils.AddrList, try int) bool {
+	var wg sync.WaitGroup
+	var connected int32
+	for _, peerAddr := range bootnodes {
+		peerinfo, err := peerstore.InfoFromP2pAddr(peerAddr)
+		if err != nil {
+			utils.GetLogInstance().Warn("invalid bootnode address", "addr", peerAddr, "error", err)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Host.GetP2PHost().Connect(ctx, *peerinfo); err != nil {
+				utils.GetLogInstance().Warn("can't connect to bootnode", "error", err, "try", try)
+				return
+			}
+			utils.GetLogInstance().Info("connected to bootnode", "node", *peerinfo, "try", try)
+			// it is okay if any bootnode is connected
+			atomic.StoreInt32(&connected, 1)
+		}()
+	}
+	wg.Wait()
+	return connected == 1
+}
+
+// connectSeeds tries to connect to the DNS seeds of the network, then to its fallback
+// seeds if none of the DNS seeds can be reached, telling whether any is connected.
+func (s *Service) connectSeeds(try int) bool {
+	if len(Seeds.DNS) > 0 {
+		resolver, err := NewSeedResolver(Seeds)
+		if err != nil {
+			utils.GetLogInstance().Error("Cannot resolve DNS seeds", "error", err)
+		} else if s.connectBootnodes(resolver.Resolve(Seeds.DNS), try) {
+			return true
+		}
+	}
+	fallback, err := utils.StringsToAddrs(Seeds.Fallback)
+	if err != nil {
+		utils.GetLogInstance().Error("Invalid fallback seeds", "error", err)
+		return false
+	}
+	return s.connectBootnodes(fallback, try)
+}
+
 // Run runs network info.
 func (s *Service) Run() {
 	defer close(s.stoppedChan)
@@ -143,9 +194,7 @@ func (s *Service) Run() {
 		return
 	}
 
-	var err error
-	s.peerInfo, err = s.discovery.FindPeers(ctx, string(s.Rendezvous))
-	if err != nil {
+	if err := s.findPeers(); err != nil {
 		utils.GetLogInstance().Error("FindPeers", "error", err)
 		return
 	}
@@ -161,18 +210,24 @@ func (s *Service) DoService() {
 		return
 	}
 	tick := time.NewTicker(dhtTicker)
+	redialTick := time.NewTicker(redialTicker)
+	defer redialTick.Stop()
 	for {
 		select {
-		case peer := <-s.peerInfo:
+		case peer, ok := <-s.peerInfo:
+			if !ok {
+				// The search is over, until the group runs short of peers again.
+				s.peerInfo = nil
+				break
+			}
 			if peer.ID != s.Host.GetP2PHost().ID() && len(peer.ID) > 0 {
+				s.groupPeers[peer.ID] = peer
 				//	utils.GetLogInstance().Info("Found Peer", "peer", peer.ID, "addr", peer.Addrs, "my ID", s.Host.GetP2PHost().ID())
-				if err := s.Host.GetP2PHost().Connect(ctx, peer); err != nil {
-					utils.GetLogInstance().Warn("can't connect to peer node", "error", err, "peer", peer)
+				if !s.dial(peer) {
 					// break if the node can't connect to peers, waiting for another peer
 					break
-				} else {
-					utils.GetLogInstance().Info("connected to peer node", "peer", peer)
 				}
+				utils.GetLogInstance().Info("connected to peer node", "peer", peer)
 				// figure out the public ip/port
 				var ip, port string
 
@@ -195,7 +250,12 @@ func (s *Service) DoService() {
 				}
 			}
 		case <-s.stopChan:
+			if s.findCancel != nil {
+				s.findCancel()
+			}
 			return
+		case <-redialTick.C:
+			s.maintainPeers()
 		case <-tick.C:
 			libp2pdis.Advertise(ctx, s.discovery, string(s.Rendezvous))
 			utils.GetLogInstance().Info("Successfully announced!", "Rendezvous", string(s.Rendezvous))
@@ -203,6 +263,73 @@ func (s *Service) DoService() {
 	}
 }
 
+// findPeers starts a new search of the peers advertising the rendezvous.
+func (s *Service) findPeers() error {
+	if s.findCancel != nil {
+		s.findCancel()
+	}
+	var findCtx context.Context
+	findCtx, s.findCancel = context.WithTimeout(context.Background(), connectionTimeout)
+	peerInfo, err := s.discovery.FindPeers(findCtx, string(s.Rendezvous))
+	if err != nil {
+		return err
+	}
+	s.peerInfo = peerInfo
+	return nil
+}
+
+// maintainPeers redials the known peers of the group and looks for new ones while fewer
+// than TargetPeerCount of them are connected. The bootnodes are redialed too when none is.
+func (s *Service) maintainPeers() {
+	if TargetPeerCount == 0 {
+		return
+	}
+	network := s.Host.GetP2PHost().Network()
+	connected := 0
+	for id := range s.groupPeers {
+		if network.Connectedness(id) == libp2pnet.Connected {
+			connected++
+		}
+	}
+	if connected >= TargetPeerCount {
+		return
+	}
+	utils.GetLogInstance().Info("Too few peers in the group, redialing",
+		"Rendezvous", string(s.Rendezvous), "connected", connected, "target", TargetPeerCount)
+	if connected == 0 {
+		for _, peerAddr := range s.bootnodes {
+			if peerinfo, err := peerstore.InfoFromP2pAddr(peerAddr); err == nil {
+				s.dial(*peerinfo)
+			}
+		}
+	}
+	for id, peer := range s.groupPeers {
+		if network.Connectedness(id) != libp2pnet.Connected && s.dial(peer) {
+			connected++
+		}
+		if connected >= TargetPeerCount {
+			return
+		}
+	}
+	if s.peerInfo == nil {
+		if err := s.findPeers(); err != nil {
+			utils.GetLogInstance().Warn("FindPeers", "error", err)
+		}
+	}
+}
+
+// dial connects to peer, telling whether it succeeded. Each dial has a deadline of its own,
+// as the service outlives the context of its initial connections.
+func (s *Service) dial(peer peerstore.PeerInfo) bool {
+	dialCtx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+	if err := s.Host.GetP2PHost().Connect(dialCtx, peer); err != nil {
+		utils.GetLogInstance().Warn("can't connect to peer node", "error", err, "peer", peer)
+		return false
+	}
+	return true
+}
+
 // StopService stops network info service.
 func (s *Service) StopService() {
 	utils.GetLogInstance().Info("Stopping network info service.")
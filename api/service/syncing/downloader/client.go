@@ -69,6 +69,18 @@ func (client *Client) GetBlocks(hashes [][]byte) *pb.DownloaderResponse {
 	return response
 }
 
+// GetBlockHeaders gets the headers of the given block hashes, RLP encoded, by calling a grpc request.
+func (client *Client) GetBlockHeaders(hashes [][]byte) *pb.DownloaderResponse {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	request := &pb.DownloaderRequest{Type: pb.DownloaderRequest_BLOCKHEADER, Hashes: hashes}
+	response, err := client.dlClient.Query(ctx, request)
+	if err != nil {
+		utils.GetLogInstance().Info("[SYNC] downloader/client.go:GetBlockHeaders query failed.", "error", err)
+	}
+	return response
+}
+
 // Register will register node's ip/port information to peers receive newly created blocks in future
 // hash is the bytes of "ip:port" string representation
 func (client *Client) Register(hash []byte, ip, port string) *pb.DownloaderResponse {
@@ -32,6 +32,7 @@ const (
 	DownloaderRequest_REGISTER        DownloaderRequest_RequestType = 4
 	DownloaderRequest_REGISTERTIMEOUT DownloaderRequest_RequestType = 5
 	DownloaderRequest_UNKNOWN         DownloaderRequest_RequestType = 6
+	DownloaderRequest_BLOCKHEADER     DownloaderRequest_RequestType = 7
 )
 
 var DownloaderRequest_RequestType_name = map[int32]string{
@@ -42,6 +43,7 @@ var DownloaderRequest_RequestType_name = map[int32]string{
 	4: "REGISTER",
 	5: "REGISTERTIMEOUT",
 	6: "UNKNOWN",
+	7: "BLOCKHEADER",
 }
 
 var DownloaderRequest_RequestType_value = map[string]int32{
@@ -52,6 +54,7 @@ var DownloaderRequest_RequestType_value = map[string]int32{
 	"REGISTER":        4,
 	"REGISTERTIMEOUT": 5,
 	"UNKNOWN":         6,
+	"BLOCKHEADER":     7,
 }
 
 func (x DownloaderRequest_RequestType) String() string {
@@ -240,32 +243,32 @@ func init() {
 func init() { proto.RegisterFile("downloader.proto", fileDescriptor_6a99ec95c7ab1ff1) }
 
 var fileDescriptor_6a99ec95c7ab1ff1 = []byte{
-	// 388 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x92, 0xdf, 0x6f, 0x94, 0x40,
-	0x10, 0xc7, 0x6f, 0x39, 0xa0, 0x77, 0xc3, 0xa5, 0x5d, 0x47, 0x63, 0x48, 0xa3, 0x86, 0xf0, 0x84,
-	0x2f, 0x3c, 0xb4, 0x4f, 0x3e, 0xf8, 0x50, 0xe9, 0x7a, 0x90, 0x56, 0x2e, 0x2e, 0x9c, 0x8d, 0x8f,
-	0xd4, 0x6e, 0x0a, 0xb1, 0x29, 0x2b, 0x4b, 0x63, 0xf8, 0xe3, 0xfc, 0x2f, 0xfc, 0x83, 0x0c, 0x7b,
-	0x3f, 0x20, 0x51, 0xef, 0x69, 0xe7, 0xfb, 0x9d, 0x9d, 0xc9, 0xcc, 0x27, 0x03, 0xf4, 0xae, 0xfe,
-	0xf9, 0xf8, 0x50, 0x17, 0x77, 0xa2, 0x09, 0x65, 0x53, 0xb7, 0x35, 0xc2, 0xe0, 0xf8, 0xbf, 0x0c,
-	0x78, 0x76, 0xb9, 0x97, 0x5c, 0xfc, 0x78, 0x12, 0xaa, 0xc5, 0xf7, 0x60, 0xb6, 0x9d, 0x14, 0x2e,
-	0xf1, 0x48, 0x70, 0x7c, 0xf6, 0x36, 0x1c, 0xb5, 0xf8, 0xeb, 0x73, 0xb8, 0x7d, 0xf3, 0x4e, 0x0a,
-	0xae, 0xcb, 0xf0, 0x25, 0xd8, 0x65, 0xa1, 0x4a, 0xa1, 0x5c, 0xc3, 0x9b, 0x06, 0x0b, 0xbe, 0x55,
-	0x78, 0x0a, 0x33, 0x29, 0x44, 0x13, 0x17, 0xaa, 0x74, 0xa7, 0x1e, 0x09, 0x16, 0x7c, 0xaf, 0xf1,
-	0x15, 0xcc, 0x6f, 0x1f, 0xea, 0x6f, 0xdf, 0x75, 0xd2, 0xd4, 0xc9, 0xc1, 0xc0, 0x63, 0x30, 0x2a,
-	0xe9, 0x5a, 0x1e, 0x09, 0xe6, 0xdc, 0xa8, 0x24, 0x22, 0x98, 0xb2, 0x6e, 0x5a, 0xd7, 0xd6, 0x8e,
-	0x8e, 0x7d, 0x05, 0xce, 0x68, 0x14, 0x04, 0xb0, 0x63, 0x76, 0x71, 0xc9, 0x38, 0x9d, 0xe0, 0x1c,
-	0xac, 0x0f, 0xd7, 0xab, 0xe8, 0x8a, 0x12, 0x5c, 0xc0, 0x2c, 0x65, 0x37, 0x1b, 0x65, 0xe0, 0x09,
-	0x38, 0x3a, 0x8c, 0x59, 0xb2, 0x8c, 0x73, 0x3a, 0xed, 0xd3, 0x9c, 0x2d, 0x93, 0x2c, 0x67, 0x9c,
-	0x9a, 0xf8, 0x1c, 0x4e, 0x76, 0x2a, 0x4f, 0x3e, 0xb1, 0xd5, 0x3a, 0xa7, 0x16, 0x3a, 0x70, 0xb4,
-	0x4e, 0xaf, 0xd2, 0xd5, 0x4d, 0x4a, 0x6d, 0xff, 0x37, 0x01, 0x1c, 0x23, 0x51, 0xb2, 0x7e, 0x54,
-	0x02, 0x5d, 0x38, 0x92, 0x45, 0xd7, 0x9b, 0x2e, 0xd1, 0x08, 0x76, 0x12, 0x97, 0x5b, 0xb4, 0x86,
-	0x46, 0x7b, 0xfe, 0x3f, 0xb4, 0x9b, 0x3e, 0x21, 0x17, 0xf7, 0x95, 0x6a, 0x07, 0x63, 0x04, 0xd9,
-	0x03, 0x67, 0xc3, 0x47, 0x54, 0xf7, 0x65, 0xab, 0x79, 0x9a, 0x7c, 0x6c, 0xf9, 0xef, 0xe0, 0xc5,
-	0xbf, 0xea, 0xfb, 0x05, 0xb2, 0x75, 0x14, 0xb1, 0x2c, 0xa3, 0x13, 0x9c, 0x81, 0xf9, 0xf1, 0x22,
-	0xb9, 0xa6, 0xa4, 0x07, 0x96, 0xa4, 0xd9, 0xd7, 0x34, 0xa2, 0xc6, 0xd9, 0x17, 0x80, 0x61, 0x1a,
-	0x8c, 0xc1, 0xfa, 0xfc, 0x24, 0x9a, 0x0e, 0x5f, 0x1f, 0xbc, 0x84, 0xd3, 0x37, 0x87, 0xb7, 0xf1,
-	0x27, 0xb7, 0xb6, 0xbe, 0xc0, 0xf3, 0x3f, 0x01, 0x00, 0x00, 0xff, 0xff, 0x45, 0x42, 0x00, 0x51,
-	0x95, 0x02, 0x00, 0x00,
+	// 391 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x92, 0xcf, 0x6e, 0x9b, 0x40,
+	0x10, 0xc6, 0xbd, 0x18, 0xb0, 0x3d, 0x58, 0xc9, 0x76, 0x5a, 0x55, 0x28, 0x6a, 0x2b, 0xc4, 0x89,
+	0x5e, 0x38, 0x24, 0xa7, 0x1e, 0x7a, 0x48, 0xc9, 0xd6, 0xa0, 0xa4, 0x58, 0x5d, 0x70, 0xa3, 0x1e,
+	0x49, 0xb3, 0x0a, 0xa8, 0x91, 0xd9, 0x02, 0x56, 0xc5, 0xbd, 0xef, 0xd7, 0x4b, 0x1f, 0xa8, 0x62,
+	0xfd, 0x07, 0xa4, 0x36, 0x3e, 0x31, 0xdf, 0x37, 0xcc, 0xec, 0xcc, 0x4f, 0x03, 0xf4, 0xbe, 0xfc,
+	0xb9, 0x7e, 0x2c, 0xb3, 0x7b, 0x51, 0xf9, 0xb2, 0x2a, 0x9b, 0x12, 0xa1, 0x77, 0xdc, 0xdf, 0x1a,
+	0x3c, 0xbb, 0x3a, 0x48, 0x2e, 0x7e, 0x6c, 0x44, 0xdd, 0xe0, 0x7b, 0xd0, 0x9b, 0x56, 0x0a, 0x9b,
+	0x38, 0xc4, 0x3b, 0x39, 0x7f, 0xeb, 0x0f, 0x5a, 0xfc, 0xf3, 0xb3, 0xbf, 0xfb, 0xa6, 0xad, 0x14,
+	0x5c, 0x95, 0xe1, 0x4b, 0x30, 0xf3, 0xac, 0xce, 0x45, 0x6d, 0x6b, 0xce, 0xd8, 0x9b, 0xf3, 0x9d,
+	0xc2, 0x33, 0x98, 0x4a, 0x21, 0xaa, 0x30, 0xab, 0x73, 0x7b, 0xec, 0x10, 0x6f, 0xce, 0x0f, 0x1a,
+	0x5f, 0xc1, 0xec, 0xee, 0xb1, 0xfc, 0xf6, 0x5d, 0x25, 0x75, 0x95, 0xec, 0x0d, 0x3c, 0x01, 0xad,
+	0x90, 0xb6, 0xe1, 0x10, 0x6f, 0xc6, 0xb5, 0x42, 0x22, 0x82, 0x2e, 0xcb, 0xaa, 0xb1, 0x4d, 0xe5,
+	0xa8, 0xd8, 0xfd, 0x45, 0xc0, 0x1a, 0xcc, 0x82, 0x00, 0x66, 0xc8, 0x2e, 0xaf, 0x18, 0xa7, 0x23,
+	0x9c, 0x81, 0xf1, 0xe1, 0x66, 0x19, 0x5c, 0x53, 0x82, 0x73, 0x98, 0xc6, 0xec, 0x76, 0xab, 0x34,
+	0x3c, 0x05, 0x4b, 0x85, 0x21, 0x8b, 0x16, 0x61, 0x4a, 0xc7, 0x5d, 0x9a, 0xb3, 0x45, 0x94, 0xa4,
+	0x8c, 0x53, 0x1d, 0x9f, 0xc3, 0xe9, 0x5e, 0xa5, 0xd1, 0x27, 0xb6, 0x5c, 0xa5, 0xd4, 0x40, 0x0b,
+	0x26, 0xab, 0xf8, 0x3a, 0x5e, 0xde, 0xc6, 0xd4, 0x1c, 0x34, 0x50, 0x4f, 0x4d, 0xdc, 0x3f, 0x04,
+	0x70, 0x08, 0xa9, 0x96, 0xe5, 0xba, 0x16, 0x68, 0xc3, 0x44, 0x66, 0x6d, 0x67, 0xda, 0x44, 0x41,
+	0xd9, 0x4b, 0x5c, 0xec, 0x60, 0x6b, 0x0a, 0xf6, 0xc5, 0x53, 0xb0, 0xb7, 0x7d, 0x7c, 0x2e, 0x1e,
+	0x8a, 0xba, 0xe9, 0x8d, 0x01, 0x76, 0x07, 0xac, 0x2d, 0x31, 0x51, 0x3c, 0xe4, 0x8d, 0x22, 0xac,
+	0xf3, 0xa1, 0xe5, 0xbe, 0x83, 0x17, 0xff, 0xab, 0xef, 0x36, 0x4a, 0x56, 0x41, 0xc0, 0x92, 0x84,
+	0x8e, 0x70, 0x0a, 0xfa, 0xc7, 0xcb, 0xe8, 0x86, 0x92, 0x8e, 0x60, 0x14, 0x27, 0x5f, 0xe3, 0x80,
+	0x6a, 0xe7, 0x5f, 0x00, 0xfa, 0x69, 0x30, 0x04, 0xe3, 0xf3, 0x46, 0x54, 0x2d, 0xbe, 0x3e, 0x7a,
+	0x1b, 0x67, 0x6f, 0x8e, 0x6f, 0xe3, 0x8e, 0xee, 0x4c, 0x75, 0x93, 0x17, 0x7f, 0x07, 0x00, 0xd4,
+	0x93, 0x1f, 0x54, 0xa7, 0x02, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -7,4 +7,5 @@ var (
 	ErrRegistrationFail = errors.New("[SYNC]: registration failed")
 	ErrGetBlock         = errors.New("[SYNC]: get block failed")
 	ErrGetBlockHash     = errors.New("[SYNC]: get blockhash failed")
+	ErrGetBlockHeader   = errors.New("[SYNC]: get block header failed")
 )
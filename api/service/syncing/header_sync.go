@@ -0,0 +1,206 @@
+package syncing
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/harmony-one/bls/ffi/go/bls"
+
+	"github.com/harmony-one/harmony/consensus"
+	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/internal/ctxerror"
+	"github.com/harmony-one/harmony/internal/utils"
+)
+
+// SyncMode tells what StateSync downloads.
+type SyncMode uint8
+
+// The sync modes
+const (
+	// SyncFull downloads the blocks and executes them.
+	SyncFull SyncMode = iota
+	// SyncHeaderFirst downloads the headers and verifies their commit signatures first,
+	// then downloads only the blocks of the verified headers.
+	SyncHeaderFirst
+	// SyncHeadersOnly downloads and verifies the headers and skips the bodies, for light operation.
+	SyncHeadersOnly
+)
+
+// number of headers asked from a peer in one request
+const headerBatchSize = 64
+
+// downloadHeaders downloads the headers of the consensus block hashes from the peers
+// in parallel, keyed by their hash. A header not matching the hash it was asked for is dropped.
+func (ss *StateSync) downloadHeaders() map[common.Hash]*types.Header {
+	var hashes [][]byte
+	ss.syncConfig.ForEachPeer(func(peerConfig *SyncPeerConfig) (brk bool) {
+		hashes = peerConfig.blockHashes
+		return true
+	})
+	var batches [][][]byte
+	for len(hashes) > 0 {
+		n := headerBatchSize
+		if n > len(hashes) {
+			n = len(hashes)
+		}
+		batches = append(batches, hashes[:n])
+		hashes = hashes[n:]
+	}
+
+	headers := make(map[common.Hash]*types.Header)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	next := 0
+	ss.syncConfig.ForEachPeer(func(peerConfig *SyncPeerConfig) (brk bool) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			count := 0
+			for {
+				mutex.Lock()
+				if next >= len(batches) {
+					mutex.Unlock()
+					return
+				}
+				batch := batches[next]
+				next++
+				mutex.Unlock()
+
+				payload, err := peerConfig.GetBlockHeaders(batch)
+				if err != nil || len(payload) == 0 {
+					count++
+					utils.GetLogInstance().Debug("[SYNC] GetBlockHeaders failed", "failNumber", count)
+					mutex.Lock()
+					batches = append(batches, batch)
+					mutex.Unlock()
+					if count > TimesToFail {
+						return
+					}
+					continue
+				}
+				for _, encodedHeader := range payload {
+					header := &types.Header{}
+					if err := rlp.DecodeBytes(encodedHeader, header); err != nil {
+						utils.GetLogInstance().Debug("[SYNC] downloadHeaders: failed to decode header", "error", err)
+						continue
+					}
+					mutex.Lock()
+					headers[header.Hash()] = header
+					mutex.Unlock()
+				}
+			}
+		}()
+		return
+	})
+	wg.Wait()
+
+	wanted := make(map[common.Hash]bool)
+	ss.syncConfig.ForEachPeer(func(peerConfig *SyncPeerConfig) (brk bool) {
+		for _, hash := range peerConfig.blockHashes {
+			wanted[common.BytesToHash(hash)] = true
+		}
+		return true
+	})
+	for hash := range headers {
+		if !wanted[hash] {
+			delete(headers, hash)
+		}
+	}
+	utils.GetLogInstance().Info("[SYNC] Finished downloadHeaders.", "numHeaders", len(headers))
+	return headers
+}
+
+// syncHeaders downloads the headers following the current header of bc, checks that each
+// was committed by its committee and inserts them into the header chain. The committee
+// follows the shard states carried by the headers at the end of each epoch.
+func (ss *StateSync) syncHeaders(bc *core.BlockChain) error {
+	headers := ss.downloadHeaders()
+	byParent := make(map[common.Hash]*types.Header)
+	for _, header := range headers {
+		byParent[header.ParentHash] = header
+	}
+
+	var quorum consensus.Quorum = consensus.CountQuorum{}
+	if ss.QuorumPolicy != nil {
+		quorum = ss.QuorumPolicy
+	}
+	parent := bc.CurrentHeader()
+	var chain []*types.Header
+	var epoch *big.Int
+	var committee []*bls.PublicKey
+	for header := byParent[parent.Hash()]; header != nil; header = byParent[header.Hash()] {
+		if epoch == nil || header.Epoch.Cmp(epoch) != 0 {
+			shardState, err := bc.ReadShardState(header.Epoch)
+			if err != nil {
+				return ctxerror.New("[SYNC] cannot read the committee of the header",
+					"epoch", header.Epoch, "blockNum", header.Number).WithCause(err)
+			}
+			committee, err = committeeKeys(shardState, header.ShardID)
+			if err != nil {
+				return err
+			}
+			epoch = header.Epoch
+		}
+		if err := consensus.VerifyHeaderSigs(header, committee, quorum); err != nil {
+			return ctxerror.New("[SYNC] header not committed by its committee",
+				"blockNum", header.Number, "blockHash", header.Hash()).WithCause(err)
+		}
+		chain = append(chain, header)
+		if header.ShardStateHash != (common.Hash{}) {
+			// Insert the epoch so far, then store the committee of the next epoch as
+			// InsertChain would, so that the next headers can be verified.
+			if _, err := bc.InsertHeaderChain(chain, 1); err != nil {
+				return ctxerror.New("[SYNC] cannot insert headers").WithCause(err)
+			}
+			chain = nil
+			nextEpoch := new(big.Int).Add(header.Epoch, common.Big1)
+			if err := bc.WriteShardState(nextEpoch, header.ShardState); err != nil {
+				return ctxerror.New("[SYNC] cannot store shard state", "epoch", nextEpoch).WithCause(err)
+			}
+		}
+	}
+	if len(chain) > 0 {
+		if _, err := bc.InsertHeaderChain(chain, 1); err != nil {
+			return ctxerror.New("[SYNC] cannot insert headers").WithCause(err)
+		}
+	}
+	utils.GetLogInstance().Info("[SYNC] Finished syncHeaders.", "headerHeight", bc.CurrentHeader().Number)
+	return nil
+}
+
+// committeeKeys returns the BLS public keys of the committee of shardID in shardState.
+func committeeKeys(shardState types.ShardState, shardID uint32) ([]*bls.PublicKey, error) {
+	committee := shardState.FindCommitteeByID(shardID)
+	if committee == nil {
+		return nil, ctxerror.New("[SYNC] shard not found in shard state", "shardID", shardID)
+	}
+	pubKeys := []*bls.PublicKey{}
+	for _, nodeID := range committee.NodeList {
+		pubKey := &bls.PublicKey{}
+		if err := pubKey.Deserialize(nodeID.BlsPublicKey[:]); err != nil {
+			return nil, ctxerror.New("[SYNC] cannot deserialize BLS public key", "shardID", shardID).WithCause(err)
+		}
+		pubKeys = append(pubKeys, pubKey)
+	}
+	return pubKeys, nil
+}
+
+// currentHeight returns the height StateSync has synced bc to, which is that of the
+// header chain when the bodies are skipped.
+func (ss *StateSync) currentHeight(bc *core.BlockChain) uint64 {
+	if ss.Mode == SyncHeadersOnly {
+		return bc.CurrentHeader().Number.Uint64()
+	}
+	return bc.CurrentBlock().NumberU64()
+}
+
+// currentHash returns the hash StateSync resumes syncing bc from.
+func (ss *StateSync) currentHash(bc *core.BlockChain) common.Hash {
+	if ss.Mode == SyncHeadersOnly {
+		return bc.CurrentHeader().Hash()
+	}
+	return bc.CurrentBlock().Hash()
+}
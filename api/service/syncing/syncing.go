@@ -14,12 +14,14 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/harmony-one/harmony/api/service/syncing/downloader"
 	pb "github.com/harmony-one/harmony/api/service/syncing/downloader/proto"
+	"github.com/harmony-one/harmony/consensus"
 	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/internal/ctxerror"
 	"github.com/harmony-one/harmony/internal/utils"
 	"github.com/harmony-one/harmony/node/worker"
 	"github.com/harmony-one/harmony/p2p"
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
 )
 
 // Constants for syncing.
@@ -36,6 +38,7 @@ const (
 type SyncPeerConfig struct {
 	ip          string
 	port        string
+	peerID      libp2p_peer.ID
 	peerHash    []byte
 	client      *downloader.Client
 	blockHashes [][]byte       // block hashes before node doing sync
@@ -82,6 +85,21 @@ func (sc *SyncConfig) ForEachPeer(f func(peer *SyncPeerConfig) (brk bool)) {
 	}
 }
 
+// PeerIDs returns the p2p IDs of the peers the blocks are synced from, as far as known.
+func (ss *StateSync) PeerIDs() []libp2p_peer.ID {
+	if ss.syncConfig == nil {
+		return nil
+	}
+	var ids []libp2p_peer.ID
+	ss.syncConfig.ForEachPeer(func(peerConfig *SyncPeerConfig) (brk bool) {
+		if peerConfig.peerID != "" {
+			ids = append(ids, peerConfig.peerID)
+		}
+		return
+	})
+	return ids
+}
+
 // CreateStateSync returns the implementation of StateSyncInterface interface.
 func CreateStateSync(ip string, port string, peerHash [20]byte) *StateSync {
 	stateSync := &StateSync{}
@@ -103,6 +121,13 @@ type StateSync struct {
 	syncConfig         *SyncConfig
 	stateSyncTaskQueue *queue.Queue
 	syncMux            sync.Mutex
+
+	// Mode tells whether to verify the headers before downloading the blocks, or to skip the blocks
+	Mode SyncMode
+	// Optional reputation manager penalizing the peers serving bad blocks
+	Reputation *p2p.Reputation
+	// The quorum the commit signatures of the headers must reach; CountQuorum if nil
+	QuorumPolicy consensus.Quorum
 }
 
 // AddLastMileBlock add the lastest a few block into queue for syncing
@@ -181,6 +206,15 @@ func (peerConfig *SyncPeerConfig) GetBlocks(hashes [][]byte) ([][]byte, error) {
 	return response.Payload, nil
 }
 
+// GetBlockHeaders gets the RLP encoded headers of the given hashes from the peer.
+func (peerConfig *SyncPeerConfig) GetBlockHeaders(hashes [][]byte) ([][]byte, error) {
+	response := peerConfig.client.GetBlockHeaders(hashes)
+	if response == nil {
+		return nil, ErrGetBlockHeader
+	}
+	return response.Payload, nil
+}
+
 // CreateSyncConfig creates SyncConfig for StateSync object.
 func (ss *StateSync) CreateSyncConfig(peers []p2p.Peer, isBeacon bool) error {
 	utils.GetLogInstance().Debug("CreateSyncConfig: len of peers", "len", len(peers), "isBeacon", isBeacon)
@@ -200,6 +234,7 @@ func (ss *StateSync) CreateSyncConfig(peers []p2p.Peer, isBeacon bool) error {
 			peerConfig := &SyncPeerConfig{
 				ip:     peer.IP,
 				port:   peer.Port,
+				peerID: peer.PeerID,
 				client: client,
 			}
 			ss.syncConfig.AddPeer(peerConfig)
@@ -364,9 +399,13 @@ func (ss *StateSync) downloadBlocks(bc *core.BlockChain) {
 				// currently only send one block a time
 				err = rlp.DecodeBytes(payload[0], &blockObj)
 
+				if err == nil && blockObj.Hash() != common.BytesToHash(syncTask.blockHash) {
+					err = ctxerror.New("[SYNC] downloadBlocks: received another block than requested")
+				}
 				if err != nil {
 					count++
-					utils.GetLogInstance().Debug("[SYNC] downloadBlocks: failed to DecodeBytes from received new block")
+					utils.GetLogInstance().Debug("[SYNC] downloadBlocks: failed to DecodeBytes from received new block", "error", err)
+					ss.penalize(peerConfig, p2p.OffenseSyncMisbehavior)
 					if count > TimesToFail {
 						break
 					}
@@ -384,6 +423,14 @@ func (ss *StateSync) downloadBlocks(bc *core.BlockChain) {
 	utils.GetLogInstance().Info("[SYNC] Finished downloadBlocks.")
 }
 
+// penalize lowers the reputation of the peer of peerConfig for offense, if there is a
+// reputation manager and the peer is known by its ID.
+func (ss *StateSync) penalize(peerConfig *SyncPeerConfig, offense p2p.Offense) {
+	if ss.Reputation != nil && peerConfig.peerID != "" {
+		ss.Reputation.Penalize(peerConfig.peerID, offense)
+	}
+}
+
 // CompareBlockByHash compares two block by hash, it will be used in sort the blocks
 func CompareBlockByHash(a *types.Block, b *types.Block) int {
 	ha := a.Hash()
@@ -482,6 +529,10 @@ func (ss *StateSync) generateNewState(bc *core.BlockChain, worker *worker.Worker
 		if block == nil {
 			break
 		}
+		if ss.Mode == SyncHeaderFirst && !bc.HasHeader(block.Hash(), block.NumberU64()) {
+			utils.GetLogInstance().Warn("[SYNC] downloaded block has no verified header", "blockHeight", block.NumberU64(), "blockHash", block.Hash())
+			break
+		}
 		ok := ss.updateBlockAndStatus(block, bc, worker)
 		if !ok {
 			break
@@ -539,6 +590,15 @@ func (ss *StateSync) ProcessStateSync(startHash []byte, bc *core.BlockChain, wor
 		utils.GetLogInstance().Debug("[SYNC] ProcessStateSync unable to reach consensus on ss.GetConsensusHashes")
 		return
 	}
+	if ss.Mode != SyncFull {
+		if err := ss.syncHeaders(bc); err != nil {
+			ctxerror.Log15(utils.GetLogInstance().Warn, err)
+			return
+		}
+		if ss.Mode == SyncHeadersOnly {
+			return
+		}
+	}
 	ss.generateStateSyncTaskQueue(bc)
 	// Download blocks.
 	if ss.stateSyncTaskQueue.Len() > 0 {
@@ -611,14 +671,14 @@ func (ss *StateSync) getMaxPeerHeight() uint64 {
 // IsSameBlockchainHeight checks whether the node is out of sync from other peers
 func (ss *StateSync) IsSameBlockchainHeight(bc *core.BlockChain) (uint64, bool) {
 	otherHeight := ss.getMaxPeerHeight()
-	currentHeight := bc.CurrentBlock().NumberU64()
+	currentHeight := ss.currentHeight(bc)
 	return otherHeight, currentHeight == otherHeight
 }
 
 // IsOutOfSync checks whether the node is out of sync from other peers
 func (ss *StateSync) IsOutOfSync(bc *core.BlockChain) bool {
 	otherHeight := ss.getMaxPeerHeight()
-	currentHeight := bc.CurrentBlock().NumberU64()
+	currentHeight := ss.currentHeight(bc)
 	utils.GetLogInstance().Debug("[SYNC] IsOutOfSync", "otherHeight", otherHeight, "myHeight", currentHeight)
 	return currentHeight+inSyncThreshold < otherHeight
 }
@@ -630,7 +690,7 @@ func (ss *StateSync) SyncLoop(bc *core.BlockChain, worker *worker.Worker, willJo
 			utils.GetLogInstance().Info("[SYNC] Node is now IN SYNC!")
 			return
 		}
-		startHash := bc.CurrentBlock().Hash()
+		startHash := ss.currentHash(bc)
 		ss.ProcessStateSync(startHash[:], bc, worker, isBeacon)
 	}
 }
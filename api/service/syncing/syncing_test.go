@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/harmony-one/harmony/api/service/syncing/downloader"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/crypto/bls"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -46,3 +48,18 @@ func TestCreateStateSync(t *testing.T) {
 		t.Error("Unable to create stateSync")
 	}
 }
+
+func TestCommitteeKeys(t *testing.T) {
+	pubKey := bls.RandPrivateKey().GetPublicKey()
+	nodeID := types.NodeID{}
+	copy(nodeID.BlsPublicKey[:], pubKey.Serialize())
+	shardState := types.ShardState{{ShardID: 1, NodeList: types.NodeIDList{nodeID}}}
+
+	pubKeys, err := committeeKeys(shardState, 1)
+	assert.NoError(t, err)
+	assert.Len(t, pubKeys, 1)
+	assert.True(t, pubKey.IsEqual(pubKeys[0]))
+
+	_, err = committeeKeys(shardState, 0)
+	assert.Error(t, err)
+}
@@ -255,10 +255,10 @@ func SendTxsToShard(clientNode *node.Node, txs types.Transactions, shardID uint3
 	msg := proto_node.ConstructTransactionListMessageAccount(txs)
 	var err error
 	if shardID == 0 {
-		err = clientNode.GetHost().SendMessageToGroups([]p2p.GroupID{p2p.GroupIDBeaconClient}, p2p_host.ConstructP2pMessage(byte(0), msg))
+		err = clientNode.GetHost().SendMessageToGroups([]p2p.GroupID{p2p.GroupIDBeaconClient}, p2p_host.ConstructP2pMessage(msg))
 	} else {
 		clientGroup := p2p.NewClientGroupIDByShardID(p2p.ShardID(shardID))
-		err = clientNode.GetHost().SendMessageToGroups([]p2p.GroupID{clientGroup}, p2p_host.ConstructP2pMessage(byte(0), msg))
+		err = clientNode.GetHost().SendMessageToGroups([]p2p.GroupID{clientGroup}, p2p_host.ConstructP2pMessage(msg))
 	}
 	if err != nil {
 		utils.GetLogInstance().Debug("Error in Sending Txns", "Err", err)
@@ -591,7 +591,7 @@ func submitTransaction(tx *types.Transaction, walletNode *node.Node, shardID uin
 	msg := proto_node.ConstructTransactionListMessageAccount(types.Transactions{tx})
 	clientGroup := p2p.NewClientGroupIDByShardID(p2p.ShardID(shardID))
 
-	err := walletNode.GetHost().SendMessageToGroups([]p2p.GroupID{clientGroup}, p2p_host.ConstructP2pMessage(byte(0), msg))
+	err := walletNode.GetHost().SendMessageToGroups([]p2p.GroupID{clientGroup}, p2p_host.ConstructP2pMessage(msg))
 	if err != nil {
 		fmt.Printf("Error in SubmitTransaction: %v\n", err)
 		return err
@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
 	"encoding/hex"
 	"flag"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"os"
+	"os/signal"
 	"path"
 	"runtime"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -15,6 +20,8 @@ import (
 	"github.com/harmony-one/bls/ffi/go/bls"
 	"github.com/harmony-one/harmony/accounts"
 	"github.com/harmony-one/harmony/accounts/keystore"
+	"github.com/harmony-one/harmony/api/service/networkinfo"
+	"github.com/harmony-one/harmony/api/service/syncing"
 	"github.com/harmony-one/harmony/consensus"
 	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/drand"
@@ -27,9 +34,14 @@ import (
 	"github.com/harmony-one/harmony/internal/utils/contract"
 	"github.com/harmony-one/harmony/node"
 	"github.com/harmony-one/harmony/p2p"
+	"github.com/harmony-one/harmony/p2p/host/hostv2"
 	"github.com/harmony-one/harmony/p2p/p2pimpl"
+	libp2p_peerstore "github.com/libp2p/go-libp2p-peerstore"
 )
 
+// shutdownTimeout bounds how long the node waits for its consensus to stop on exit.
+const shutdownTimeout = 10 * time.Second
+
 var (
 	version string
 	builtBy string
@@ -117,6 +129,73 @@ var (
 	// Disable view change.
 	disableViewChange = flag.Bool("disable_view_change", false,
 		"Do not propose view change (testing only)")
+
+	// Enable the attack model.
+	enableAttackModel = flag.Bool("enable_attack_model", false,
+		"Run the attack model hooks in consensus (testing only)")
+
+	// Record the consensus rounds.
+	transcriptFile = flag.String("transcript_file", "",
+		"If set, appends the consensus messages of every round to this file, for cmd/replay")
+
+	// Keep the signed views across restarts.
+	signStateFile = flag.String("sign_state_file", "",
+		"If set, saves the highest view signed by each key to this file and never signs a view at or below it again")
+
+	// What state sync downloads.
+	syncMode = flag.String("sync_mode", "full",
+		"full: download and execute the blocks; header_first: verify the headers before downloading the blocks; headers_only: verify the headers and skip the blocks")
+
+	// Heartbeat blocks of idle shards.
+	emptyBlockPeriod = flag.Duration("empty_block_period", node.BlockPeriod,
+		"How long after its last block the leader proposes a block without enough transactions, possibly empty; 0 waits for transactions")
+
+	// Pruning of old blocks.
+	blockRetention = flag.Uint64("block_retention", 0,
+		"If set, keeps the bodies and receipts of this many recent blocks only, and the headers of all; 0 keeps the whole history")
+
+	// Peer discovery.
+	targetPeers = flag.Int("target_peers", networkinfo.TargetPeerCount,
+		"The number of connected peers of its shard group the node keeps redialing and discovering; 0 for no target")
+
+	// Bootstrapping when no bootnode can be reached.
+	networkType = flag.String("network_type", "localnet",
+		"The network the node joins, for its DNS and fallback seeds: mainnet, testnet or localnet")
+	dnsSeeds = flag.String("dns_seeds", "",
+		"If set, the domains listing the bootnodes in their TXT and SRV records, instead of those of the network (delimited by ,)")
+	seedSigner = flag.String("seed_signer", "",
+		"If set, the address of the key the DNS seed lists must be signed with, instead of that of the network")
+
+	// Peer reputation.
+	banDuration = flag.Duration("ban_duration", p2p.DefaultBanDuration,
+		"How long a misbehaving peer is first banned for; each further ban of the peer lasts this much longer")
+
+	// Message rate limits.
+	msgRateLimits = flag.String("msg_rate_limits", "",
+		"Messages per second and burst each peer may send per kind, e.g. default=50:100,consensus=100:200,node/0=20:40; a rate of 0 is no limit")
+
+	// NAT traversal.
+	natPortMap = flag.Bool("nat_port_map", false,
+		"Ask the gateway to forward the port to the node, over UPnP or NAT-PMP")
+	relayHop = flag.Bool("relay_hop", false,
+		"Relay the connections of the peers behind a NAT; needs a public address")
+	relays utils.AddrList
+
+	// Transport security.
+	p2pInsecure = flag.Bool("p2p.insecure", false,
+		"Do not encrypt nor authenticate the connections to the peers, for local test networks only")
+
+	// Peer access rules.
+	accessRulesFile = flag.String("access_rules", "",
+		"If set, a JSON file of the peers the node connects with, e.g. {\"allowNets\":[\"10.0.0.0/8\"],\"denyPeers\":[\"Qm...\"]}; the rules can be changed at runtime with admin_setAccessRules on localhost")
+
+	// Connection limits.
+	connProfile = flag.String("conn_profile", "medium",
+		"The peer connection limits for the size of the deployment: small, medium or large; empty for no limits")
+
+	// Traffic metrics.
+	prometheusAddr = flag.String("prometheus_addr", "",
+		"If set, serves the p2p traffic metrics to Prometheus at /metrics on this address, e.g. 127.0.0.1:9900")
 )
 
 func initSetup() {
@@ -151,6 +230,27 @@ func initSetup() {
 		}
 		utils.BootNodes = bootNodeAddrs
 	}
+	networkinfo.TargetPeerCount = *targetPeers
+	seeds, ok := networkinfo.Networks[*networkType]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown -network_type: %s\n", *networkType)
+		os.Exit(1)
+	}
+	if *dnsSeeds != "" {
+		seeds.DNS = strings.Split(*dnsSeeds, ",")
+	}
+	if *seedSigner != "" {
+		seeds.Signer = *seedSigner
+	}
+	if _, err := networkinfo.NewSeedResolver(seeds); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid -seed_signer: %v\n", err)
+		os.Exit(1)
+	}
+	if seeds.RequireSigned && seeds.Signer == "" && len(seeds.DNS) > 0 {
+		fmt.Fprintf(os.Stderr, "-dns_seeds requires -seed_signer on %s, whose seed lists must be signed\n", *networkType)
+		os.Exit(1)
+	}
+	networkinfo.Seeds = seeds
 
 	ks = hmykey.GetHmyKeyStore()
 
@@ -215,6 +315,7 @@ func createGlobalConfig() *nodeconfig.ConfigType {
 
 	// The initial genesis nodes are sequentially put into genesis shards based on their accountIndex
 	nodeConfig.ShardID = myShardID
+	nodeConfig.NetworkID = nodeconfig.NetworkIDs[*networkType]
 
 	// Key Setup ================= [Start]
 	consensusPriKey := &bls.SecretKey{}
@@ -253,13 +354,62 @@ func createGlobalConfig() *nodeconfig.ConfigType {
 		nodeConfig.StringRole = "validator"
 	}
 
-	nodeConfig.Host, err = p2pimpl.NewHost(&nodeConfig.SelfPeer, nodeConfig.P2pPriKey)
+	hostConfig := hostv2.Config{
+		NAT:                hostv2.NATConfig{PortMap: *natPortMap, RelayHop: *relayHop},
+		Insecure:           *p2pInsecure,
+		CompressionFlagDay: hostv2.CompressionFlagDays[*networkType],
+		ExpiryFlagDay:      hostv2.ExpiryFlagDays[*networkType],
+	}
+	for _, addr := range relays {
+		relay, err := libp2p_peerstore.InfoFromP2pAddr(addr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid relay %s: %v\n", addr, err)
+			os.Exit(1)
+		}
+		hostConfig.NAT.Relays = append(hostConfig.NAT.Relays, *relay)
+	}
+	if *accessRulesFile != "" {
+		hostConfig.Access, err = p2p.LoadAccessRules(*accessRulesFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid -access_rules: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	nodeConfig.Host, err = p2pimpl.NewHostWithConfig(&nodeConfig.SelfPeer, nodeConfig.P2pPriKey, hostConfig)
 	if *logConn {
 		nodeConfig.Host.GetP2PHost().Network().Notify(utils.ConnLogger)
 	}
 	if err != nil {
 		panic("unable to new host in harmony")
 	}
+	if host, ok := nodeConfig.Host.(*hostv2.HostV2); ok {
+		budgets, err := p2p.ParseRateBudgets(*msgRateLimits)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid -msg_rate_limits: %v\n", err)
+			os.Exit(1)
+		}
+		rateLimiter := p2p.NewRateLimiter(node.MessageKind)
+		for kind, budget := range budgets {
+			if kind == "default" {
+				rateLimiter.DefaultBudget = budget
+			} else {
+				rateLimiter.SetBudget(kind, budget)
+			}
+		}
+		host.SetRateLimiter(rateLimiter)
+
+		bandwidth := p2p.NewBandwidthCounter(node.MessageKind)
+		host.SetBandwidthCounter(bandwidth)
+		if *prometheusAddr != "" {
+			go func() {
+				mux := http.NewServeMux()
+				mux.Handle("/metrics", bandwidth)
+				if err := http.ListenAndServe(*prometheusAddr, mux); err != nil {
+					utils.GetLogInstance().Error("Cannot serve Prometheus metrics", "addr", *prometheusAddr, "error", err)
+				}
+			}()
+		}
+	}
 
 	nodeConfig.Host.AddPeer(&nodeConfig.Leader)
 
@@ -278,15 +428,61 @@ func setUpConsensusAndNode(nodeConfig *nodeconfig.ConfigType) *node.Node {
 		os.Exit(1)
 	}
 	currentConsensus.MinPeers = *minPeers
+	currentConsensus.NonceFlagDay = consensus.NonceFlagDays[*networkType]
 	if *disableViewChange {
 		currentConsensus.DisableViewChangeForTestingOnly()
 	}
+	currentConsensus.EnableAttackModel = *enableAttackModel
+	if *transcriptFile != "" {
+		transcript, err := consensus.OpenTranscriptFile(*transcriptFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot open the transcript file: %v\n", err)
+			os.Exit(1)
+		}
+		currentConsensus.Transcript = transcript
+	}
+	if *signStateFile != "" {
+		signState, err := consensus.LoadSignState(*signStateFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot load the sign state file: %v\n", err)
+			os.Exit(1)
+		}
+		currentConsensus.SignState = signState
+	}
 
 	// Current node.
 	chainDBFactory := &shardchain.LDBFactory{RootDir: nodeConfig.DBDir}
 	currentNode := node.New(nodeConfig.Host, currentConsensus, chainDBFactory, *isArchival)
 	currentNode.NodeConfig.SetRole(nodeconfig.NewNode)
 	currentNode.StakingAccount = myAccount
+	currentNode.EmptyBlockPeriod = *emptyBlockPeriod
+	currentNode.Reputation.BanDuration = *banDuration
+	switch *syncMode {
+	case "full":
+		currentNode.SyncMode = syncing.SyncFull
+	case "header_first":
+		currentNode.SyncMode = syncing.SyncHeaderFirst
+	case "headers_only":
+		currentNode.SyncMode = syncing.SyncHeadersOnly
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown sync mode: %v\n", *syncMode)
+		os.Exit(1)
+	}
+	if *blockRetention != 0 {
+		if *isArchival {
+			fmt.Fprintf(os.Stderr, "An archival node cannot prune blocks\n")
+			os.Exit(1)
+		}
+		currentNode.SetBlockRetention(*blockRetention)
+	}
+	if *connProfile != "" {
+		connConfig, err := p2p.ParseConnManagerProfile(*connProfile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid -conn_profile: %v\n", err)
+			os.Exit(1)
+		}
+		currentNode.StartConnManager(connConfig)
+	}
 	utils.GetLogInstance().Info("node account set",
 		"address", currentNode.StakingAccount.Address.Hex())
 
@@ -364,15 +560,19 @@ func setUpConsensusAndNode(nodeConfig *nodeconfig.ConfigType) *node.Node {
 		currentNode.InitShardState(*shardID == -1 && !*isNewNode) // TODO: Have a better why to distinguish non-genesis node
 	}
 
-	// Set the consensus ID to be the current block number
+	// Resume consensus after the current block
 	height := currentNode.Blockchain().CurrentBlock().NumberU64()
-
-	currentConsensus.SetViewID(uint32(height))
+	if err := currentConsensus.InitFromChainHead(currentNode.Blockchain()); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Cannot resume consensus from the chain head: %v\n", err)
+		os.Exit(1)
+	}
 	utils.GetLogInstance().Info("Init Blockchain", "height", height)
 
 	// Assign closure functions to the consensus object
 	currentConsensus.BlockVerifier = currentNode.VerifyNewBlock
 	currentConsensus.OnConsensusDone = currentNode.PostConsensusProcessing
+	currentConsensus.Proposer = currentNode
+	currentConsensus.RequestMissingBlock = currentConsensus.SendBlockRequest
 	currentNode.State = node.NodeWaitToJoin
 
 	// Watching currentNode and currentConsensus.
@@ -383,6 +583,7 @@ func setUpConsensusAndNode(nodeConfig *nodeconfig.ConfigType) *node.Node {
 
 func main() {
 	flag.Var(&utils.BootNodes, "bootnodes", "a list of bootnode multiaddress (delimited by ,)")
+	flag.Var(&relays, "relays", "a list of relay multiaddress the node is reachable through when behind a NAT (delimited by ,)")
 	flag.Parse()
 
 	// Configure log parameters
@@ -390,6 +591,7 @@ func main() {
 	utils.SetLogVerbosity(log.Lvl(*verbosity))
 
 	initSetup()
+	nodeconfig.Version = fmt.Sprintf("Harmony/%v-%v/%v-%v/%v", version, commit, runtime.GOOS, runtime.GOARCH, runtime.Version())
 	nodeConfig := createGlobalConfig()
 	initLogFile(*logFolder, nodeConfig.StringRole, *ip, *port, *onlyLogTps)
 
@@ -402,6 +604,9 @@ func main() {
 		}
 	}
 	currentNode := setUpConsensusAndNode(nodeConfig)
+	if host, ok := nodeConfig.Host.(*hostv2.HostV2); ok {
+		host.SetReputation(currentNode.Reputation)
+	}
 	//if consensus.ShardID != 0 {
 	//	go currentNode.SupportBeaconSyncing()
 	//}
@@ -421,5 +626,13 @@ func main() {
 	currentNode.ServiceManagerSetup()
 	currentNode.StartRPC(*port)
 	currentNode.RunServices()
-	currentNode.StartServer()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := currentNode.Stop(ctx); err != nil {
+		utils.GetLogInstance().Warn("Node did not stop cleanly", "error", err)
+	}
 }
@@ -0,0 +1,139 @@
+// replay feeds a consensus transcript recorded with -transcript_file back through the
+// validator, to audit or debug a faulty round offline.
+
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/harmony-one/bls/ffi/go/bls"
+	libp2p_host "github.com/libp2p/go-libp2p-host"
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+
+	"github.com/harmony-one/harmony/consensus"
+	"github.com/harmony-one/harmony/core/types"
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/p2p"
+)
+
+// replayHost is a p2p.Host which drops the messages the replayed validator sends.
+type replayHost struct {
+	self p2p.Peer
+}
+
+func (host replayHost) GetSelfPeer() p2p.Peer                                 { return host.self }
+func (replayHost) Close() error                                               { return nil }
+func (replayHost) AddPeer(*p2p.Peer) error                                    { return nil }
+func (replayHost) GetID() libp2p_peer.ID                                      { return "" }
+func (replayHost) GetP2PHost() libp2p_host.Host                               { return nil }
+func (replayHost) GetPeerCount() int                                          { return 0 }
+func (replayHost) ConnectHostPeer(p2p.Peer)                                   {}
+func (replayHost) SendMessageToGroups(groups []p2p.GroupID, msg []byte) error { return nil }
+func (replayHost) SendMessageToPeer(libp2p_peer.ID, []byte) error             { return nil }
+func (replayHost) DirectReceiver() p2p.GroupReceiver                          { return nil }
+func (replayHost) GroupReceiver(p2p.GroupID) (p2p.GroupReceiver, error) {
+	return nil, fmt.Errorf("replay host does not receive messages")
+}
+
+// replayChainReader is a chain with every parent block, so that the announced blocks
+// are checked for their signatures and rounds only, without the state of the shard.
+type replayChainReader struct {
+	number uint64
+}
+
+func (replayChainReader) Config() *params.ChainConfig { return nil }
+func (reader replayChainReader) CurrentHeader() *types.Header {
+	return &types.Header{Number: new(big.Int).SetUint64(reader.number)}
+}
+func (replayChainReader) GetHeader(hash common.Hash, number uint64) *types.Header {
+	return &types.Header{Number: new(big.Int).SetUint64(number)}
+}
+func (replayChainReader) GetHeaderByNumber(number uint64) *types.Header {
+	return &types.Header{Number: new(big.Int).SetUint64(number)}
+}
+func (replayChainReader) GetHeaderByHash(hash common.Hash) *types.Header { return &types.Header{} }
+func (replayChainReader) GetBlock(hash common.Hash, number uint64) *types.Block {
+	return nil
+}
+func (replayChainReader) ReadShardState(epoch *big.Int) (types.ShardState, error) {
+	return nil, nil
+}
+
+func main() {
+	transcriptFile := flag.String("transcript", "", "the transcript file to replay")
+	committee := flag.String("committee", "", "comma-separated hex BLS public keys of the committee, leader first")
+	fromViewID := flag.Uint("from_view", 0, "the first view to replay")
+	toViewID := flag.Uint("to_view", 0, "the last view to replay; defaults to from_view")
+	blockNum := flag.Uint64("block_num", 0, "the number of the block committed before from_view")
+	flag.Parse()
+
+	if *transcriptFile == "" || *committee == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *toViewID < *fromViewID {
+		*toViewID = *fromViewID
+	}
+
+	var pubKeys []*bls.PublicKey
+	for _, hexKey := range strings.Split(*committee, ",") {
+		pubKey := &bls.PublicKey{}
+		keyBytes, err := hex.DecodeString(strings.TrimSpace(hexKey))
+		if err == nil {
+			err = pubKey.Deserialize(keyBytes)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid committee key %v: %v\n", hexKey, err)
+			os.Exit(1)
+		}
+		pubKeys = append(pubKeys, pubKey)
+	}
+
+	input, err := os.Open(*transcriptFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot open the transcript: %v\n", err)
+		os.Exit(1)
+	}
+	defer input.Close()
+	entries, err := consensus.ReadTranscript(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Transcript corrupted after %v entries: %v\n", len(entries), err)
+	}
+
+	// The replayed validator holds a key of its own, so it only checks the messages of the
+	// round without taking part in it.
+	leader := p2p.Peer{ConsensusPubKey: pubKeys[0]}
+	validator, err := consensus.New(replayHost{self: leader}, 0, leader, bls_cosi.RandPrivateKey())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot create consensus: %v\n", err)
+		os.Exit(1)
+	}
+	validator.UpdatePublicKeys(pubKeys)
+	validator.SetViewID(uint32(*fromViewID))
+	validator.SetBlockNum(*blockNum)
+	validator.ChainReader = replayChainReader{number: *blockNum}
+	validator.NumBlockVerifiers = 0
+	validator.OnConsensusDone = func(block *types.Block) {
+		fmt.Printf("committed block %v %v with %v transactions\n", block.NumberU64(), block.Hash().Hex(), len(block.Transactions()))
+	}
+	validator.RejectionChan = make(chan consensus.Rejection, len(entries))
+	validator.DeadLetterChan = make(chan consensus.DeadLetter, len(entries))
+
+	numReplayed := validator.ReplayTranscript(entries, uint32(*fromViewID), uint32(*toViewID))
+	for len(validator.RejectionChan) > 0 {
+		rejection := <-validator.RejectionChan
+		fmt.Printf("rejected %v of view %v: %v\n", rejection.Type, rejection.ViewID, rejection.Err)
+	}
+	for len(validator.DeadLetterChan) > 0 {
+		deadLetter := <-validator.DeadLetterChan
+		fmt.Printf("dropped %v: %v\n", deadLetter.Type, deadLetter.Err)
+	}
+	fmt.Printf("replayed %v of %v messages, ended at view %v\n", numReplayed, len(entries), validator.GetViewID())
+}
@@ -0,0 +1,94 @@
+package consensus
+
+import (
+	"bytes"
+
+	protobuf "github.com/golang/protobuf/proto"
+	"github.com/harmony-one/bls/ffi/go/bls"
+
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	"github.com/harmony-one/harmony/internal/utils"
+)
+
+// BadLeaderReport tells that the validator keeps rejecting the messages of a leader, for
+// the node to start a view change or deprioritize the peer. It is sent for every fault
+// once Total reaches badLeaderReportThreshold, until the leader commits a block.
+type BadLeaderReport struct {
+	Leader *bls.PublicKey
+	ViewID uint32
+	// Counts has the number of rejected messages for each reason, e.g. ErrInvalidBlock,
+	// since the leader last committed a block
+	Counts map[error]int
+	Total  int
+	// The reason of the last rejection
+	Err error
+}
+
+// isLeaderFault returns whether the leader is to blame for a message rejected with err,
+// as opposed to the message being late, early or sent by another node.
+func isLeaderFault(err error) bool {
+	switch err {
+	case ErrMalformedMessage, ErrBadSignature, ErrNoQuorum, ErrInvalidBlock, ErrEquivocation:
+		return true
+	}
+	return false
+}
+
+// recordLeaderFault counts a message of the leader the validator rejected, and reports
+// the leader to BadLeaderChan if it failed too many times. Only the messages signed by the
+// current leader count, as any peer can send a message in the name of the leader.
+// The caller must hold consensus.mutex.
+func (consensus *Consensus) recordLeaderFault(message *msg_pb.Message, err error) {
+	consensusMsg := message.GetConsensus()
+	if consensusMsg == nil || !isLeaderFault(err) {
+		return
+	}
+	leader := consensus.leader.ConsensusPubKey
+	if leader == nil || !bytes.Equal(consensusMsg.SenderPubkey, leader.Serialize()) {
+		return
+	}
+	if verifyMessageSig(leader, protobuf.Clone(message).(*msg_pb.Message)) != nil {
+		return
+	}
+	consensus.leaderFaultsMutex.Lock()
+	defer consensus.leaderFaultsMutex.Unlock()
+	if !bytes.Equal(consensus.faultyLeader, consensusMsg.SenderPubkey) {
+		consensus.faultyLeader = append(consensusMsg.SenderPubkey[:0:0], consensusMsg.SenderPubkey...)
+		consensus.leaderFaults = map[error]int{}
+		consensus.numLeaderFaults = 0
+	}
+	consensus.leaderFaults[err]++
+	consensus.numLeaderFaults++
+	if consensus.numLeaderFaults < badLeaderReportThreshold {
+		return
+	}
+
+	utils.GetLogInstance().Warn("Leader keeps sending bad messages", "viewID", consensusMsg.ViewId, "numFaults", consensus.numLeaderFaults, "error", err)
+	if consensus.BadLeaderChan == nil {
+		return
+	}
+	report := BadLeaderReport{
+		Leader: leader,
+		ViewID: consensusMsg.ViewId,
+		Counts: map[error]int{},
+		Total:  consensus.numLeaderFaults,
+		Err:    err,
+	}
+	for reason, count := range consensus.leaderFaults {
+		report.Counts[reason] = count
+	}
+	select {
+	case consensus.BadLeaderChan <- report:
+	default:
+		utils.GetLogInstance().Info("bad leader report send to chan failed", "viewID", report.ViewID)
+	}
+}
+
+// resetLeaderFaults forgets the faults of the leader once it commits a block.
+func (consensus *Consensus) resetLeaderFaults() {
+	consensus.leaderFaultsMutex.Lock()
+	defer consensus.leaderFaultsMutex.Unlock()
+	consensus.faultyLeader = nil
+	consensus.leaderFaults = nil
+	consensus.numLeaderFaults = 0
+}
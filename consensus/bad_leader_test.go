@@ -0,0 +1,72 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	protobuf "github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/p2p"
+)
+
+func TestBadLeaderReport(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.BadLeaderChan = make(chan BadLeaderReport, 1)
+
+	// Messages sent in the name of the leader, or by another validator, are not the leader's fault.
+	otherPriKey := bls_cosi.RandPrivateKey()
+	for i := 0; i < badLeaderReportThreshold; i++ {
+		forged := protobuf.Clone(round.announce).(*msg_pb.Message)
+		resignTestMessage(test, forged, otherPriKey)
+		payload, err := protobuf.Marshal(forged)
+		if err != nil {
+			test.Fatalf("Cannot marshal message: %v", err)
+		}
+		consensusValidator.ProcessMessageValidator(payload)
+		consensusValidator.reportRejection(forged, ErrBadSignature)
+
+		other := protobuf.Clone(round.announce).(*msg_pb.Message)
+		other.GetConsensus().SenderPubkey = otherPriKey.GetPublicKey().Serialize()
+		resignTestMessage(test, other, otherPriKey)
+		consensusValidator.reportRejection(other, ErrInvalidBlock)
+	}
+	assert.Empty(test, consensusValidator.BadLeaderChan)
+	assert.Equal(test, 0, consensusValidator.numLeaderFaults)
+
+	for i := 1; i < badLeaderReportThreshold; i++ {
+		consensusValidator.reportRejection(round.announce, ErrInvalidBlock)
+	}
+	// Late messages are not the leader's fault.
+	consensusValidator.viewID = 1
+	consensusValidator.reportRejection(round.announce, consensusValidator.processAnnounceMessage(round.announce))
+	consensusValidator.viewID = 0
+	assert.Empty(test, consensusValidator.BadLeaderChan)
+
+	consensusValidator.reportRejection(round.announce, ErrInvalidBlock)
+	select {
+	case report := <-consensusValidator.BadLeaderChan:
+		assert.True(test, report.Leader.IsEqual(leader.ConsensusPubKey))
+		assert.Equal(test, uint32(0), report.ViewID)
+		assert.Equal(test, badLeaderReportThreshold, report.Total)
+		assert.Equal(test, map[error]int{ErrInvalidBlock: badLeaderReportThreshold}, report.Counts)
+		assert.Equal(test, ErrInvalidBlock, report.Err)
+	default:
+		test.Fatal("no bad leader report")
+	}
+
+	// Committing a block clears the faults of the leader.
+	assert.NoError(test, consensusValidator.processAnnounceMessage(round.announce))
+	assert.NoError(test, consensusValidator.processPreparedMessage(round.prepared))
+	assert.NoError(test, consensusValidator.processCommittedMessage(round.committed))
+	assert.Equal(test, 0, consensusValidator.numLeaderFaults)
+}
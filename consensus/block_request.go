@@ -0,0 +1,247 @@
+package consensus
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/harmony-one/bls/ffi/go/bls"
+
+	"github.com/harmony-one/harmony/api/proto"
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	"github.com/harmony-one/harmony/core/types"
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/internal/ctxerror"
+	"github.com/harmony-one/harmony/internal/utils"
+)
+
+// SendBlockRequest asks the leader for the committed block following the local chain
+// head, which the node misses to commit the given view. It can be set as RequestMissingBlock.
+func (consensus *Consensus) SendBlockRequest(viewID uint32) {
+	consensus.mutex.Lock()
+	if consensus.ChainReader == nil {
+		consensus.mutex.Unlock()
+		return
+	}
+	blockNum := consensus.ChainReader.CurrentHeader().Number.Uint64() + 1
+	msgToSend := consensus.constructBlockRequestMessage(viewID, blockNum)
+	consensus.mutex.Unlock()
+
+	utils.GetLogInstance().Info("[Consensus]", "sent block request", len(msgToSend), "viewID", viewID, "blockNum", blockNum)
+	consensus.sendMessage(msgToSend)
+}
+
+// constructBlockRequestMessage constructs the request for the committed block of blockNum.
+func (consensus *Consensus) constructBlockRequestMessage(viewID uint32, blockNum uint64) []byte {
+	message := &msg_pb.Message{
+		ServiceType: msg_pb.ServiceType_CONSENSUS,
+		Type:        msg_pb.MessageType_BLOCK_REQUEST,
+		Request: &msg_pb.Message_Consensus{
+			Consensus: &msg_pb.ConsensusRequest{},
+		},
+	}
+	consensusMsg := message.GetConsensus()
+	consensus.populateMessageFields(consensusMsg)
+	consensusMsg.ViewId = viewID
+	consensusMsg.BlockNum = blockNum
+	consensusMsg.BlockHash = nil
+
+	marshaledMessage, err := consensus.signAndMarshalConsensusMessage(message)
+	if err != nil {
+		utils.GetLogInstance().Error("Failed to sign and marshal the BlockRequest message", "error", err)
+	}
+	return proto.ConstructConsensusMessage(marshaledMessage)
+}
+
+// constructBlockResponseMessage constructs the response carrying the committed block
+// requested for viewID.
+func (consensus *Consensus) constructBlockResponseMessage(viewID uint32, block *types.Block) ([]byte, error) {
+	encodedBlock, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		return nil, ctxerror.New("cannot encode the requested block").WithCause(err)
+	}
+	message := &msg_pb.Message{
+		ServiceType: msg_pb.ServiceType_CONSENSUS,
+		Type:        msg_pb.MessageType_BLOCK_RESPONSE,
+		Request: &msg_pb.Message_Consensus{
+			Consensus: &msg_pb.ConsensusRequest{},
+		},
+	}
+	consensusMsg := message.GetConsensus()
+	consensus.populateMessageFields(consensusMsg)
+	blockHash := block.Hash()
+	consensusMsg.ViewId = viewID
+	consensusMsg.BlockNum = block.NumberU64()
+	consensusMsg.BlockHash = blockHash[:]
+	consensusMsg.Payload = encodedBlock
+
+	marshaledMessage, err := consensus.signAndMarshalConsensusMessage(message)
+	if err != nil {
+		return nil, err
+	}
+	return proto.ConstructConsensusMessage(marshaledMessage), nil
+}
+
+// onBlockRequest answers a committee member with the committed block it requested.
+func (consensus *Consensus) onBlockRequest(message *msg_pb.Message) {
+	consensusMsg := message.GetConsensus()
+	senderKey, err := bls_cosi.BytesToBlsPublicKey(consensusMsg.SenderPubkey)
+	if err != nil {
+		utils.GetLogInstance().Debug("Failed to deserialize BLS public key", "error", err)
+		return
+	}
+
+	consensus.mutex.Lock()
+	defer consensus.mutex.Unlock()
+
+	if !consensus.IsValidatorInCommittee(utils.GetBlsAddress(senderKey)) {
+		utils.GetLogInstance().Debug("Block request from outside the committee", "sender Address", blsPubKeyToAddress(senderKey))
+		return
+	}
+	if err := verifyMessageSig(senderKey, message); err != nil {
+		utils.GetLogInstance().Debug("Failed to verify the block request signature", "error", err)
+		return
+	}
+	if err := consensus.checkNonce(message, senderKey); err != nil {
+		return
+	}
+	consensus.acceptNonce(message, senderKey)
+	if consensus.ChainReader == nil {
+		return
+	}
+
+	header := consensus.ChainReader.GetHeaderByNumber(consensusMsg.BlockNum)
+	if header == nil {
+		utils.GetLogInstance().Debug("Requested block not committed", "blockNum", consensusMsg.BlockNum)
+		return
+	}
+	block := consensus.ChainReader.GetBlock(header.Hash(), consensusMsg.BlockNum)
+	if block == nil {
+		utils.GetLogInstance().Debug("Requested block not found", "blockNum", consensusMsg.BlockNum)
+		return
+	}
+	msgToSend, err := consensus.constructBlockResponseMessage(consensusMsg.ViewId, block)
+	if err != nil {
+		ctxerror.Log15(utils.GetLogInstance().Warn, err)
+		return
+	}
+	utils.GetLogInstance().Info("[Consensus]", "sent block response", len(msgToSend), "blockNum", consensusMsg.BlockNum)
+	consensus.sendMessage(msgToSend)
+}
+
+// onBlockResponse commits the block the validator requested for its current view, once its
+// prepare and commit signatures are verified, and requests the next one if still behind,
+// up to MaxCatchupBlocks blocks in a row until the next committed message of the leader.
+func (consensus *Consensus) onBlockResponse(message *msg_pb.Message) {
+	consensusMsg := message.GetConsensus()
+	viewID := consensusMsg.ViewId
+
+	consensus.mutex.Lock()
+	defer consensus.mutex.Unlock()
+
+	if !consensus.blockRequests[viewID] || viewID != consensus.viewID {
+		utils.GetLogInstance().Debug("Unexpected block response", "viewID", viewID, "myViewID", consensus.viewID)
+		return
+	}
+	leaderKey := consensus.leader.ConsensusPubKey
+	if !bytes.Equal(consensusMsg.SenderPubkey, leaderKey.Serialize()) {
+		utils.GetLogInstance().Warn("Block response not sent by the leader", "leader Address", blsPubKeyToAddress(leaderKey))
+		return
+	}
+	if err := verifyMessageSig(leaderKey, message); err != nil {
+		utils.GetLogInstance().Debug("Failed to verify the block response signature", "error", err)
+		return
+	}
+	if err := consensus.checkNonce(message, leaderKey); err != nil {
+		return
+	}
+	consensus.acceptNonce(message, leaderKey)
+
+	var blockObj types.Block
+	if err := rlp.DecodeBytes(consensusMsg.Payload, &blockObj); err != nil {
+		utils.GetLogInstance().Warn("Failed to decode the requested block", "error", err, "viewID", viewID)
+		return
+	}
+	if blockObj.ParentHash() != consensus.ChainReader.CurrentHeader().Hash() {
+		utils.GetLogInstance().Debug("Requested block does not extend the chain", "viewID", viewID, "blockNum", blockObj.NumberU64())
+		return
+	}
+	if err := consensus.VerifyHeader(consensus.ChainReader, blockObj.Header(), false); err != nil {
+		utils.GetLogInstance().Debug("Requested block header is not verified", "error", err, "viewID", viewID)
+		return
+	}
+	if err := consensus.verifyBlockSigs(&blockObj); err != nil {
+		ctxerror.Log15(utils.GetLogInstance().Warn, err)
+		return
+	}
+
+	utils.GetLogInstance().Info("Adding requested block to chain", "viewID", viewID, "numTx", len(blockObj.Transactions()))
+	delete(consensus.blocksReceived, viewID)
+	delete(consensus.announceMessages, viewID)
+	consensus.blockHash = [32]byte{}
+	consensus.viewID++
+	consensus.applyValidatorSetUpdate()
+	consensus.OnConsensusDone(&blockObj)
+	consensus.metrics().RoundCompleted()
+	consensus.ResetState()
+	select {
+	case consensus.VerifiedNewBlock <- &blockObj:
+	default:
+		utils.GetLogInstance().Info("[SYNC] consensus verified block send to chan failed", "blockHash", blockObj.Hash())
+	}
+	consensus.pruneSeenMessages()
+	consensus.catchupBlocks++
+	if consensus.MaxCatchupBlocks > 0 && consensus.catchupBlocks >= consensus.MaxCatchupBlocks {
+		utils.GetLogInstance().Info("Catch up limit reached, deferring the remaining blocks", "viewID", consensus.viewID, "numBlocks", consensus.catchupBlocks)
+		return
+	}
+	consensus.requestMissingBlock()
+}
+
+// verifyBlockSigs checks that the prepare and commit signatures carried by a committed
+// block were made by a quorum of the committee.
+func (consensus *Consensus) verifyBlockSigs(block *types.Block) error {
+	return VerifyHeaderSigs(block.Header(), consensus.PublicKeys, consensus.quorumPolicy())
+}
+
+// VerifyHeaderSigs checks that the prepare and commit signatures carried by a committed
+// header were made by a quorum of committee, so that a header can be trusted without its body.
+func VerifyHeaderSigs(header *types.Header, committee []*bls.PublicKey, quorum Quorum) error {
+	unsignedHeader := types.CopyHeader(header)
+	unsignedHeader.PrepareSignature = [48]byte{}
+	unsignedHeader.PrepareBitmap = nil
+	unsignedHeader.CommitSignature = [48]byte{}
+	unsignedHeader.CommitBitmap = nil
+	blockHash := unsignedHeader.Hash()
+
+	if err := verifyGroupSig(committee, quorum, header.PrepareSignature[:], header.PrepareBitmap, blockHash[:]); err != nil {
+		return ctxerror.New("invalid prepare signature", "blockHash", blockHash).WithCause(err)
+	}
+	prepareSigAndBitmap := append(header.PrepareSignature[:], header.PrepareBitmap...)
+	if err := verifyGroupSig(committee, quorum, header.CommitSignature[:], header.CommitBitmap, prepareSigAndBitmap); err != nil {
+		return ctxerror.New("invalid commit signature", "blockHash", blockHash).WithCause(err)
+	}
+	return nil
+}
+
+// verifyGroupSig checks that sig is the aggregated signature on hash of a quorum of
+// committee, whose members are given by bitmap.
+func verifyGroupSig(committee []*bls.PublicKey, quorum Quorum, sig []byte, bitmap []byte, hash []byte) error {
+	mask, err := bls_cosi.NewMask(committee, nil)
+	if err != nil {
+		return err
+	}
+	if err := mask.SetMask(bitmap); err != nil {
+		return err
+	}
+	if !quorum.IsAchieved(mask) {
+		return ctxerror.New("not enough signers", "numSigners", mask.CountEnabled())
+	}
+	var groupSig bls.Sign
+	if err := groupSig.Deserialize(sig); err != nil {
+		return err
+	}
+	if !groupSig.VerifyHash(mask.AggregatePublic, hash) {
+		return ctxerror.New("signature verification failed")
+	}
+	return nil
+}
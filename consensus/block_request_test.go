@@ -0,0 +1,186 @@
+package consensus
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/golang/mock/gomock"
+	"github.com/harmony-one/bls/ffi/go/bls"
+	"github.com/stretchr/testify/assert"
+
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	"github.com/harmony-one/harmony/core/types"
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/p2p"
+	mock_host "github.com/harmony-one/harmony/p2p/host/mock"
+)
+
+// blockChainReader is a headChainReader holding a single committed block.
+type blockChainReader struct {
+	headChainReader
+	block *types.Block
+}
+
+func (reader blockChainReader) GetHeaderByNumber(number uint64) *types.Header {
+	if number != reader.block.NumberU64() {
+		return nil
+	}
+	return reader.block.Header()
+}
+
+func (reader blockChainReader) GetBlock(hash common.Hash, number uint64) *types.Block {
+	return reader.block
+}
+
+// testCommittedBlock returns the block following the head of headChainReader, with the
+// prepare and commit signatures of the given signers of the committee.
+func testCommittedBlock(test *testing.T, pubKeys []*bls.PublicKey, signers []*bls.SecretKey) *types.Block {
+	block := types.NewBlock(&types.Header{
+		Number:     big.NewInt(1),
+		ParentHash: headChainReader{}.CurrentHeader().Hash(),
+		Time:       big.NewInt(0),
+	}, nil, nil)
+	blockHash := block.Header().Hash()
+
+	mask, err := bls_cosi.NewMask(pubKeys, nil)
+	if err != nil {
+		test.Fatalf("Cannot create mask: %v", err)
+	}
+	var prepareSigs, commitSigs []*bls.Sign
+	for _, priKey := range signers {
+		mask.SetKey(priKey.GetPublicKey(), true)
+		prepareSigs = append(prepareSigs, priKey.SignHash(blockHash[:]))
+	}
+	prepareSig := bls_cosi.AggregateSig(prepareSigs).Serialize()
+	prepareSigAndBitmap := append(prepareSig, mask.Bitmap...)
+	for _, priKey := range signers {
+		commitSigs = append(commitSigs, priKey.SignHash(prepareSigAndBitmap))
+	}
+	block.SetPrepareSig(prepareSig, mask.Bitmap)
+	block.SetCommitSig(bls_cosi.AggregateSig(commitSigs).Serialize(), mask.Bitmap)
+	return block
+}
+
+// newTestMember returns a member of the committee of the given keys, with the messages it sends.
+func newTestMember(test *testing.T, ctrl *gomock.Controller, leader p2p.Peer, priKey *bls.SecretKey, pubKeys []*bls.PublicKey) (*Consensus, *[]*msg_pb.Message) {
+	m := mock_host.NewMockHost(ctrl)
+	m.EXPECT().GetSelfPeer().Return(leader)
+	member, err := New(m, 0, leader, priKey)
+	if err != nil {
+		test.Fatalf("Cannot craeate consensus: %v", err)
+	}
+	member.UpdatePublicKeys(pubKeys)
+	member.ChainReader = headChainReader{}
+	sent := &[]*msg_pb.Message{}
+	m.EXPECT().SendMessageToGroups(gomock.Any(), gomock.Any()).Do(func(groupIDs []p2p.GroupID, msg []byte) {
+		*sent = append(*sent, testConsensusMessage(test, msg[5:]))
+	}).AnyTimes()
+	return member, sent
+}
+
+func TestBlockRequest(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	validatorPriKey := bls_cosi.RandPrivateKey()
+	pubKeys := []*bls.PublicKey{leader.ConsensusPubKey, validatorPriKey.GetPublicKey()}
+	block := testCommittedBlock(test, pubKeys, []*bls.SecretKey{leaderPriKey, validatorPriKey})
+
+	consensusLeader, leaderSent := newTestMember(test, ctrl, leader, leaderPriKey, pubKeys)
+	consensusLeader.ChainReader = blockChainReader{block: block}
+	consensusValidator, validatorSent := newTestMember(test, ctrl, leader, validatorPriKey, pubKeys)
+	var committed *types.Block
+	consensusValidator.OnConsensusDone = func(newBlock *types.Block) { committed = newBlock }
+
+	// The validator missed the block of view 0, which the leader committed.
+	consensusValidator.blockRequests[0] = true
+	consensusValidator.SendBlockRequest(0)
+	if !assert.Len(test, *validatorSent, 1) {
+		return
+	}
+	request := (*validatorSent)[0]
+	assert.Equal(test, msg_pb.MessageType_BLOCK_REQUEST, request.Type)
+	assert.Equal(test, uint64(1), request.GetConsensus().BlockNum)
+
+	consensusLeader.onBlockRequest(request)
+	if !assert.Len(test, *leaderSent, 1) {
+		return
+	}
+	response := (*leaderSent)[0]
+	assert.Equal(test, msg_pb.MessageType_BLOCK_RESPONSE, response.Type)
+
+	consensusValidator.onBlockResponse(response)
+	if assert.NotNil(test, committed) {
+		assert.Equal(test, block.Hash(), committed.Hash())
+	}
+	assert.Equal(test, uint32(1), consensusValidator.GetViewID())
+
+	// A replayed response does not commit the block again.
+	committed = nil
+	consensusValidator.onBlockResponse(response)
+	assert.Nil(test, committed)
+}
+
+func TestBlockRequestCatchupLimit(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	validatorPriKey := bls_cosi.RandPrivateKey()
+	pubKeys := []*bls.PublicKey{leader.ConsensusPubKey, validatorPriKey.GetPublicKey()}
+	block := testCommittedBlock(test, pubKeys, []*bls.SecretKey{leaderPriKey, validatorPriKey})
+
+	consensusLeader, leaderSent := newTestMember(test, ctrl, leader, leaderPriKey, pubKeys)
+	consensusLeader.ChainReader = blockChainReader{block: block}
+	consensusValidator, validatorSent := newTestMember(test, ctrl, leader, validatorPriKey, pubKeys)
+	consensusValidator.OnConsensusDone = func(*types.Block) {}
+	var requested []uint32
+	consensusValidator.RequestMissingBlock = func(viewID uint32) { requested = append(requested, viewID) }
+	consensusValidator.MaxCatchupBlocks = 1
+
+	// The leader committed up to view 3, the validator is still at view 0.
+	consensusValidator.highestCommittedViewID = 3
+	consensusValidator.blockRequests[0] = true
+	consensusValidator.SendBlockRequest(0)
+	if !assert.Len(test, *validatorSent, 1) {
+		return
+	}
+	consensusLeader.onBlockRequest((*validatorSent)[0])
+	if !assert.Len(test, *leaderSent, 1) {
+		return
+	}
+
+	consensusValidator.onBlockResponse((*leaderSent)[0])
+	assert.Equal(test, uint32(1), consensusValidator.GetViewID())
+	// The limit is reached, so the next block waits for the next committed message.
+	assert.False(test, consensusValidator.blockRequests[1])
+	assert.Empty(test, requested)
+}
+
+func TestVerifyBlockSigs(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	validatorPriKey := bls_cosi.RandPrivateKey()
+	pubKeys := []*bls.PublicKey{leader.ConsensusPubKey, validatorPriKey.GetPublicKey()}
+	consensusValidator, _ := newTestMember(test, ctrl, leader, validatorPriKey, pubKeys)
+
+	assert.NoError(test, consensusValidator.verifyBlockSigs(testCommittedBlock(test, pubKeys, []*bls.SecretKey{leaderPriKey, validatorPriKey})))
+	// A single signer of two is not a quorum.
+	assert.Error(test, consensusValidator.verifyBlockSigs(testCommittedBlock(test, pubKeys, []*bls.SecretKey{leaderPriKey})))
+
+	// Signatures over another block are rejected.
+	block := testCommittedBlock(test, pubKeys, []*bls.SecretKey{leaderPriKey, validatorPriKey})
+	header := block.Header()
+	header.Number = big.NewInt(2)
+	assert.Error(test, consensusValidator.verifyBlockSigs(types.NewBlockWithHeader(header)))
+}
@@ -14,8 +14,85 @@ const (
 	phaseDuration     time.Duration = 90 * time.Second
 	bootstrapDuration time.Duration = 90 * time.Second
 	maxLogSize        uint32        = 1000
+
+	// the consensus messages stop being gossiped once older than a phase, their round
+	// being over by then
+	messageTTL = phaseDuration
+
+	// default number of views a validator keeps in flight; no pipelining
+	defaultMaxInFlightViews = 1
+
+	// default number of announced blocks a validator verifies concurrently
+	defaultNumBlockVerifiers = 4
+
+	// default tolerance for the timestamp of an announced block being ahead of the local clock
+	defaultMaxFutureBlockTime time.Duration = 15 * time.Second
+
+	// default bounds of an announced block, so that a leader cannot stall the shard with an enormous one
+	defaultMaxBlockBytes = 4 * 1024 * 1024
+	defaultMaxBlockTxs   = 8000
+	defaultMaxTxBytes    = 32 * 1024
+
+	// backoff and number of attempts when re-requesting a block missing from the catch up
+	blockRequestMinBackoff time.Duration = 2 * time.Second
+	blockRequestMaxBackoff time.Duration = 32 * time.Second
+	blockRequestMaxRetries               = 5
+	// default number of requested blocks a validator applies in a row before waiting for the next commit
+	defaultMaxCatchupBlocks = 50
+
+	// maximum number of messages remembered for dropping duplicates
+	maxSeenMessages = 4096
+
+	// maximum number of prepare and commit messages remembered for detecting double signs
+	maxSignedMessages = 4096
+
+	// number of payload bytes kept in a dead letter
+	deadLetterPreviewLen = 64
+
+	// number of rejected messages from the leader after which the validator reports it
+	badLeaderReportThreshold = 3
+
+	// number of views ahead of the current one whose messages a validator holds until it reaches them
+	maxPendingViews = 4
+	// maximum number of messages held for the next views
+	maxPendingMessages = 256
+
+	// how often Stop drains the consensus channels while waiting for the workers
+	stopDrainInterval time.Duration = 100 * time.Millisecond
+
+	// wait before asking the Proposer again for a block it failed to build
+	proposeRetryInterval time.Duration = 1 * time.Second
 )
 
+// ConsensusTimeoutConfig configures how long a validator waits for the leader
+// in each phase of a round before starting a view change.
+type ConsensusTimeoutConfig struct {
+	// Wait for the PREPARED message once the announced block is prepared
+	Prepared time.Duration
+	// Wait for the COMMITTED message once the prepared block is committed
+	Committed time.Duration
+	// Each timeout in a row multiplies the waits by BackoffFactor, up to MaxTimeout
+	BackoffFactor float64
+	MaxTimeout    time.Duration
+}
+
+// DefaultConsensusTimeoutConfig is the timeout configuration of a new Consensus.
+var DefaultConsensusTimeoutConfig = ConsensusTimeoutConfig{
+	Prepared:      30 * time.Second,
+	Committed:     30 * time.Second,
+	BackoffFactor: 2,
+	MaxTimeout:    5 * time.Minute,
+}
+
+// NonceFlagDays are when the known networks start rejecting the consensus messages without
+// a nonce, as sent by the nodes of a version before the nonces. Until then such a message is
+// accepted without replay protection, so that the nodes can be upgraded one by one. It stays
+// accepted on the networks without a flag day.
+var NonceFlagDays = map[string]time.Time{
+	// The nodes of a local network all run the same version.
+	"localnet": time.Unix(0, 0),
+}
+
 // TimeoutType is the type of timeout in view change protocol
 type TimeoutType int
 
@@ -23,6 +100,8 @@ const (
 	timeoutConsensus TimeoutType = iota
 	timeoutViewChange
 	timeoutBootstrap
+	timeoutPrepared
+	timeoutCommitted
 )
 
 // NIL is the m2 type message, which suppose to be nil/empty, however
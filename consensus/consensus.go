@@ -4,6 +4,7 @@ package consensus // consensus
 import (
 	"math/big"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -11,6 +12,7 @@ import (
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/harmony-one/bls/ffi/go/bls"
 
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
 	consensus_engine "github.com/harmony-one/harmony/consensus/engine"
 	"github.com/harmony-one/harmony/contracts/structs"
 	"github.com/harmony-one/harmony/core/state"
@@ -54,11 +56,20 @@ type Consensus struct {
 	// channel to receive consensus message
 	MsgChan chan []byte
 
+	// How the leadership is handed over on view change, and every LeaderRotationInterval blocks if not 0
+	LeaderRotation         LeaderRotationPolicy
+	LeaderRotationInterval uint64
+
 	// 2 types of timeouts: normal and viewchange
 	consensusTimeout map[TimeoutType]*utils.Timeout
+	// Timeouts of the validator phases, and the number of them which expired in a row
+	TimeoutConfig    ConsensusTimeoutConfig
+	numPhaseTimeouts int
 
 	//TODO depreciate it after implement PbftPhase
 	state State
+	// When the current phase or state started, for Metrics
+	phaseStartTime time.Time
 	// Commits collected from validators.
 	prepareSigs          map[common.Address]*bls.Sign // key is the validator's address
 	commitSigs           map[common.Address]*bls.Sign // key is the validator's address
@@ -66,6 +77,13 @@ type Consensus struct {
 	aggregatedCommitSig  *bls.Sign
 	prepareBitmap        *bls_cosi.Mask
 	commitBitmap         *bls_cosi.Mask
+	// Votes not verified yet, checked in one batch once they can complete a quorum
+	pendingPrepares []pendingVote
+	pendingCommits  []pendingVote
+	// Set atomically once the leader has a quorum of votes, so that the later votes are
+	// dropped without verifying them or waiting for mutex
+	prepareQuorumReached uint32
+	commitQuorumReached  uint32
 
 	// Commits collected from view change
 	bhpSigs      map[common.Address]*bls.Sign // bhpSigs: blockHashPreparedSigs is the signature on m1 type message
@@ -87,20 +105,29 @@ type Consensus struct {
 	// If the number of validators is less than minPeers, the consensus won't start
 	MinPeers int
 
-	// Leader's address
+	// Leader's address; guarded by mutex, use Leader and SetLeader when not holding it
 	leader p2p.Peer
 
 	// Public keys of the committee including leader and validators
 	PublicKeys []*bls.PublicKey
+	// The quorum the prepare, commit and view change signers must reach; CountQuorum by default
+	QuorumPolicy Quorum
 	// The addresses of my committee
 	CommitteeAddresses map[common.Address]bool
 	pubKeyLock         sync.Mutex
+	// Committee change waiting for its view, see UpdateValidatorSet
+	pendingValidatorSet *validatorSetUpdate
 
 	// private/public keys of current node
 	priKey *bls.SecretKey
 	PubKey *bls.PublicKey
+	// keys of the further validator seats this node holds, see AddPriKey
+	extraPriKeys []*bls.SecretKey
 	// the publickey of leader
 	LeaderPubKey *bls.PublicKey
+	// the p2p peer the leader sends from, to send the votes to; guarded by leaderPeerMutex
+	leaderPeer      leaderPeer
+	leaderPeerMutex sync.Mutex
 
 	// Leader or validator address in hex
 	SelfAddress common.Address
@@ -117,6 +144,13 @@ type Consensus struct {
 	ShardID uint32
 	// whether to ignore viewID check
 	ignoreViewIDCheck bool
+	// Nonce of the last consensus message sent; seeded from the clock so that it keeps increasing across restarts
+	nonce uint64
+	// Highest nonce received from each sender for each message type
+	senderNonces map[senderNonceKey]uint64
+	// When the messages without a nonce start being rejected, see NonceFlagDays.
+	// They are accepted if it is zero.
+	NonceFlagDay time.Time
 
 	// global consensus mutex
 	mutex sync.Mutex
@@ -124,6 +158,59 @@ type Consensus struct {
 	// Validator specific fields
 	// Blocks received but not done with consensus yet
 	blocksReceived map[uint32]*BlockConsensusStatus
+	// The verified announce message of each view in blocksReceived, kept as equivocation evidence
+	announceMessages map[uint32]*msg_pb.Message
+	// The consensus messages received per view, to drop duplicates delivered by several gossip paths
+	seenMessages    map[uint32]map[seenMessageKey]bool
+	numSeenMessages int
+	// The first prepare and commit message signed by each validator per view, to detect double signs
+	signedMessages map[signedMessageKey]*msg_pb.Message
+
+	// The maximum number of views in flight, including the current one; 1 disables pipelining.
+	// The announces of the later views are held in pipelinedAnnounces until the view before commits.
+	MaxInFlightViews   int
+	pipelinedAnnounces map[uint32]*msg_pb.Message
+
+	// The prepared message received while the announced block was being verified
+	heldPrepared *msg_pb.Message
+
+	// The transactions of the last verified announced block and its view
+	lastAnnouncedViewID uint32
+	lastAnnouncedTxs    []*types.Transaction
+	// How far ahead of the local clock the timestamp of an announced block may be; 0 means no limit.
+	MaxFutureBlockTime time.Duration
+	// The maximum encoded size of an announced block, the number of its transactions and the
+	// encoded size of each of them; 0 means no limit. See verifyBlockLimits.
+	MaxBlockBytes int
+	MaxBlockTxs   int
+	MaxTxBytes    int
+	// Optional callback asking the leader/peers for the block of a view missing from blocksReceived
+	RequestMissingBlock func(viewID uint32)
+	// Views with a block request in flight
+	blockRequests map[uint32]bool
+	// The latest view the leader was seen committing, used to request the blocks up to it
+	highestCommittedViewID uint32
+	// The maximum number of requested blocks applied in a row before the catch up waits for the
+	// next committed message of the leader; 0 means no limit.
+	MaxCatchupBlocks int
+	catchupBlocks    int
+	// The messages for the next views, held until the validator reaches their view
+	pendingMessages messageQueue
+	pendingSeq      uint64
+	pendingMutex    sync.Mutex
+
+	// The number of announced blocks verified concurrently off the message handler;
+	// 0 verifies them inline.
+	NumBlockVerifiers  int
+	blockVerifierSlots chan struct{}
+
+	// The background block verifications and block requests, waited for by Stop
+	workers sync.WaitGroup
+	// Whether Stop was called; the messages are not handled any more
+	stopped bool
+
+	// Whether to run the attack model hooks; they are for testing only and off by default.
+	EnableAttackModel bool
 
 	// Signal channel for starting a new consensus process
 	ReadySignal chan struct{}
@@ -132,10 +219,37 @@ type Consensus struct {
 	OnConsensusDone func(*types.Block)
 	// The verifier func passed from Node object
 	BlockVerifier func(*types.Block) error
+	// The builder of the blocks the leader announces, see RunProposer
+	Proposer Proposer
 
 	// verified block to state sync broadcast
 	VerifiedNewBlock chan *types.Block
 
+	// Optional hook receiving the measurements of the consensus
+	Metrics Metrics
+	// Optional recorder of the messages of every round, for audit and replay
+	Transcript TranscriptRecorder
+	// Optional watermark of the signed views, refusing to sign a view twice across restarts
+	SignState *SignState
+
+	// Optional channel reporting how far each COMMITTED message advanced the node
+	CommittedEventChan chan CommittedEvent
+	// Optional channel reporting the evidence of a leader announcing two blocks for one view
+	EquivocationChan chan EquivocationEvidence
+	// Optional channel reporting the signed evidence of a validator preparing or committing two blocks for one view
+	SlashingEvidenceChan chan SignedEvidence
+	// Optional channel receiving the messages the validator could not parse or does not handle
+	DeadLetterChan chan DeadLetter
+	// Optional channel receiving the messages from the leader the validator rejected
+	RejectionChan chan Rejection
+	// Optional channel reporting the leader whose messages the validator keeps rejecting
+	BadLeaderChan chan BadLeaderReport
+	// Rejected messages of the leader since it last committed a block, see BadLeaderChan
+	faultyLeader      []byte
+	leaderFaults      map[error]int
+	numLeaderFaults   int
+	leaderFaultsMutex sync.Mutex
+
 	// will trigger state syncing when consensus ID is low
 	ViewIDLowChan chan struct{}
 
@@ -196,6 +310,20 @@ func (consensus *Consensus) Quorum() int {
 	return len(consensus.PublicKeys)*2/3 + 1
 }
 
+// IsQuorumAchieved returns whether the signers set in the mask reach the quorum
+// of the committee, as decided by QuorumPolicy.
+func (consensus *Consensus) IsQuorumAchieved(mask *bls_cosi.Mask) bool {
+	return consensus.quorumPolicy().IsAchieved(mask)
+}
+
+// quorumPolicy returns QuorumPolicy, or CountQuorum if it is not set.
+func (consensus *Consensus) quorumPolicy() Quorum {
+	if consensus.QuorumPolicy == nil {
+		return CountQuorum{}
+	}
+	return consensus.QuorumPolicy
+}
+
 // StakeInfoFinder finds the staking account for the given consensus key.
 type StakeInfoFinder interface {
 	// FindStakeInfoByNodeKey returns a list of staking information matching
@@ -220,6 +348,59 @@ type BlockConsensusStatus struct {
 	state State  // the latest state of the consensus
 }
 
+// CommittedEvent reports the outcome of processing a COMMITTED message.
+// NumBlocks is one, or zero when the message did not apply any new block,
+// e.g. when its view was already committed or the block of the view was not received.
+// A QuorumMargin of zero means the commit was signed by exactly the quorum,
+// so losing a single signer would have stalled the round.
+type CommittedEvent struct {
+	ViewID           uint32 // the viewID the node advanced to
+	NumBlocks        int    // the number of blocks applied by this message
+	NumCommitSigners int    // the number of validators who signed the commit
+	QuorumMargin     int    // the number of commit signers above the quorum, see Quorum.Margin
+}
+
+// seenMessageKey identifies a received consensus message.
+type seenMessageKey struct {
+	msgType msg_pb.MessageType
+	sender  string
+	msgHash common.Hash
+}
+
+// senderNonceKey identifies the nonces of a sender for a message type.
+type senderNonceKey struct {
+	msgType msg_pb.MessageType
+	sender  string // the hex string of the blsKey
+}
+
+// DeadLetter is a message dropped by ProcessMessageValidator, kept for protocol debugging.
+// Err is set if the message failed ValidateMessage; Type is then meaningless if the
+// payload could not be unmarshaled at all.
+type DeadLetter struct {
+	Type    msg_pb.MessageType
+	Err     error
+	Payload []byte // the first deadLetterPreviewLen bytes of the raw payload
+}
+
+// Rejection is a message from the leader the validator refused, with the reason, for
+// monitoring. Err is one of the errors of this package, e.g. ErrBadSignature.
+type Rejection struct {
+	Type   msg_pb.MessageType
+	ViewID uint32
+	Err    error
+}
+
+// RoundStatus is a read-only snapshot of the consensus round, for diagnostics.
+type RoundStatus struct {
+	ViewID            uint32 `json:"viewID"`
+	State             string `json:"state"`
+	NumPrepareSigners int    `json:"numPrepareSigners"`
+	PrepareSig        string `json:"prepareSig"` // hex of the aggregated prepare signature, empty if none yet
+	NumCommitSigners  int    `json:"numCommitSigners"`
+	CommitSig         string `json:"commitSig"` // hex of the aggregated commit signature, empty if none yet
+	BlockHash         string `json:"blockHash"`
+}
+
 // New creates a new Consensus object
 // TODO: put shardId into chain reader's chain config
 func New(host p2p.Host, ShardID uint32, leader p2p.Peer, blsPriKey *bls.SecretKey) (*Consensus, error) {
@@ -234,6 +415,10 @@ func New(host p2p.Host, ShardID uint32, leader p2p.Peer, blsPriKey *bls.SecretKe
 	consensus.mode = PbftMode{mode: Normal}
 	// pbft timeout
 	consensus.consensusTimeout = createTimeout()
+	consensus.TimeoutConfig = DefaultConsensusTimeoutConfig
+	consensus.LeaderRotation = RoundRobinRotation{}
+	consensus.QuorumPolicy = CountQuorum{}
+	consensus.phaseStartTime = time.Now()
 
 	selfPeer := host.GetSelfPeer()
 	if leader.Port == selfPeer.Port && leader.IP == selfPeer.IP {
@@ -266,8 +451,22 @@ func New(host p2p.Host, ShardID uint32, leader p2p.Peer, blsPriKey *bls.SecretKe
 	consensus.syncReadyChan = make(chan struct{})
 	consensus.commitFinishChan = make(chan uint64)
 
+	consensus.nonce = uint64(time.Now().UnixNano())
+	consensus.senderNonces = make(map[senderNonceKey]uint64)
 	// For validators to keep track of all blocks received but not yet committed, so as to catch up to latest consensus if lagged behind.
 	consensus.blocksReceived = make(map[uint32]*BlockConsensusStatus)
+	consensus.announceMessages = make(map[uint32]*msg_pb.Message)
+	consensus.seenMessages = make(map[uint32]map[seenMessageKey]bool)
+	consensus.signedMessages = make(map[signedMessageKey]*msg_pb.Message)
+	consensus.MaxInFlightViews = defaultMaxInFlightViews
+	consensus.NumBlockVerifiers = defaultNumBlockVerifiers
+	consensus.pipelinedAnnounces = make(map[uint32]*msg_pb.Message)
+	consensus.MaxFutureBlockTime = defaultMaxFutureBlockTime
+	consensus.MaxBlockBytes = defaultMaxBlockBytes
+	consensus.MaxBlockTxs = defaultMaxBlockTxs
+	consensus.MaxTxBytes = defaultMaxTxBytes
+	consensus.blockRequests = make(map[uint32]bool)
+	consensus.MaxCatchupBlocks = defaultMaxCatchupBlocks
 
 	consensus.ReadySignal = make(chan struct{})
 	if nodeconfig.GetDefaultConfig().IsLeader() {
@@ -2,6 +2,7 @@ package consensus
 
 import (
 	"encoding/hex"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -16,8 +17,6 @@ import (
 	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
 	"github.com/harmony-one/harmony/internal/profiler"
 	"github.com/harmony-one/harmony/internal/utils"
-	"github.com/harmony-one/harmony/p2p"
-	"github.com/harmony-one/harmony/p2p/host"
 )
 
 var (
@@ -96,11 +95,16 @@ func (consensus *Consensus) WaitForNewBlock(blockChannel chan *types.Block, stop
 
 // ProcessMessageLeader dispatches consensus message for the leader.
 func (consensus *Consensus) ProcessMessageLeader(payload []byte) {
+	if consensus.IsStopped() {
+		return
+	}
 	message := &msg_pb.Message{}
 	err := protobuf.Unmarshal(payload, message)
 
 	if err != nil {
 		utils.GetLogInstance().Error("Failed to unmarshal message payload.", "err", err, "consensus", consensus)
+	} else {
+		consensus.recordMessage(TranscriptReceived, message, payload)
 	}
 
 	switch message.Type {
@@ -108,6 +112,10 @@ func (consensus *Consensus) ProcessMessageLeader(payload []byte) {
 		consensus.processPrepareMessage(message)
 	case msg_pb.MessageType_COMMIT:
 		consensus.processCommitMessage(message)
+	case msg_pb.MessageType_BLOCK_REQUEST:
+		consensus.onBlockRequest(message)
+	case msg_pb.MessageType_BLOCK_RESPONSE:
+		// the leader has the committed blocks already
 	default:
 		utils.GetLogInstance().Error("Unexpected message type", "msgType", message.Type, "consensus", consensus)
 	}
@@ -115,6 +123,9 @@ func (consensus *Consensus) ProcessMessageLeader(payload []byte) {
 
 // startConsensus starts a new consensus for a block by broadcast a announce message to the validators
 func (consensus *Consensus) startConsensus(newBlock *types.Block) {
+	consensus.mutex.Lock()
+	defer consensus.mutex.Unlock()
+
 	// Copy over block hash and block header data
 	blockHash := newBlock.Hash()
 	copy(consensus.blockHash[:], blockHash[:])
@@ -132,14 +143,15 @@ func (consensus *Consensus) startConsensus(newBlock *types.Block) {
 	msgToSend := consensus.constructAnnounceMessage()
 
 	// Set state to AnnounceDone
-	consensus.state = AnnounceDone
+	consensus.setState(AnnounceDone)
 
 	// Leader sign the block hash itself
 	consensus.prepareSigs[consensus.SelfAddress] = consensus.priKey.SignHash(consensus.blockHash[:])
+	consensus.signWithExtraKeys(consensus.prepareSigs, consensus.prepareBitmap, consensus.blockHash[:])
 
 	// Construct broadcast p2p message
 	utils.GetLogInstance().Warn("[Consensus]", "sent announce message", len(msgToSend))
-	consensus.host.SendMessageToGroups([]p2p.GroupID{p2p.NewGroupIDByShardID(p2p.ShardID(consensus.ShardID))}, host.ConstructP2pMessage(byte(17), msgToSend))
+	consensus.sendMessage(msgToSend)
 }
 
 // processPrepareMessage processes the prepare message sent from validators
@@ -156,8 +168,14 @@ func (consensus *Consensus) processPrepareMessage(message *msg_pb.Message) {
 
 	prepareSig := consensusMsg.Payload
 
-	prepareSigs := consensus.prepareSigs
-	prepareBitmap := consensus.prepareBitmap
+	if atomic.LoadUint32(&consensus.prepareQuorumReached) != 0 {
+		utils.GetLogInstance().Debug("Received additional prepare message", "validatorAddress", validatorAddress)
+		return
+	}
+	// The votes are delivered concurrently: verify them before taking the lock.
+	if err := consensus.verifyConsensusMessageSig(message, validatorPubKey); err != nil {
+		return
+	}
 
 	consensus.mutex.Lock()
 	defer consensus.mutex.Unlock()
@@ -167,11 +185,14 @@ func (consensus *Consensus) processPrepareMessage(message *msg_pb.Message) {
 		return
 	}
 
-	if err := consensus.checkConsensusMessage(message, validatorPubKey); err != nil {
+	if err := consensus.checkVerifiedConsensusMessage(message, validatorPubKey); err != nil {
 		utils.GetLogInstance().Debug("Failed to check the validator message", "error", err, "validatorAddress", validatorAddress)
 		return
 	}
 
+	prepareSigs := consensus.prepareSigs
+	prepareBitmap := consensus.prepareBitmap
+
 	// proceed only when the message is not received before
 	_, ok := prepareSigs[validatorAddress]
 	if ok {
@@ -179,7 +200,7 @@ func (consensus *Consensus) processPrepareMessage(message *msg_pb.Message) {
 		return
 	}
 
-	if len(prepareSigs) >= consensus.Quorum() {
+	if consensus.IsQuorumAchieved(prepareBitmap) {
 		utils.GetLogInstance().Debug("Received additional prepare message", "validatorAddress", validatorAddress)
 		return
 	}
@@ -192,17 +213,16 @@ func (consensus *Consensus) processPrepareMessage(message *msg_pb.Message) {
 		return
 	}
 
-	if !sign.VerifyHash(validatorPubKey, consensus.blockHash[:]) {
-		utils.GetLogInstance().Error("Received invalid BLS signature", "validatorAddress", validatorAddress)
+	vote := pendingVote{address: validatorAddress, pubKey: validatorPubKey, sig: &sign}
+	if !consensus.addVote(prepareSigs, prepareBitmap, &consensus.pendingPrepares, vote, consensus.blockHash[:]) {
+		utils.GetLogInstance().Debug("Already received prepare message from the validator", "validatorAddress", validatorAddress)
 		return
 	}
-
-	utils.GetLogInstance().Debug("Received new prepare signature", "numReceivedSoFar", len(prepareSigs), "validatorAddress", validatorAddress, "PublicKeys", len(consensus.PublicKeys))
-	prepareSigs[validatorAddress] = &sign
-	prepareBitmap.SetKey(validatorPubKey, true) // Set the bitmap indicating that this validator signed.
+	utils.GetLogInstance().Debug("Received new prepare signature", "numReceivedSoFar", len(prepareSigs), "numPending", len(consensus.pendingPrepares), "validatorAddress", validatorAddress, "PublicKeys", len(consensus.PublicKeys))
 
 	targetState := PreparedDone
-	if len(prepareSigs) >= consensus.Quorum() && consensus.state < targetState {
+	if consensus.IsQuorumAchieved(prepareBitmap) && consensus.state < targetState {
+		atomic.StoreUint32(&consensus.prepareQuorumReached, 1)
 		utils.GetLogInstance().Debug("Enough prepares received with signatures", "num", len(prepareSigs), "state", consensus.state)
 
 		// Construct and broadcast prepared message
@@ -210,14 +230,15 @@ func (consensus *Consensus) processPrepareMessage(message *msg_pb.Message) {
 		consensus.aggregatedPrepareSig = aggSig
 
 		utils.GetLogInstance().Warn("[Consensus]", "sent prepared message", len(msgToSend))
-		consensus.host.SendMessageToGroups([]p2p.GroupID{p2p.NewGroupIDByShardID(p2p.ShardID(consensus.ShardID))}, host.ConstructP2pMessage(byte(17), msgToSend))
+		consensus.sendMessage(msgToSend)
 
 		// Set state to targetState
-		consensus.state = targetState
+		consensus.setState(targetState)
 
 		// Leader sign the multi-sig and bitmap (for commit phase)
 		multiSigAndBitmap := append(aggSig.Serialize(), prepareBitmap.Bitmap...)
 		consensus.commitSigs[consensus.SelfAddress] = consensus.priKey.SignHash(multiSigAndBitmap)
+		consensus.signWithExtraKeys(consensus.commitSigs, consensus.commitBitmap, multiSigAndBitmap)
 	}
 }
 
@@ -235,6 +256,15 @@ func (consensus *Consensus) processCommitMessage(message *msg_pb.Message) {
 
 	commitSig := consensusMsg.Payload
 
+	if atomic.LoadUint32(&consensus.commitQuorumReached) != 0 {
+		utils.GetLogInstance().Debug("Received additional new commit message", "validatorAddress", validatorAddress)
+		return
+	}
+	// The votes are delivered concurrently: verify them before taking the lock.
+	if err := consensus.verifyConsensusMessageSig(message, validatorPubKey); err != nil {
+		return
+	}
+
 	consensus.mutex.Lock()
 	defer consensus.mutex.Unlock()
 
@@ -243,7 +273,7 @@ func (consensus *Consensus) processCommitMessage(message *msg_pb.Message) {
 		return
 	}
 
-	if err := consensus.checkConsensusMessage(message, validatorPubKey); err != nil {
+	if err := consensus.checkVerifiedConsensusMessage(message, validatorPubKey); err != nil {
 		utils.GetLogInstance().Debug("Failed to check the validator message", "validatorAddress", validatorAddress)
 		return
 	}
@@ -258,7 +288,7 @@ func (consensus *Consensus) processCommitMessage(message *msg_pb.Message) {
 		return
 	}
 
-	if len(commitSigs) >= consensus.Quorum() {
+	if consensus.IsQuorumAchieved(commitBitmap) {
 		utils.GetLogInstance().Debug("Received additional new commit message", "validatorAddress", validatorAddress)
 		return
 	}
@@ -271,18 +301,16 @@ func (consensus *Consensus) processCommitMessage(message *msg_pb.Message) {
 		return
 	}
 	aggSig := bls_cosi.AggregateSig(consensus.GetPrepareSigsArray())
-	if !sign.VerifyHash(validatorPubKey, append(aggSig.Serialize(), consensus.prepareBitmap.Bitmap...)) {
-		utils.GetLogInstance().Error("Received invalid BLS signature", "validatorAddress", validatorAddress)
+	vote := pendingVote{address: validatorAddress, pubKey: validatorPubKey, sig: &sign}
+	if !consensus.addVote(commitSigs, commitBitmap, &consensus.pendingCommits, vote, append(aggSig.Serialize(), consensus.prepareBitmap.Bitmap...)) {
+		utils.GetLogInstance().Debug("Already received commit message from the validator", "validatorAddress", validatorAddress)
 		return
 	}
-
-	utils.GetLogInstance().Debug("Received new commit message", "numReceivedSoFar", len(commitSigs), "validatorAddress", validatorAddress)
-	commitSigs[validatorAddress] = &sign
-	// Set the bitmap indicating that this validator signed.
-	commitBitmap.SetKey(validatorPubKey, true)
+	utils.GetLogInstance().Debug("Received new commit message", "numReceivedSoFar", len(commitSigs), "numPending", len(consensus.pendingCommits), "validatorAddress", validatorAddress)
 
 	targetState := CommittedDone
-	if len(commitSigs) >= consensus.Quorum() && consensus.state != targetState {
+	if consensus.IsQuorumAchieved(commitBitmap) && consensus.state != targetState {
+		atomic.StoreUint32(&consensus.commitQuorumReached, 1)
 		utils.GetLogInstance().Info("Enough commits received!", "num", len(commitSigs), "state", consensus.state)
 
 		// Construct and broadcast committed message
@@ -290,7 +318,7 @@ func (consensus *Consensus) processCommitMessage(message *msg_pb.Message) {
 		consensus.aggregatedCommitSig = aggSig
 
 		utils.GetLogInstance().Warn("[Consensus]", "sent committed message", len(msgToSend))
-		consensus.host.SendMessageToGroups([]p2p.GroupID{p2p.NewGroupIDByShardID(p2p.ShardID(consensus.ShardID))}, host.ConstructP2pMessage(byte(17), msgToSend))
+		consensus.sendMessage(msgToSend)
 
 		var blockObj types.Block
 		err := rlp.DecodeBytes(consensus.block, &blockObj)
@@ -306,7 +334,7 @@ func (consensus *Consensus) processCommitMessage(message *msg_pb.Message) {
 			consensus.aggregatedCommitSig.Serialize(),
 			consensus.commitBitmap.Bitmap)
 
-		consensus.state = targetState
+		consensus.setState(targetState)
 
 		select {
 		case consensus.VerifiedNewBlock <- &blockObj:
@@ -322,8 +350,10 @@ func (consensus *Consensus) processCommitMessage(message *msg_pb.Message) {
 		// Reset state to Finished, and clear other data.
 		consensus.ResetState()
 		consensus.viewID++
+		consensus.applyValidatorSetUpdate()
 
 		consensus.OnConsensusDone(&blockObj)
+		consensus.metrics().RoundCompleted()
 		utils.GetLogInstance().Debug("HOORAY!!!!!!! CONSENSUS REACHED!!!!!!!", "viewID", consensus.viewID, "numOfSignatures", len(commitSigs))
 
 		// TODO: remove this temporary delay
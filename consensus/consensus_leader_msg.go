@@ -1,8 +1,6 @@
 package consensus
 
 import (
-	"bytes"
-
 	"github.com/harmony-one/bls/ffi/go/bls"
 	"github.com/harmony-one/harmony/api/proto"
 	msg_pb "github.com/harmony-one/harmony/api/proto/message"
@@ -44,18 +42,12 @@ func (consensus *Consensus) constructPreparedMessage() ([]byte, *bls.Sign) {
 	consensusMsg := message.GetConsensus()
 	consensus.populateMessageFields(consensusMsg)
 
-	//// Payload
-	buffer := bytes.NewBuffer([]byte{})
-
-	// 48 bytes aggregated signature
 	aggSig := bls_cosi.AggregateSig(consensus.GetPrepareSigsArray())
-	buffer.Write(aggSig.Serialize())
-
-	// Bitmap
-	buffer.Write(consensus.prepareBitmap.Bitmap)
-
-	consensusMsg.Payload = buffer.Bytes()
-	//// END Payload
+	payload, err := encodeMultiSigPayload(aggSig, consensus.prepareBitmap.Bitmap)
+	if err != nil {
+		utils.GetLogInstance().Error("Failed to encode the Prepared message payload", "error", err)
+	}
+	consensusMsg.Payload = payload
 
 	marshaledMessage, err := consensus.signAndMarshalConsensusMessage(message)
 	if err != nil {
@@ -77,18 +69,12 @@ func (consensus *Consensus) constructCommittedMessage() ([]byte, *bls.Sign) {
 	consensusMsg := message.GetConsensus()
 	consensus.populateMessageFields(consensusMsg)
 
-	//// Payload
-	buffer := bytes.NewBuffer([]byte{})
-
-	// 48 bytes aggregated signature
 	aggSig := bls_cosi.AggregateSig(consensus.GetCommitSigsArray())
-	buffer.Write(aggSig.Serialize())
-
-	// Bitmap
-	buffer.Write(consensus.commitBitmap.Bitmap)
-
-	consensusMsg.Payload = buffer.Bytes()
-	//// END Payload
+	payload, err := encodeMultiSigPayload(aggSig, consensus.commitBitmap.Bitmap)
+	if err != nil {
+		utils.GetLogInstance().Error("Failed to encode the Committed message payload", "error", err)
+	}
+	consensusMsg.Payload = payload
 
 	marshaledMessage, err := consensus.signAndMarshalConsensusMessage(message)
 	if err != nil {
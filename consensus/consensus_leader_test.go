@@ -84,6 +84,14 @@ func TestProcessMessageLeaderPrepare(test *testing.T) {
 
 	assert.Equal(test, PreparedDone, consensusLeader.state)
 
+	// The votes after the quorum are dropped without verifying them.
+	metrics := &testMetrics{}
+	consensusLeader.Metrics = metrics
+	msg := consensusValidators[0].constructPrepareMessage()
+	msgPayload, _ := proto.GetConsensusMessagePayload(msg)
+	consensusLeader.ProcessMessageLeader(msgPayload)
+	assert.Equal(test, 0, metrics.numVerified)
+
 	time.Sleep(1 * time.Second)
 }
 
@@ -224,3 +232,86 @@ func TestProcessMessageLeaderCommit(test *testing.T) {
 	//assert.Equal(test, Finished, consensusLeader.state)
 	time.Sleep(1 * time.Second)
 }
+
+func TestSignWithExtraKeys(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: ip, Port: "7777"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	extraPriKey := bls_cosi.RandPrivateKey()
+	outsiderPriKey := bls_cosi.RandPrivateKey()
+
+	m := mock_host.NewMockHost(ctrl)
+	m.EXPECT().GetSelfPeer().Return(leader)
+	consensusLeader, err := New(m, 0, leader, leaderPriKey)
+	if err != nil {
+		test.Fatalf("Cannot craeate consensus: %v", err)
+	}
+	consensusLeader.AddPriKey(extraPriKey)
+	consensusLeader.AddPriKey(outsiderPriKey)
+	consensusLeader.UpdatePublicKeys([]*bls.PublicKey{leader.ConsensusPubKey, extraPriKey.GetPublicKey()})
+
+	consensusLeader.prepareSigs[consensusLeader.SelfAddress] = leaderPriKey.SignHash(blockHash[:])
+	consensusLeader.signWithExtraKeys(consensusLeader.prepareSigs, consensusLeader.prepareBitmap, blockHash[:])
+
+	assert.Len(test, consensusLeader.prepareSigs, 2)
+	assert.Equal(test, 2, consensusLeader.prepareBitmap.CountEnabled())
+	aggSig := bls_cosi.AggregateSig(consensusLeader.GetPrepareSigsArray())
+	assert.True(test, aggSig.VerifyHash(consensusLeader.prepareBitmap.AggregatePublic, blockHash[:]))
+}
+
+func TestProcessPrepareMessageBatchVerification(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: ip, Port: "7777"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	validatorKeys := []*bls.SecretKey{bls_cosi.RandPrivateKey(), bls_cosi.RandPrivateKey(), bls_cosi.RandPrivateKey()}
+
+	m := mock_host.NewMockHost(ctrl)
+	m.EXPECT().GetSelfPeer().Return(leader)
+	m.EXPECT().SendMessageToGroups(gomock.Any(), gomock.Any())
+	consensusLeader, err := New(m, 0, leader, leaderPriKey)
+	if err != nil {
+		test.Fatalf("Cannot craeate consensus: %v", err)
+	}
+	pubKeys := []*bls.PublicKey{leader.ConsensusPubKey}
+	for _, priKey := range validatorKeys {
+		pubKeys = append(pubKeys, priKey.GetPublicKey())
+	}
+	consensusLeader.UpdatePublicKeys(pubKeys)
+	consensusLeader.ignoreViewIDCheck = false
+	consensusLeader.blockHash = blockHash
+
+	prepare := func(priKey *bls.SecretKey) *msg_pb.Message {
+		m := mock_host.NewMockHost(ctrl)
+		m.EXPECT().GetSelfPeer().Return(leader)
+		consensusValidator, err := New(m, 0, leader, priKey)
+		if err != nil {
+			test.Fatalf("Cannot craeate consensus: %v", err)
+		}
+		consensusValidator.blockHash = blockHash
+		return testConsensusMessage(test, consensusValidator.constructPrepareMessage())
+	}
+
+	// Verification waits until the votes can complete a quorum
+	consensusLeader.processPrepareMessage(prepare(validatorKeys[0]))
+	assert.Empty(test, consensusLeader.prepareSigs)
+	assert.Len(test, consensusLeader.pendingPrepares, 1)
+
+	// The invalid vote is dropped from the batch
+	invalid := prepare(validatorKeys[1])
+	invalid.GetConsensus().Payload = bls_cosi.RandPrivateKey().SignHash(blockHash[:]).Serialize()
+	resignTestMessage(test, invalid, validatorKeys[1])
+	consensusLeader.processPrepareMessage(invalid)
+	assert.Len(test, consensusLeader.prepareSigs, 1)
+	assert.Empty(test, consensusLeader.pendingPrepares)
+	assert.NotEqual(test, PreparedDone, consensusLeader.state)
+
+	consensusLeader.processPrepareMessage(prepare(validatorKeys[2]))
+	assert.Len(test, consensusLeader.prepareSigs, 2)
+	assert.Equal(test, PreparedDone, consensusLeader.state)
+}
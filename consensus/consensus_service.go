@@ -5,7 +5,10 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"math/big"
 	"reflect"
+	"sync/atomic"
+	"time"
 
 	"github.com/harmony-one/harmony/crypto/hash"
 
@@ -103,12 +106,25 @@ func (consensus *Consensus) populateMessageFields(request *msg_pb.ConsensusReque
 	// sender address
 	request.SenderPubkey = consensus.PubKey.Serialize()
 
+	// replay protection
+	request.Nonce = atomic.AddUint64(&consensus.nonce, 1)
+
 	utils.GetLogInstance().Debug("[populateMessageFields]", "myViewID", consensus.viewID, "SenderAddress", consensus.SelfAddress, "blockNum", consensus.blockNum)
 }
 
+// shardGroupIDs returns the groups consensus messages of this node's shard are sent to.
+func (consensus *Consensus) shardGroupIDs() []p2p.GroupID {
+	return []p2p.GroupID{p2p.NewGroupIDByShardID(p2p.ShardID(consensus.ShardID))}
+}
+
 // Signs the consensus message and returns the marshaled message.
 func (consensus *Consensus) signAndMarshalConsensusMessage(message *msg_pb.Message) ([]byte, error) {
-	err := consensus.signConsensusMessage(message)
+	return signAndMarshalConsensusMessageWithKey(consensus.priKey, message)
+}
+
+// signAndMarshalConsensusMessageWithKey signs the consensus message with the given key and returns the marshaled message.
+func signAndMarshalConsensusMessageWithKey(priKey *bls.SecretKey, message *msg_pb.Message) ([]byte, error) {
+	err := signConsensusMessageWithKey(priKey, message)
 	if err != nil {
 		return []byte{}, err
 	}
@@ -120,15 +136,35 @@ func (consensus *Consensus) signAndMarshalConsensusMessage(message *msg_pb.Messa
 	return marshaledMessage, nil
 }
 
+// Leader returns the consensus leader
+func (consensus *Consensus) Leader() p2p.Peer {
+	consensus.mutex.Lock()
+	defer consensus.mutex.Unlock()
+	return consensus.leader
+}
+
+// SetLeader sets the consensus leader
+func (consensus *Consensus) SetLeader(leader p2p.Peer) {
+	consensus.mutex.Lock()
+	defer consensus.mutex.Unlock()
+	consensus.leader = leader
+}
+
 // SetLeaderPubKey deserialize the public key of consensus leader
 func (consensus *Consensus) SetLeaderPubKey(k []byte) error {
-	consensus.leader.ConsensusPubKey = &bls.PublicKey{}
-	return consensus.leader.ConsensusPubKey.Deserialize(k)
+	pubKey := &bls.PublicKey{}
+	if err := pubKey.Deserialize(k); err != nil {
+		return err
+	}
+	consensus.mutex.Lock()
+	defer consensus.mutex.Unlock()
+	consensus.leader.ConsensusPubKey = pubKey
+	return nil
 }
 
 // GetLeaderPubKey returns the public key of consensus leader
 func (consensus *Consensus) GetLeaderPubKey() *bls.PublicKey {
-	return consensus.leader.ConsensusPubKey
+	return consensus.Leader().ConsensusPubKey
 }
 
 // GetNodeIDs returns Node IDs of all nodes in the same shard
@@ -145,6 +181,49 @@ func (consensus *Consensus) GetNodeIDs() []libp2p_peer.ID {
 	return nodes
 }
 
+// RoundStatus returns a snapshot of how far the current round has gone.
+func (consensus *Consensus) RoundStatus() RoundStatus {
+	consensus.mutex.Lock()
+	defer consensus.mutex.Unlock()
+
+	status := RoundStatus{
+		ViewID:    consensus.viewID,
+		State:     consensus.state.String(),
+		BlockHash: hex.EncodeToString(consensus.blockHash[:]),
+	}
+	if consensus.prepareBitmap != nil {
+		status.NumPrepareSigners = consensus.prepareBitmap.CountEnabled()
+	}
+	if consensus.aggregatedPrepareSig != nil {
+		status.PrepareSig = hex.EncodeToString(consensus.aggregatedPrepareSig.Serialize())
+	}
+	if consensus.commitBitmap != nil {
+		status.NumCommitSigners = consensus.commitBitmap.CountEnabled()
+	}
+	if consensus.aggregatedCommitSig != nil {
+		status.CommitSig = hex.EncodeToString(consensus.aggregatedCommitSig.Serialize())
+	}
+	return status
+}
+
+// GetBlockHash returns a copy of the hash of the block consensus is running on
+func (consensus *Consensus) GetBlockHash() [32]byte {
+	consensus.mutex.Lock()
+	defer consensus.mutex.Unlock()
+	return consensus.blockHash
+}
+
+// LastAnnouncedTransactions returns the transactions of the last block announced
+// for the current view, or nil if none has been announced yet.
+func (consensus *Consensus) LastAnnouncedTransactions() []*types.Transaction {
+	consensus.mutex.Lock()
+	defer consensus.mutex.Unlock()
+	if consensus.lastAnnouncedViewID != consensus.viewID {
+		return nil
+	}
+	return consensus.lastAnnouncedTxs
+}
+
 // GetViewID returns the consensus ID
 func (consensus *Consensus) GetViewID() uint32 {
 	return consensus.viewID
@@ -166,7 +245,7 @@ func (consensus *Consensus) DebugPrintValidators() {
 	consensus.validators.Range(func(k, v interface{}) bool {
 		if p, ok := v.(p2p.Peer); ok {
 			str2 := fmt.Sprintf("%s", p.ConsensusPubKey.Serialize())
-			utils.GetLogInstance().Debug("validator:", "IP", p.IP, "Port", p.Port, "address", utils.GetBlsAddress(p.ConsensusPubKey), "Key", str2)
+			utils.GetLogInstance().Debug("validator:", "IP", p.IP, "Port", p.Port, "address", blsPubKeyToAddress(p.ConsensusPubKey), "Key", str2)
 			count++
 			return true
 		}
@@ -184,19 +263,20 @@ func (consensus *Consensus) UpdatePublicKeys(pubKeys []*bls.PublicKey) int {
 		consensus.CommitteeAddresses[utils.GetBlsAddress(pubKey)] = true
 	}
 	// TODO: use pubkey to identify leader rather than p2p.Peer.
-	consensus.leader = p2p.Peer{ConsensusPubKey: pubKeys[0]}
+	leader := p2p.Peer{ConsensusPubKey: pubKeys[0]}
+	consensus.SetLeader(leader)
 	consensus.LeaderPubKey = pubKeys[0]
-	prepareBitmap, err := bls_cosi.NewMask(consensus.PublicKeys, consensus.leader.ConsensusPubKey)
+	prepareBitmap, err := bls_cosi.NewMask(consensus.PublicKeys, leader.ConsensusPubKey)
 	if err == nil {
 		consensus.prepareBitmap = prepareBitmap
 	}
 
-	commitBitmap, err := bls_cosi.NewMask(consensus.PublicKeys, consensus.leader.ConsensusPubKey)
+	commitBitmap, err := bls_cosi.NewMask(consensus.PublicKeys, leader.ConsensusPubKey)
 	if err == nil {
 		consensus.commitBitmap = commitBitmap
 	}
 
-	utils.GetLogInstance().Info("My Leader", "info", hex.EncodeToString(consensus.leader.ConsensusPubKey.Serialize()))
+	utils.GetLogInstance().Info("My Leader", "info", hex.EncodeToString(leader.ConsensusPubKey.Serialize()))
 	utils.GetLogInstance().Info("My Committee", "info", consensus.PublicKeys)
 	consensus.pubKeyLock.Unlock()
 	// reset states after update public keys
@@ -225,6 +305,39 @@ func (consensus *Consensus) VerifyHeader(chain consensus_engine.ChainReader, hea
 	return nil
 }
 
+// verifyBlockTime returns ErrFutureBlock if the header's timestamp is more than
+// MaxFutureBlockTime ahead of the local clock. A zero MaxFutureBlockTime disables the check.
+func (consensus *Consensus) verifyBlockTime(header *types.Header) error {
+	if consensus.MaxFutureBlockTime <= 0 {
+		return nil
+	}
+	maxTime := big.NewInt(time.Now().Add(consensus.MaxFutureBlockTime).Unix())
+	if header.Time.Cmp(maxTime) > 0 {
+		return consensus_engine.ErrFutureBlock
+	}
+	return nil
+}
+
+// verifyBlockLimits checks an announced block of size encoded bytes against MaxBlockBytes,
+// MaxBlockTxs and MaxTxBytes, before the block is executed or voted for.
+func (consensus *Consensus) verifyBlockLimits(block *types.Block, size int) error {
+	if consensus.MaxBlockBytes > 0 && size > consensus.MaxBlockBytes {
+		return ctxerror.New("block too large", "size", size, "max", consensus.MaxBlockBytes)
+	}
+	txs := block.Transactions()
+	if consensus.MaxBlockTxs > 0 && len(txs) > consensus.MaxBlockTxs {
+		return ctxerror.New("too many transactions", "numTxs", len(txs), "max", consensus.MaxBlockTxs)
+	}
+	if consensus.MaxTxBytes > 0 {
+		for _, tx := range txs {
+			if tx.Size() > common.StorageSize(consensus.MaxTxBytes) {
+				return ctxerror.New("transaction too large", "txHash", tx.Hash(), "size", tx.Size(), "max", consensus.MaxTxBytes)
+			}
+		}
+	}
+	return nil
+}
+
 // VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers
 // concurrently. The method returns a quit channel to abort the operations and
 // a results channel to retrieve the async verifications.
@@ -262,13 +375,18 @@ func (consensus *Consensus) Author(header *types.Header) (common.Address, error)
 
 // Sign on the hash of the message
 func (consensus *Consensus) signMessage(message []byte) []byte {
+	return signMessageWithKey(consensus.priKey, message)
+}
+
+// signMessageWithKey signs on the hash of the message with the given key.
+func signMessageWithKey(priKey *bls.SecretKey, message []byte) []byte {
 	hash := hash.Keccak256(message)
-	signature := consensus.priKey.SignHash(hash[:])
+	signature := priKey.SignHash(hash[:])
 	return signature.Serialize()
 }
 
-// Sign on the consensus message signature field.
-func (consensus *Consensus) signConsensusMessage(message *msg_pb.Message) error {
+// Sign on the consensus message signature field with the given key.
+func signConsensusMessageWithKey(priKey *bls.SecretKey, message *msg_pb.Message) error {
 	message.Signature = nil
 	// TODO: use custom serialization method rather than protobuf
 	marshaledMessage, err := protobuf.Marshal(message)
@@ -276,11 +394,36 @@ func (consensus *Consensus) signConsensusMessage(message *msg_pb.Message) error
 		return err
 	}
 	// 64 byte of signature on previous data
-	signature := consensus.signMessage(marshaledMessage)
+	signature := signMessageWithKey(priKey, marshaledMessage)
 	message.Signature = signature
 	return nil
 }
 
+// AddPriKey adds the key of a further validator seat held by this node. The node
+// then signs the prepare and commit phases for that seat as well.
+func (consensus *Consensus) AddPriKey(priKey *bls.SecretKey) {
+	consensus.mutex.Lock()
+	defer consensus.mutex.Unlock()
+	consensus.extraPriKeys = append(consensus.extraPriKeys, priKey)
+}
+
+// priKeys returns the keys of all the validator seats this node holds, its own key first.
+func (consensus *Consensus) priKeys() []*bls.SecretKey {
+	return append([]*bls.SecretKey{consensus.priKey}, consensus.extraPriKeys...)
+}
+
+// signWithExtraKeys adds the signatures of the further seats held by the leader to sigs and bitmap.
+func (consensus *Consensus) signWithExtraKeys(sigs map[common.Address]*bls.Sign, bitmap *bls_cosi.Mask, data []byte) {
+	for _, priKey := range consensus.extraPriKeys {
+		pubKey := priKey.GetPublicKey()
+		if err := bitmap.SetKey(pubKey, true); err != nil {
+			utils.GetLogInstance().Warn("Extra key is not in the committee", "error", err, "address", blsPubKeyToAddress(pubKey))
+			continue
+		}
+		sigs[utils.GetBlsAddress(pubKey)] = priKey.SignHash(data)
+	}
+}
+
 // GetValidatorPeers returns list of validator peers.
 func (consensus *Consensus) GetValidatorPeers() []p2p.Peer {
 	validatorPeers := make([]p2p.Peer, 0)
@@ -349,12 +492,88 @@ func (consensus *Consensus) ResetState() {
 	consensus.prepareSigs = map[common.Address]*bls.Sign{}
 	consensus.commitSigs = map[common.Address]*bls.Sign{}
 
-	prepareBitmap, _ := bls_cosi.NewMask(consensus.PublicKeys, consensus.LeaderPubKey)
-	commitBitmap, _ := bls_cosi.NewMask(consensus.PublicKeys, consensus.LeaderPubKey)
-	consensus.prepareBitmap = prepareBitmap
-	consensus.commitBitmap = commitBitmap
+	consensus.prepareBitmap = consensus.newLeaderMask()
+	consensus.commitBitmap = consensus.newLeaderMask()
 	consensus.aggregatedPrepareSig = nil
 	consensus.aggregatedCommitSig = nil
+	consensus.pendingPrepares = nil
+	consensus.pendingCommits = nil
+	consensus.heldPrepared = nil
+	atomic.StoreUint32(&consensus.prepareQuorumReached, 0)
+	atomic.StoreUint32(&consensus.commitQuorumReached, 0)
+	consensus.phaseStartTime = time.Now()
+}
+
+// pendingVote is a prepare or commit signature of a validator, not verified yet.
+type pendingVote struct {
+	address common.Address
+	pubKey  *bls.PublicKey
+	sig     *bls.Sign
+}
+
+// addVote queues a vote signing hash. Once the queued votes can complete a quorum with
+// the ones in sigs and bitmap, they are verified in one batch and the valid ones are
+// moved there. It returns false if the validator already has a vote queued.
+// The caller must hold consensus.mutex.
+func (consensus *Consensus) addVote(sigs map[common.Address]*bls.Sign, bitmap *bls_cosi.Mask, pending *[]pendingVote, vote pendingVote, hash []byte) bool {
+	for _, queued := range *pending {
+		if queued.address == vote.address {
+			return false
+		}
+	}
+	*pending = append(*pending, vote)
+
+	candidates, err := bls_cosi.NewMask(consensus.PublicKeys, nil)
+	if err != nil {
+		utils.GetLogInstance().Warn("Failed to create the mask of the pending votes", "error", err)
+		return true
+	}
+	if err := candidates.SetMask(bitmap.Bitmap); err != nil {
+		utils.GetLogInstance().Warn("Failed to copy the mask of the verified votes", "error", err)
+		return true
+	}
+	for _, queued := range *pending {
+		candidates.SetKey(queued.pubKey, true)
+	}
+	if !consensus.IsQuorumAchieved(candidates) {
+		return true
+	}
+
+	pubKeys := make([]*bls.PublicKey, len(*pending))
+	votes := make([]*bls.Sign, len(*pending))
+	for i, queued := range *pending {
+		pubKeys[i] = queued.pubKey
+		votes[i] = queued.sig
+	}
+	invalid, err := bls_cosi.VerifyBatch(pubKeys, votes, hash)
+	if err != nil {
+		utils.GetLogInstance().Warn("Failed to verify the pending votes", "error", err)
+		return true
+	}
+	isInvalid := map[int]bool{}
+	for _, i := range invalid {
+		isInvalid[i] = true
+		utils.GetLogInstance().Error("Received invalid BLS signature", "validatorAddress", (*pending)[i].address)
+	}
+	for i, queued := range *pending {
+		if !isInvalid[i] {
+			sigs[queued.address] = queued.sig
+			bitmap.SetKey(queued.pubKey, true) // Set the bitmap indicating that this validator signed.
+		}
+	}
+	*pending = nil
+	return true
+}
+
+// newLeaderMask returns a mask with the leader's bit set, or an empty mask if
+// the leader key is not among the public keys.
+func (consensus *Consensus) newLeaderMask() *bls_cosi.Mask {
+	mask, err := bls_cosi.NewMask(consensus.PublicKeys, consensus.LeaderPubKey)
+	if err != nil {
+		utils.GetLogInstance().Warn("Leader key is not in the committee, using an empty mask", "error", err)
+		mask, _ = bls_cosi.NewMask(consensus.PublicKeys, nil)
+	}
+	return mask
 }
 
 // Returns a string representation of this consensus
@@ -432,10 +651,10 @@ func (consensus *Consensus) RemovePeers(peers []p2p.Peer) int {
 		// Or the shard won't be able to reach consensus if public keys are mismatch
 
 		validators := consensus.GetValidatorPeers()
-		pong := proto_discovery.NewPongMessage(validators, consensus.PublicKeys, consensus.leader.ConsensusPubKey, consensus.ShardID)
+		pong := proto_discovery.NewPongMessage(validators, consensus.PublicKeys, consensus.GetLeaderPubKey(), consensus.ShardID)
 		buffer := pong.ConstructPongMessage()
 
-		consensus.host.SendMessageToGroups([]p2p.GroupID{p2p.NewGroupIDByShardID(p2p.ShardID(consensus.ShardID))}, host.ConstructP2pMessage(byte(17), buffer))
+		consensus.host.SendMessageToGroups(consensus.shardGroupIDs(), host.ConstructP2pMessage(buffer))
 	}
 
 	return count2
@@ -492,6 +711,11 @@ func verifyMessageSig(signerPubKey *bls.PublicKey, message *msg_pb.Message) erro
 	return nil
 }
 
+// blsPubKeyToAddress returns the hex address of a BLS public key, as used for logging.
+func blsPubKeyToAddress(pub *bls.PublicKey) string {
+	return utils.GetBlsAddress(pub).Hex()
+}
+
 // verifySenderKey verifys the message senderKey is properly signed and senderAddr is valid
 func (consensus *Consensus) verifySenderKey(msg *msg_pb.Message) (*bls.PublicKey, error) {
 	consensusMsg := msg.GetConsensus()
@@ -539,31 +763,64 @@ func (consensus *Consensus) RegisterRndChannel(rndChannel chan [64]byte) {
 }
 
 // Checks the basic meta of a consensus message, including the signature.
+// The caller must hold consensus.mutex.
 func (consensus *Consensus) checkConsensusMessage(message *msg_pb.Message, publicKey *bls.PublicKey) error {
-	consensusMsg := message.GetConsensus()
-	viewID := consensusMsg.ViewId
-	blockHash := consensusMsg.BlockHash
+	if err := consensus.verifyConsensusMessageSig(message, publicKey); err != nil {
+		return err
+	}
+	return consensus.checkVerifiedConsensusMessage(message, publicKey)
+}
 
-	// Verify message signature
+// verifyConsensusMessageSig verifies the signature of a consensus message. It does not need
+// consensus.mutex, so that the leader verifies the votes of a large committee on all cores.
+func (consensus *Consensus) verifyConsensusMessageSig(message *msg_pb.Message, publicKey *bls.PublicKey) error {
+	verifyStart := time.Now()
 	err := verifyMessageSig(publicKey, message)
+	consensus.metrics().SignatureVerified(time.Since(verifyStart))
 	if err != nil {
 		ctxerror.Log15(utils.GetLogger().Warn,
 			ctxerror.New("failed to verify the message signature",
 				"publicKey", publicKey.GetHexString(),
 			).WithCause(err))
-		return consensus_engine.ErrInvalidConsensusMessage
+		consensus.metrics().MessageDropped(ErrBadSignature)
+		return ErrBadSignature
 	}
-	if !bytes.Equal(blockHash, consensus.blockHash[:]) {
+	return nil
+}
+
+// checkVerifiedConsensusMessage is checkConsensusMessage for a message whose signature
+// was verified with verifyConsensusMessageSig. The caller must hold consensus.mutex.
+func (consensus *Consensus) checkVerifiedConsensusMessage(message *msg_pb.Message, publicKey *bls.PublicKey) error {
+	return consensus.checkVerifiedMessageForBlock(message, publicKey, consensus.blockHash[:])
+}
+
+// checkVerifiedMessageForBlock is checkVerifiedConsensusMessage for a message about the
+// block of expectedHash, e.g. an announce before its block becomes the current one.
+// The caller must hold consensus.mutex.
+func (consensus *Consensus) checkVerifiedMessageForBlock(message *msg_pb.Message, publicKey *bls.PublicKey, expectedHash []byte) (err error) {
+	defer func() {
+		if err != nil {
+			consensus.metrics().MessageDropped(err)
+		}
+	}()
+	consensusMsg := message.GetConsensus()
+	viewID := consensusMsg.ViewId
+	blockHash := consensusMsg.BlockHash
+
+	consensus.recordSignedMessage(message, publicKey)
+	if !bytes.Equal(blockHash, expectedHash) {
 		utils.GetLogInstance().Warn("Wrong blockHash", "consensus", consensus)
-		return consensus_engine.ErrInvalidConsensusMessage
+		return ErrBlockHashMismatch
+	}
+	if err := consensus.checkNonce(message, publicKey); err != nil {
+		return err
 	}
 
 	// just ignore consensus check for the first time when node join
 	if consensus.ignoreViewIDCheck {
 		consensus.viewID = viewID
-		consensus.mutex.Lock()
 		consensus.ignoreViewIDCheck = false
-		consensus.mutex.Unlock()
+		consensus.acceptNonce(message, publicKey)
 		return nil
 	} else if viewID != consensus.viewID {
 		utils.GetLogInstance().Warn("Wrong consensus Id", "myViewId", consensus.viewID, "theirViewId", viewID, "consensus", consensus)
@@ -573,11 +830,50 @@ func (consensus *Consensus) checkConsensusMessage(message *msg_pb.Message, publi
 		default:
 		}
 
-		return consensus_engine.ErrViewIDNotMatch
+		return ErrStaleView
 	}
+	consensus.acceptNonce(message, publicKey)
 	return nil
 }
 
+// checkNonce rejects a replayed message, i.e. one whose nonce is not above the highest
+// nonce accepted from its sender for its message type. The nonces are tracked per type as
+// the gossip may deliver the messages of different phases out of order.
+// Until NonceFlagDay, a message without a nonce from a node of an older version is accepted.
+// The caller must hold consensus.mutex.
+func (consensus *Consensus) checkNonce(message *msg_pb.Message, publicKey *bls.PublicKey) error {
+	nonce := message.GetConsensus().Nonce
+	if nonce == 0 && !consensus.isNonceRequired() {
+		return nil
+	}
+	highestNonce := consensus.senderNonces[newSenderNonceKey(message, publicKey)]
+	if nonce <= highestNonce {
+		utils.GetLogInstance().Warn("Stale message nonce", "msgType", message.Type, "nonce", nonce, "highestNonce", highestNonce, "sender Address", blsPubKeyToAddress(publicKey))
+		return consensus_engine.ErrInvalidConsensusMessage
+	}
+	return nil
+}
+
+// isNonceRequired returns whether NonceFlagDay has passed.
+func (consensus *Consensus) isNonceRequired() bool {
+	return !consensus.NonceFlagDay.IsZero() && !time.Now().Before(consensus.NonceFlagDay)
+}
+
+// acceptNonce records the nonce of an accepted message, unless it has none.
+// The caller must hold consensus.mutex.
+func (consensus *Consensus) acceptNonce(message *msg_pb.Message, publicKey *bls.PublicKey) {
+	nonce := message.GetConsensus().Nonce
+	if nonce == 0 {
+		return
+	}
+	consensus.senderNonces[newSenderNonceKey(message, publicKey)] = nonce
+}
+
+// newSenderNonceKey returns the key of the nonces of publicKey for the type of message.
+func newSenderNonceKey(message *msg_pb.Message, publicKey *bls.PublicKey) senderNonceKey {
+	return senderNonceKey{msgType: message.Type, sender: hex.EncodeToString(publicKey.Serialize())}
+}
+
 // Check viewID
 func (consensus *Consensus) checkViewID(msg *PbftMessage) error {
 	// just ignore consensus check for the first time when node join
@@ -612,22 +908,35 @@ func (consensus *Consensus) SetBlockNum(blockNum uint64) {
 	consensus.blockNum = blockNum
 }
 
+// InitFromChainHead resumes consensus after the current head of the chain, called at node
+// bootstrap so that a restarting node runs the next view instead of the committed ones.
+func (consensus *Consensus) InitFromChainHead(reader consensus_engine.ChainReader) error {
+	head := reader.CurrentHeader()
+	if head == nil || head.Number == nil {
+		return ctxerror.New("cannot resume consensus without a chain head")
+	}
+	consensus.mutex.Lock()
+	defer consensus.mutex.Unlock()
+	consensus.ResetState()
+	consensus.blockNum = head.Number.Uint64() + 1
+	consensus.viewID = uint32(consensus.blockNum)
+	consensus.ignoreViewIDCheck = false
+	return nil
+}
+
 // read the payload for signature and bitmap; offset is the beginning position of reading
 func (consensus *Consensus) readSignatureBitmapPayload(recvPayload []byte, offset int) (*bls.Sign, *bls_cosi.Mask, error) {
-	if offset+48 > len(recvPayload) {
+	if offset > len(recvPayload) {
 		return nil, nil, errors.New("payload not have enough length")
 	}
-	payload := append(recvPayload[:0:0], recvPayload...)
-	//#### Read payload data
-	// 48 byte of multi-sig
-	multiSig := payload[offset : offset+48]
-	offset += 48
-	// bitmap
-	bitmap := payload[offset:]
-	//#### END Read payload data
+	payload, err := decodeMultiSigPayload(recvPayload[offset:])
+	if err != nil {
+		return nil, nil, err
+	}
+	bitmap := payload.Bitmap
 
 	aggSig := bls.Sign{}
-	err := aggSig.Deserialize(multiSig)
+	err = aggSig.Deserialize(payload.Signature)
 	if err != nil {
 		return nil, nil, errors.New("unable to deserialize multi-signature from payload")
 	}
@@ -636,6 +945,9 @@ func (consensus *Consensus) readSignatureBitmapPayload(recvPayload []byte, offse
 		utils.GetLogInstance().Warn("onNewView unable to setup mask for prepared message", "err", err)
 		return nil, nil, errors.New("unable to setup mask from payload")
 	}
-	mask.SetMask(bitmap)
+	if err := mask.SetMask(bitmap); err != nil {
+		utils.GetLogInstance().Warn("unable to set the bitmap from payload", "err", err)
+		return nil, nil, errors.New("unable to set the bitmap from payload")
+	}
 	return &aggSig, mask, nil
 }
@@ -2,6 +2,7 @@ package consensus
 
 import (
 	"bytes"
+	"math/big"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -9,6 +10,7 @@ import (
 	"github.com/harmony-one/harmony/crypto/bls"
 
 	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/internal/utils"
 	"github.com/harmony-one/harmony/p2p"
 	"github.com/harmony-one/harmony/p2p/p2pimpl"
@@ -112,3 +114,102 @@ func TestSetViewID(t *testing.T) {
 		t.Errorf("Cannot set consensus ID. Got: %v, Expected: %v", consensus.viewID, height)
 	}
 }
+
+func TestBlsPubKeyToAddress(t *testing.T) {
+	pubKey := bls.RandPrivateKey().GetPublicKey()
+	addrBytes := pubKey.GetAddress()
+	expected := common.BytesToAddress(addrBytes[:]).Hex()
+	if got := blsPubKeyToAddress(pubKey); got != expected {
+		t.Errorf("blsPubKeyToAddress() = %s, expected %s", got, expected)
+	}
+	if got := utils.GetBlsAddress(pubKey).Hex(); got != expected {
+		t.Errorf("utils.GetBlsAddress() = %s, expected %s", got, expected)
+	}
+}
+
+func TestReadSignatureBitmapPayloadBadBitmap(t *testing.T) {
+	leaderPriKey := bls.RandPrivateKey()
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "9902", ConsensusPubKey: leaderPriKey.GetPublicKey()}
+	priKey, _, _ := utils.GenKeyP2P("127.0.0.1", "9902")
+	host, err := p2pimpl.NewHost(&leader, priKey)
+	if err != nil {
+		t.Fatalf("newhost failure: %v", err)
+	}
+	consensus, err := New(host, 0, leader, leaderPriKey)
+	if err != nil {
+		t.Fatalf("Cannot craeate consensus: %v", err)
+	}
+	consensus.UpdatePublicKeys(append(consensus.PublicKeys, leader.ConsensusPubKey))
+
+	sig := leaderPriKey.SignHash([]byte("hash"))
+	payload, err := encodeMultiSigPayload(sig, []byte{0, 0})
+	if err != nil {
+		t.Fatalf("encodeMultiSigPayload() failed: %v", err)
+	}
+	if _, _, err := consensus.readSignatureBitmapPayload(payload, 0); err == nil {
+		t.Errorf("readSignatureBitmapPayload() accepted a bitmap of the wrong length")
+	}
+	payload, err = encodeMultiSigPayload(sig, []byte{1})
+	if err != nil {
+		t.Fatalf("encodeMultiSigPayload() failed: %v", err)
+	}
+	_, mask, err := consensus.readSignatureBitmapPayload(payload, 0)
+	if err != nil {
+		t.Fatalf("readSignatureBitmapPayload() failed: %v", err)
+	}
+	if mask.CountEnabled() != 1 {
+		t.Errorf("mask.CountEnabled() = %d, expected 1", mask.CountEnabled())
+	}
+}
+
+func TestResetStateLeaderNotInCommittee(t *testing.T) {
+	leaderPriKey := bls.RandPrivateKey()
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "9902", ConsensusPubKey: leaderPriKey.GetPublicKey()}
+	priKey, _, _ := utils.GenKeyP2P("127.0.0.1", "9902")
+	host, err := p2pimpl.NewHost(&leader, priKey)
+	if err != nil {
+		t.Fatalf("newhost failure: %v", err)
+	}
+	consensus, err := New(host, 0, leader, leaderPriKey)
+	if err != nil {
+		t.Fatalf("Cannot craeate consensus: %v", err)
+	}
+	consensus.UpdatePublicKeys(append(consensus.PublicKeys, leader.ConsensusPubKey))
+	consensus.LeaderPubKey = bls.RandPrivateKey().GetPublicKey()
+
+	consensus.ResetState()
+	if consensus.prepareBitmap == nil || consensus.commitBitmap == nil {
+		t.Fatalf("ResetState() left a nil bitmap")
+	}
+	if consensus.prepareBitmap.CountEnabled() != 0 || consensus.commitBitmap.CountEnabled() != 0 {
+		t.Errorf("ResetState() enabled keys in the fallback bitmaps")
+	}
+}
+
+func TestVerifyBlockLimits(t *testing.T) {
+	small := types.NewTransaction(0, common.Address{}, 0, big.NewInt(1), 21000, big.NewInt(1), nil)
+	large := types.NewTransaction(1, common.Address{}, 0, big.NewInt(1), 21000, big.NewInt(1), make([]byte, 2048))
+	consensus := &Consensus{MaxBlockBytes: 4096, MaxBlockTxs: 2, MaxTxBytes: 1024}
+
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1)}, []*types.Transaction{small, small}, nil)
+	if err := consensus.verifyBlockLimits(block, int(block.Size())); err != nil {
+		t.Errorf("verifyBlockLimits() rejected a block within the limits: %v", err)
+	}
+	if err := consensus.verifyBlockLimits(block, 4097); err == nil {
+		t.Errorf("verifyBlockLimits() accepted a block of too many bytes")
+	}
+	block = types.NewBlock(&types.Header{Number: big.NewInt(1)}, []*types.Transaction{small, small, small}, nil)
+	if err := consensus.verifyBlockLimits(block, int(block.Size())); err == nil {
+		t.Errorf("verifyBlockLimits() accepted a block of too many transactions")
+	}
+	block = types.NewBlock(&types.Header{Number: big.NewInt(1)}, []*types.Transaction{large}, nil)
+	if err := consensus.verifyBlockLimits(block, int(block.Size())); err == nil {
+		t.Errorf("verifyBlockLimits() accepted a transaction of too many bytes")
+	}
+
+	// Zero limits disable the checks.
+	consensus = &Consensus{}
+	if err := consensus.verifyBlockLimits(block, 1<<30); err != nil {
+		t.Errorf("verifyBlockLimits() without limits rejected a block: %v", err)
+	}
+}
@@ -16,13 +16,11 @@ import (
 	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
 	"github.com/harmony-one/harmony/internal/ctxerror"
 	"github.com/harmony-one/harmony/internal/utils"
-	"github.com/harmony-one/harmony/p2p"
-	"github.com/harmony-one/harmony/p2p/host"
 )
 
 // handleMessageUpdate will update the consensus state according to received message
 func (consensus *Consensus) handleMessageUpdate(payload []byte) {
-	if len(payload) == 0 {
+	if len(payload) == 0 || consensus.IsStopped() {
 		return
 	}
 	msg := &msg_pb.Message{}
@@ -31,6 +29,7 @@ func (consensus *Consensus) handleMessageUpdate(payload []byte) {
 		utils.GetLogInstance().Error("Failed to unmarshal message payload.", "err", err, "consensus", consensus)
 		return
 	}
+	consensus.recordMessage(TranscriptReceived, msg, payload)
 
 	// when node is in ViewChanging mode, it still accepts normal message into PbftLog to avoid possible trap forever
 	// but drop PREPARE and COMMIT which are message types for leader
@@ -53,6 +52,8 @@ func (consensus *Consensus) handleMessageUpdate(payload []byte) {
 		consensus.onViewChange(msg)
 	case msg_pb.MessageType_NEWVIEW:
 		consensus.onNewView(msg)
+	case msg_pb.MessageType_BLOCK_REQUEST:
+		consensus.onBlockRequest(msg)
 	}
 
 }
@@ -96,10 +97,11 @@ func (consensus *Consensus) tryAnnounce(block *types.Block) {
 
 	// Leader sign the block hash itself
 	consensus.prepareSigs[consensus.SelfAddress] = consensus.priKey.SignHash(consensus.blockHash[:])
+	consensus.signWithExtraKeys(consensus.prepareSigs, consensus.prepareBitmap, consensus.blockHash[:])
 
 	// Construct broadcast p2p message
-	utils.GetLogInstance().Warn("tryAnnounce", "sent announce message", len(msgToSend), "groupID", p2p.NewGroupIDByShardID(p2p.ShardID(consensus.ShardID)))
-	consensus.host.SendMessageToGroups([]p2p.GroupID{p2p.NewGroupIDByShardID(p2p.ShardID(consensus.ShardID))}, host.ConstructP2pMessage(byte(17), msgToSend))
+	utils.GetLogInstance().Warn("tryAnnounce", "sent announce message", len(msgToSend), "groupID", consensus.shardGroupIDs()[0])
+	consensus.sendMessage(msgToSend)
 }
 
 func (consensus *Consensus) onAnnounce(msg *msg_pb.Message) {
@@ -135,6 +137,14 @@ func (consensus *Consensus) onAnnounce(msg *msg_pb.Message) {
 		utils.GetLogInstance().Warn("onAnnounce Unparseable block header data", "error", err)
 		return
 	}
+	if err := consensus.verifyBlockTime(blockObj.Header()); err != nil {
+		utils.GetLogInstance().Warn("onAnnounce block timestamp is too far ahead", "error", err, "blockTime", blockObj.Time())
+		return
+	}
+	if err := consensus.verifyBlockLimits(&blockObj, len(block)); err != nil {
+		utils.GetLogInstance().Warn("onAnnounce block exceeds the limits", "error", err)
+		return
+	}
 
 	if blockObj.NumberU64() != recvMsg.BlockNum || recvMsg.BlockNum < consensus.blockNum {
 		utils.GetLogger().Warn("blockNum not match", "recvBlockNum", recvMsg.BlockNum, "blockObjNum", blockObj.NumberU64(), "myBlockNum", consensus.blockNum)
@@ -164,8 +174,10 @@ func (consensus *Consensus) onAnnounce(msg *msg_pb.Message) {
 	logMsgs := consensus.pbftLog.GetMessagesByTypeSeqView(msg_pb.MessageType_ANNOUNCE, recvMsg.BlockNum, recvMsg.ViewID)
 	if len(logMsgs) > 0 {
 		if logMsgs[0].BlockHash != blockObj.Header().Hash() {
-			utils.GetLogInstance().Debug("onAnnounce leader is malicious", "leaderKey", utils.GetBlsAddress(consensus.LeaderPubKey))
+			utils.GetLogInstance().Debug("onAnnounce leader is malicious", "leaderKey", blsPubKeyToAddress(consensus.LeaderPubKey))
+			consensus.mutex.Lock()
 			consensus.startViewChange(consensus.viewID + 1)
+			consensus.mutex.Unlock()
 		}
 		return
 	}
@@ -207,9 +219,10 @@ func (consensus *Consensus) tryPrepare(blockHash common.Hash) {
 
 	if !consensus.PubKey.IsEqual(consensus.LeaderPubKey) { //TODO(chao): check whether this is necessary when calling tryPrepare
 		// Construct and send prepare message
-		msgToSend := consensus.constructPrepareMessage()
-		utils.GetLogInstance().Info("tryPrepare", "sent prepare message", len(msgToSend))
-		consensus.host.SendMessageToGroups([]p2p.GroupID{p2p.NewGroupIDByShardID(p2p.ShardID(consensus.ShardID))}, host.ConstructP2pMessage(byte(17), msgToSend))
+		for _, msgToSend := range consensus.constructPrepareMessages() {
+			utils.GetLogInstance().Info("tryPrepare", "sent prepare message", len(msgToSend))
+			consensus.sendToLeader(msgToSend)
+		}
 	}
 }
 
@@ -228,6 +241,9 @@ func (consensus *Consensus) onPrepare(msg *msg_pb.Message) {
 		utils.GetLogInstance().Debug("onPrepare Failed to verify sender's signature", "error", err)
 		return
 	}
+	consensus.mutex.Lock()
+	consensus.recordSignedMessage(msg, senderKey)
+	consensus.mutex.Unlock()
 
 	recvMsg, err := ParsePbftMessage(msg)
 	if err != nil {
@@ -256,7 +272,7 @@ func (consensus *Consensus) onPrepare(msg *msg_pb.Message) {
 
 	consensus.mutex.Lock()
 	defer consensus.mutex.Unlock()
-	if len(prepareSigs) >= consensus.Quorum() {
+	if consensus.IsQuorumAchieved(prepareBitmap) {
 		// already have enough signatures
 		return
 	}
@@ -275,16 +291,14 @@ func (consensus *Consensus) onPrepare(msg *msg_pb.Message) {
 		utils.GetLogInstance().Error("Failed to deserialize bls signature", "validatorAddress", validatorAddress)
 		return
 	}
-	if !sign.VerifyHash(validatorPubKey, consensus.blockHash[:]) {
-		utils.GetLogInstance().Error("Received invalid BLS signature", "validatorAddress", validatorAddress)
+	vote := pendingVote{address: validatorAddress, pubKey: validatorPubKey, sig: &sign}
+	if !consensus.addVote(prepareSigs, prepareBitmap, &consensus.pendingPrepares, vote, consensus.blockHash[:]) {
+		utils.GetLogInstance().Debug("Already received prepare message from the validator", "validatorAddress", validatorAddress)
 		return
 	}
+	utils.GetLogInstance().Debug("Received new prepare signature", "numReceivedSoFar", len(prepareSigs), "numPending", len(consensus.pendingPrepares), "validatorAddress", validatorAddress, "PublicKeys", len(consensus.PublicKeys))
 
-	utils.GetLogInstance().Debug("Received new prepare signature", "numReceivedSoFar", len(prepareSigs), "validatorAddress", validatorAddress, "PublicKeys", len(consensus.PublicKeys))
-	prepareSigs[validatorAddress] = &sign
-	prepareBitmap.SetKey(validatorPubKey, true) // Set the bitmap indicating that this validator signed.
-
-	if len(prepareSigs) >= consensus.Quorum() {
+	if consensus.IsQuorumAchieved(prepareBitmap) {
 		consensus.switchPhase(Commit)
 
 		// Construct and broadcast prepared message
@@ -292,11 +306,12 @@ func (consensus *Consensus) onPrepare(msg *msg_pb.Message) {
 		consensus.aggregatedPrepareSig = aggSig
 
 		utils.GetLogInstance().Warn("onPrepare", "sent prepared message", len(msgToSend))
-		consensus.host.SendMessageToGroups([]p2p.GroupID{p2p.NewGroupIDByShardID(p2p.ShardID(consensus.ShardID))}, host.ConstructP2pMessage(byte(17), msgToSend))
+		consensus.sendMessage(msgToSend)
 
 		// Leader sign the multi-sig and bitmap (for commit phase)
 		multiSigAndBitmap := append(aggSig.Serialize(), prepareBitmap.Bitmap...)
 		consensus.commitSigs[consensus.SelfAddress] = consensus.priKey.SignHash(multiSigAndBitmap)
+		consensus.signWithExtraKeys(consensus.commitSigs, consensus.commitBitmap, multiSigAndBitmap)
 	}
 	return
 }
@@ -383,9 +398,10 @@ func (consensus *Consensus) onPrepared(msg *msg_pb.Message) {
 
 	// Construct and send the commit message
 	multiSigAndBitmap := append(aggSig.Serialize(), consensus.prepareBitmap.Bitmap...)
-	msgToSend := consensus.constructCommitMessage(multiSigAndBitmap)
-	utils.GetLogInstance().Warn("[Consensus]", "sent commit message", len(msgToSend))
-	consensus.host.SendMessageToGroups([]p2p.GroupID{p2p.NewGroupIDByShardID(p2p.ShardID(consensus.ShardID))}, host.ConstructP2pMessage(byte(17), msgToSend))
+	for _, msgToSend := range consensus.constructCommitMessages(multiSigAndBitmap) {
+		utils.GetLogInstance().Warn("[Consensus]", "sent commit message", len(msgToSend))
+		consensus.sendToLeader(msgToSend)
+	}
 
 	consensus.switchPhase(Commit)
 
@@ -407,6 +423,9 @@ func (consensus *Consensus) onCommit(msg *msg_pb.Message) {
 		utils.GetLogInstance().Debug("onCommit Failed to verify sender's signature", "error", err)
 		return
 	}
+	consensus.mutex.Lock()
+	consensus.recordSignedMessage(msg, senderKey)
+	consensus.mutex.Unlock()
 
 	recvMsg, err := ParsePbftMessage(msg)
 	if err != nil {
@@ -448,7 +467,7 @@ func (consensus *Consensus) onCommit(msg *msg_pb.Message) {
 		return
 	}
 
-	quorumWasMet := len(commitSigs) >= consensus.Quorum()
+	quorumWasMet := consensus.IsQuorumAchieved(commitBitmap)
 
 	// Verify the signature on prepare multi-sig and bitmap is correct
 	var sign bls.Sign
@@ -457,17 +476,18 @@ func (consensus *Consensus) onCommit(msg *msg_pb.Message) {
 		utils.GetLogInstance().Debug("Failed to deserialize bls signature", "validatorAddress", validatorAddress)
 		return
 	}
-	if !sign.VerifyHash(validatorPubKey, append(consensus.aggregatedPrepareSig.Serialize(), consensus.prepareBitmap.Bitmap...)) {
-		utils.GetLogInstance().Error("Received invalid BLS signature", "validatorAddress", validatorAddress)
+	if consensus.aggregatedPrepareSig == nil {
+		utils.GetLogInstance().Debug("Received commit message before the prepare quorum", "validatorAddress", validatorAddress)
 		return
 	}
+	vote := pendingVote{address: validatorAddress, pubKey: validatorPubKey, sig: &sign}
+	if !consensus.addVote(commitSigs, commitBitmap, &consensus.pendingCommits, vote, append(consensus.aggregatedPrepareSig.Serialize(), consensus.prepareBitmap.Bitmap...)) {
+		utils.GetLogInstance().Debug("Already received commit message from the validator", "validatorAddress", validatorAddress)
+		return
+	}
+	utils.GetLogInstance().Debug("Received new commit message", "numReceivedSoFar", len(commitSigs), "numPending", len(consensus.pendingCommits), "validatorAddress", validatorAddress)
 
-	utils.GetLogInstance().Debug("Received new commit message", "numReceivedSoFar", len(commitSigs), "validatorAddress", validatorAddress)
-	commitSigs[validatorAddress] = &sign
-	// Set the bitmap indicating that this validator signed.
-	commitBitmap.SetKey(validatorPubKey, true)
-
-	quorumIsMet := len(commitSigs) >= consensus.Quorum()
+	quorumIsMet := consensus.IsQuorumAchieved(commitBitmap)
 
 	if !quorumWasMet && quorumIsMet {
 		utils.GetLogInstance().Info("Enough commits received!", "num", len(commitSigs), "state", consensus.state)
@@ -488,7 +508,7 @@ func (consensus *Consensus) finalizeCommits() {
 	consensus.aggregatedCommitSig = aggSig
 
 	utils.GetLogInstance().Warn("[Consensus]", "sent committed message", len(msgToSend))
-	consensus.host.SendMessageToGroups([]p2p.GroupID{p2p.NewGroupIDByShardID(p2p.ShardID(consensus.ShardID))}, host.ConstructP2pMessage(byte(17), msgToSend))
+	consensus.sendMessage(msgToSend)
 
 	var blockObj types.Block
 	err := rlp.DecodeBytes(consensus.block, &blockObj)
@@ -519,15 +539,20 @@ func (consensus *Consensus) finalizeCommits() {
 	consensus.ResetState()
 	consensus.viewID++
 	consensus.blockNum++
+	consensus.applyValidatorSetUpdate()
+	consensus.rotateLeader(consensus.blockNum - 1)
 
 	consensus.consensusTimeout[timeoutConsensus].Start()
 	consensus.consensusTimeout[timeoutBootstrap].Stop()
 
 	consensus.OnConsensusDone(&blockObj)
+	consensus.metrics().RoundCompleted()
 	utils.GetLogInstance().Debug("HOORAY!!!!!!! CONSENSUS REACHED!!!!!!!", "viewID", consensus.viewID, "numOfSignatures", len(consensus.commitSigs))
 
 	// Send signal to Node so the new block can be added and new round of consensus can be triggered
-	consensus.ReadySignal <- struct{}{}
+	if consensus.PubKey.IsEqual(consensus.LeaderPubKey) {
+		consensus.ReadySignal <- struct{}{}
+	}
 }
 
 func (consensus *Consensus) onCommitted(msg *msg_pb.Message) {
@@ -560,9 +585,7 @@ func (consensus *Consensus) onCommitted(msg *msg_pb.Message) {
 		return
 	}
 
-	validatorPubKey := recvMsg.SenderPubkey
-	addrBytes := validatorPubKey.GetAddress()
-	leaderAddress := common.BytesToAddress(addrBytes[:]).Hex()
+	leaderAddress := blsPubKeyToAddress(recvMsg.SenderPubkey)
 
 	aggSig, mask, err := consensus.readSignatureBitmapPayload(recvMsg.Payload, 0)
 	if err != nil {
@@ -644,35 +667,34 @@ func (consensus *Consensus) tryCatchup() {
 		if msg == nil {
 			break
 		}
+		committedPayload, err := decodeMultiSigPayload(msgs[0].Payload)
+		if err != nil {
+			utils.GetLogInstance().Warn("Failed to read the committed message payload", "error", err)
+			break
+		}
+		preparedPayload, err := decodeMultiSigPayload(msg.Payload)
+		if err != nil {
+			utils.GetLogInstance().Warn("Failed to read the prepared message payload", "error", err)
+			break
+		}
 		consensus.blockHash = [32]byte{}
 		consensus.blockNum = consensus.blockNum + 1
 		consensus.viewID = msgs[0].ViewID + 1
 		consensus.LeaderPubKey = msgs[0].SenderPubkey
-
-		//#### Read payload data from committed msg
-		aggSig := make([]byte, 48)
-		bitmap := make([]byte, len(msgs[0].Payload)-48)
-		offset := 0
-		copy(aggSig[:], msgs[0].Payload[offset:offset+48])
-		offset += 48
-		copy(bitmap[:], msgs[0].Payload[offset:])
-		//#### END Read payload data from committed msg
-
-		//#### Read payload data from prepared msg
-		prepareSig := make([]byte, 48)
-		prepareBitmap := make([]byte, len(msg.Payload)-48)
-		offset = 0
-		copy(prepareSig[:], msg.Payload[offset:offset+48])
-		offset += 48
-		copy(prepareBitmap[:], msg.Payload[offset:])
-		//#### END Read payload data from committed msg
+		consensus.applyValidatorSetUpdate()
+		if consensus.rotateLeader(msgs[0].BlockNum) {
+			go func() {
+				consensus.ReadySignal <- struct{}{}
+			}()
+		}
 
 		// Put the signatures into the block
-		block.SetPrepareSig(prepareSig, prepareBitmap)
+		block.SetPrepareSig(preparedPayload.Signature, preparedPayload.Bitmap)
 
-		block.SetCommitSig(aggSig, bitmap)
+		block.SetCommitSig(committedPayload.Signature, committedPayload.Bitmap)
 		utils.GetLogInstance().Info("Adding block to chain", "numTx", len(block.Transactions()))
 		consensus.OnConsensusDone(block)
+		consensus.metrics().RoundCompleted()
 		consensus.ResetState()
 
 		select {
@@ -707,6 +729,8 @@ func (consensus *Consensus) Start(blockChannel chan *types.Block, stopChan chan
 		for {
 			select {
 			case <-ticker.C:
+				// The validator starts and stops the phase timeouts under the mutex
+				consensus.mutex.Lock()
 				for k, v := range consensus.consensusTimeout {
 					if consensus.mode.Mode() == Syncing {
 						v.Stop()
@@ -716,6 +740,9 @@ func (consensus *Consensus) Start(blockChannel chan *types.Block, stopChan chan
 					}
 					if k != timeoutViewChange {
 						utils.GetLogInstance().Debug("ops", "phase", k, "mode", consensus.mode.Mode())
+						if k == timeoutPrepared || k == timeoutCommitted {
+							consensus.numPhaseTimeouts++
+						}
 						consensus.startViewChange(consensus.viewID + 1)
 						break
 					} else {
@@ -725,6 +752,7 @@ func (consensus *Consensus) Start(blockChannel chan *types.Block, stopChan chan
 						break
 					}
 				}
+				consensus.mutex.Unlock()
 
 			case <-consensus.syncReadyChan:
 				func() {
@@ -1,48 +1,157 @@
 package consensus
 
 import (
-	"github.com/ethereum/go-ethereum/common"
+	"bytes"
+
 	"github.com/ethereum/go-ethereum/rlp"
-	protobuf "github.com/golang/protobuf/proto"
 	"github.com/harmony-one/bls/ffi/go/bls"
 	msg_pb "github.com/harmony-one/harmony/api/proto/message"
 	"github.com/harmony-one/harmony/core/types"
 	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/crypto/hash"
 	"github.com/harmony-one/harmony/internal/attack"
 	"github.com/harmony-one/harmony/internal/ctxerror"
 	"github.com/harmony-one/harmony/internal/utils"
 	"github.com/harmony-one/harmony/p2p"
-	"github.com/harmony-one/harmony/p2p/host"
 )
 
 // ProcessMessageValidator dispatches validator's consensus message.
 func (consensus *Consensus) ProcessMessageValidator(payload []byte) {
-	message := &msg_pb.Message{}
-	err := protobuf.Unmarshal(payload, message)
+	if consensus.IsStopped() {
+		return
+	}
+	message, err := consensus.ValidateMessage(payload)
 	if err != nil {
-		utils.GetLogInstance().Error("Failed to unmarshal message payload.", "err", err, "consensus", consensus)
+		utils.GetLogInstance().Error("Failed to validate message payload.", "err", err, "consensus", consensus)
+		var msgType msg_pb.MessageType // meaningless if the payload is not a message
+		if message != nil {
+			msgType = message.Type
+		}
+		consensus.reportDeadLetter(msgType, err, payload)
+		return
+	}
+	consensus.recordMessage(TranscriptReceived, message, payload)
+
+	if consensus.isDuplicateMessage(message, payload) {
+		utils.GetLogInstance().Debug("Dropping duplicate message", "msgType", message.Type)
+		return
 	}
 
+	// The messages for the next views wait in a queue until the validator reaches their view.
+	consensus.queueMessage(message, payload)
+	consensus.dispatchPendingMessages()
+}
+
+// dispatchMessageValidator hands a validator's consensus message to its handler.
+func (consensus *Consensus) dispatchMessageValidator(message *msg_pb.Message, payload []byte) {
+	var err error
 	switch message.Type {
 	case msg_pb.MessageType_ANNOUNCE:
-		consensus.processAnnounceMessage(message)
+		err = consensus.processAnnounceMessage(message)
 	case msg_pb.MessageType_PREPARED:
-		consensus.processPreparedMessage(message)
+		err = consensus.processPreparedMessage(message)
 	case msg_pb.MessageType_COMMITTED:
-		consensus.processCommittedMessage(message)
+		err = consensus.processCommittedMessage(message)
+	case msg_pb.MessageType_BLOCK_RESPONSE:
+		consensus.onBlockResponse(message)
 	case msg_pb.MessageType_PREPARE:
 	case msg_pb.MessageType_COMMIT:
+	case msg_pb.MessageType_BLOCK_REQUEST:
 		// ignore consensus message that is only meant to sent to leader
 		// since we use pubsub, the relay node will also receive those message
 		// but we should just ignore them
 
 	default:
 		utils.GetLogInstance().Error("Unexpected message type", "msgType", message.Type, "consensus", consensus)
+		consensus.reportDeadLetter(message.Type, nil, payload)
+	}
+	if err != nil {
+		consensus.mutex.Lock()
+		consensus.reportRejection(message, err)
+		consensus.mutex.Unlock()
+	}
+}
+
+// isDuplicateMessage records a consensus message and returns whether an identical one from the
+// same sender was seen before, so that it can be dropped before its signature is verified.
+func (consensus *Consensus) isDuplicateMessage(message *msg_pb.Message, payload []byte) bool {
+	consensusMsg := message.GetConsensus()
+	if consensusMsg == nil {
+		return false
+	}
+	key := seenMessageKey{
+		msgType: message.Type,
+		sender:  string(consensusMsg.SenderPubkey),
+		msgHash: hash.Keccak256Hash(payload),
+	}
+
+	consensus.mutex.Lock()
+	defer consensus.mutex.Unlock()
+	if consensusMsg.ViewId < consensus.viewID {
+		// stale, rejected by the view check anyway
+		return false
+	}
+	seen, ok := consensus.seenMessages[consensusMsg.ViewId]
+	if !ok {
+		seen = make(map[seenMessageKey]bool)
+		consensus.seenMessages[consensusMsg.ViewId] = seen
+	}
+	if seen[key] {
+		return true
+	}
+	if consensus.numSeenMessages < maxSeenMessages {
+		seen[key] = true
+		consensus.numSeenMessages++
+	}
+	return false
+}
+
+// pruneSeenMessages forgets the messages of the views before the current one.
+// The caller must hold consensus.mutex.
+func (consensus *Consensus) pruneSeenMessages() {
+	for viewID, seen := range consensus.seenMessages {
+		if viewID < consensus.viewID {
+			consensus.numSeenMessages -= len(seen)
+			delete(consensus.seenMessages, viewID)
+		}
+	}
+}
+
+// reportDeadLetter delivers a preview of a dropped message to DeadLetterChan if anyone listens, without blocking.
+func (consensus *Consensus) reportDeadLetter(msgType msg_pb.MessageType, err error, payload []byte) {
+	if consensus.DeadLetterChan == nil {
+		return
+	}
+	if len(payload) > deadLetterPreviewLen {
+		payload = payload[:deadLetterPreviewLen]
+	}
+	select {
+	case consensus.DeadLetterChan <- DeadLetter{Type: msgType, Err: err, Payload: append([]byte{}, payload...)}:
+	default:
+		utils.GetLogInstance().Info("dead letter send to chan failed", "msgType", msgType)
+	}
+}
+
+// reportRejection delivers a message the validator rejected to RejectionChan if anyone listens, without blocking.
+// The caller must hold consensus.mutex.
+func (consensus *Consensus) reportRejection(message *msg_pb.Message, err error) {
+	consensus.recordLeaderFault(message, err)
+	if consensus.RejectionChan == nil {
+		return
+	}
+	rejection := Rejection{Type: message.Type, Err: err}
+	if consensusMsg := message.GetConsensus(); consensusMsg != nil {
+		rejection.ViewID = consensusMsg.ViewId
+	}
+	select {
+	case consensus.RejectionChan <- rejection:
+	default:
+		utils.GetLogInstance().Info("rejection send to chan failed", "msgType", message.Type, "error", err)
 	}
 }
 
 // Processes the announce message sent from the leader
-func (consensus *Consensus) processAnnounceMessage(message *msg_pb.Message) {
+func (consensus *Consensus) processAnnounceMessage(message *msg_pb.Message) error {
 	utils.GetLogInstance().Info("Received Announce Message", "ValidatorAddress", consensus.SelfAddress)
 
 	consensusMsg := message.GetConsensus()
@@ -51,129 +160,252 @@ func (consensus *Consensus) processAnnounceMessage(message *msg_pb.Message) {
 	blockHash := consensusMsg.BlockHash
 	block := consensusMsg.Payload
 
-	// Add block to received block cache
 	consensus.mutex.Lock()
+	defer consensus.mutex.Unlock()
+
+	// A different block announced for a view already announced is an equivocation
+	if first, ok := consensus.announceMessages[viewID]; ok && !bytes.Equal(first.GetConsensus().BlockHash, blockHash) {
+		consensus.reportEquivocation(first, message)
+		return ErrEquivocation
+	}
+
+	if consensus.isPipelinedView(viewID) {
+		return consensus.pipelineAnnounce(message)
+	}
+
+	// The announce only becomes the block of the round once it is checked to come from the leader
+	leaderKey := consensus.leader.ConsensusPubKey
+	if err := consensus.verifyConsensusMessageSig(message, leaderKey); err != nil {
+		return err
+	}
+	if err := consensus.checkVerifiedMessageForBlock(message, leaderKey, blockHash); err != nil {
+		utils.GetLogInstance().Debug("Failed to check the leader message", "leader Address", blsPubKeyToAddress(leaderKey))
+		return err
+	}
+
+	// Add block to received block cache
 	consensus.blocksReceived[viewID] = &BlockConsensusStatus{block, consensus.state}
-	consensus.mutex.Unlock()
 
 	copy(consensus.blockHash[:], blockHash[:])
 	consensus.block = block
+	return consensus.prepareAnnouncedBlock(message)
+}
 
-	if err := consensus.checkConsensusMessage(message, consensus.leader.ConsensusPubKey); err != nil {
-		utils.GetLogInstance().Debug("Failed to check the leader message", "key", utils.GetBlsAddress(consensus.leader.ConsensusPubKey))
-		return
-	}
+// prepareAnnouncedBlock verifies the block of a checked announce message and
+// sends the prepare message for it. The block content is verified by the block
+// verifier pool unless NumBlockVerifiers is zero, in which case a failure is
+// reported to RejectionChan once known. The caller must hold consensus.mutex.
+func (consensus *Consensus) prepareAnnouncedBlock(message *msg_pb.Message) error {
+	consensus.announceMessages[message.GetConsensus().ViewId] = message
+	block := message.GetConsensus().Payload
 
 	// check block header is valid
 	var blockObj types.Block
 	err := rlp.DecodeBytes(block, &blockObj)
 	if err != nil {
 		utils.GetLogInstance().Warn("Unparseable block header data", "error", err)
-		return
+		return ErrMalformedMessage
+	}
+	if err := consensus.verifyBlockTime(blockObj.Header()); err != nil {
+		utils.GetLogInstance().Warn("Block timestamp is too far ahead", "error", err, "blockTime", blockObj.Time())
+		return ErrInvalidBlock
+	}
+	if err := consensus.verifyBlockLimits(&blockObj, len(block)); err != nil {
+		ctxerror.Log15(utils.GetLogInstance().Warn, err)
+		return ErrInvalidBlock
 	}
 
 	// Add attack model of IncorrectResponse
-	if attack.GetInstance().IncorrectResponse() {
+	if consensus.attackIncorrectResponse() {
 		utils.GetLogInstance().Warn("IncorrectResponse attacked")
-		return
+		return ErrAttacked
+	}
+
+	if consensus.NumBlockVerifiers <= 0 {
+		return consensus.onBlockVerified(message, &blockObj, consensus.verifyBlock(&blockObj))
+	}
+	if consensus.blockVerifierSlots == nil {
+		consensus.blockVerifierSlots = make(chan struct{}, consensus.NumBlockVerifiers)
 	}
+	slots := consensus.blockVerifierSlots
+	consensus.workers.Add(1)
+	go func() {
+		defer consensus.workers.Done()
+		slots <- struct{}{}
+		err := consensus.verifyBlock(&blockObj)
+		<-slots
+
+		consensus.mutex.Lock()
+		defer consensus.mutex.Unlock()
+		if err := consensus.onBlockVerified(message, &blockObj, err); err != nil {
+			consensus.reportRejection(message, err)
+		}
+	}()
+	return nil
+}
 
-	// check block data transactions
+// verifyBlock checks the header and the transactions of an announced block.
+func (consensus *Consensus) verifyBlock(blockObj *types.Block) error {
 	if err := consensus.VerifyHeader(consensus.ChainReader, blockObj.Header(), false); err != nil {
-		utils.GetLogInstance().Warn("Block content is not verified successfully", "error", err)
-		return
+		return ctxerror.New("block header verification failed",
+			"blockHash", blockObj.Hash(),
+		).WithCause(err)
 	}
 	if consensus.BlockVerifier == nil {
 		// do nothing
-	} else if err := consensus.BlockVerifier(&blockObj); err != nil {
+	} else if err := consensus.BlockVerifier(blockObj); err != nil {
 		// TODO ek – maybe we could do this in commit phase
-		err := ctxerror.New("block verification failed",
+		return ctxerror.New("block verification failed",
 			"blockHash", blockObj.Hash(),
 		).WithCause(err)
+	}
+	return nil
+}
+
+// onBlockVerified sends the prepare message for a verified announced block, unless
+// the round moved on while the block was being verified. The caller must hold consensus.mutex.
+func (consensus *Consensus) onBlockVerified(message *msg_pb.Message, blockObj *types.Block, err error) error {
+	consensusMsg := message.GetConsensus()
+	if consensus.stopped {
+		return nil
+	}
+	if err != nil {
 		ctxerror.Log15(utils.GetLogInstance().Warn, err)
-		return
+		return ErrInvalidBlock
 	}
+	if consensusMsg.ViewId != consensus.viewID || !bytes.Equal(consensusMsg.BlockHash, consensus.blockHash[:]) ||
+		consensus.state == CommitDone {
+		utils.GetLogInstance().Debug("Round moved on during block verification", "viewID", consensusMsg.ViewId, "myViewID", consensus.viewID)
+		return ErrStaleView
+	}
+	consensus.lastAnnouncedViewID = consensusMsg.ViewId
+	consensus.lastAnnouncedTxs = blockObj.Transactions()
 
 	// Construct and send prepare message
-	msgToSend := consensus.constructPrepareMessage()
-	utils.GetLogInstance().Warn("[Consensus]", "sent prepare message", len(msgToSend))
-	consensus.host.SendMessageToGroups([]p2p.GroupID{p2p.NewGroupIDByShardID(p2p.ShardID(consensus.ShardID))}, host.ConstructP2pMessage(byte(17), msgToSend))
+	for _, msgToSend := range consensus.constructPrepareMessages() {
+		utils.GetLogInstance().Warn("[Consensus]", "sent prepare message", len(msgToSend))
+		consensus.sendToLeader(msgToSend)
+	}
+
+	consensus.setState(PrepareDone)
+	consensus.startPhaseTimeout(timeoutPrepared)
 
-	consensus.state = PrepareDone
+	// The prepared message may have arrived while the block was being verified
+	if held := consensus.heldPrepared; held != nil {
+		consensus.heldPrepared = nil
+		if err := consensus.onPreparedMessage(held); err != nil {
+			consensus.reportRejection(held, err)
+		}
+	}
+	return nil
 }
 
 // Processes the prepared message sent from the leader
-func (consensus *Consensus) processPreparedMessage(message *msg_pb.Message) {
+func (consensus *Consensus) processPreparedMessage(message *msg_pb.Message) error {
 	utils.GetLogInstance().Info("Received Prepared Message", "ValidatorAddress", consensus.SelfAddress)
 
 	consensusMsg := message.GetConsensus()
 
 	viewID := consensusMsg.ViewId
-	blockHash := consensusMsg.BlockHash
 	pubKey, err := bls_cosi.BytesToBlsPublicKey(consensusMsg.SenderPubkey)
 	if err != nil {
 		utils.GetLogInstance().Debug("Failed to deserialize BLS public key", "error", err)
-		return
+		return ErrMalformedMessage
 	}
-	addrBytes := pubKey.GetAddress()
-	leaderAddress := common.BytesToAddress(addrBytes[:]).Hex()
+	leaderAddress := blsPubKeyToAddress(pubKey)
 
-	messagePayload := consensusMsg.Payload
+	// Update readyByConsensus for attack.
+	consensus.attackUpdateConsensusReady(viewID)
 
-	//#### Read payload data
-	offset := 0
-	// 48 byte of multi-sig
-	multiSig := messagePayload[offset : offset+48]
-	offset += 48
+	consensus.mutex.Lock()
+	defer consensus.mutex.Unlock()
 
-	// bitmap
-	bitmap := messagePayload[offset:]
-	//#### END Read payload data
+	if !bytes.Equal(consensusMsg.SenderPubkey, consensus.leader.ConsensusPubKey.Serialize()) {
+		utils.GetLogInstance().Warn("Prepared message not sent by the leader", "sender Address", leaderAddress, "leader Address", blsPubKeyToAddress(consensus.leader.ConsensusPubKey))
+		return ErrUnknownLeader
+	}
+	if err := consensus.verifyConsensusMessageSig(message, consensus.leader.ConsensusPubKey); err != nil {
+		return err
+	}
 
-	// Update readyByConsensus for attack.
-	attack.GetInstance().UpdateConsensusReady(viewID)
+	// The validator only commits a block it verified and prepared.
+	if consensus.state != PrepareDone {
+		if viewID == consensus.viewID && bytes.Equal(consensusMsg.BlockHash, consensus.blockHash[:]) && consensus.state != CommitDone {
+			// The announced block is still being verified, see prepareAnnouncedBlock
+			utils.GetLogInstance().Debug("Holding the prepared message until the block is verified", "viewID", viewID)
+			consensus.heldPrepared = message
+			return nil
+		}
+		utils.GetLogInstance().Warn("Received the prepared message before preparing the block", "viewID", viewID, "state", consensus.state)
+		return ErrOutOfOrder
+	}
+	return consensus.onPreparedMessage(message)
+}
 
-	if err := consensus.checkConsensusMessage(message, consensus.leader.ConsensusPubKey); err != nil {
+// onPreparedMessage verifies the prepare phase multi-signature of a prepared message whose
+// signature was verified, and sends the commit message. The caller must hold consensus.mutex.
+func (consensus *Consensus) onPreparedMessage(message *msg_pb.Message) error {
+	consensusMsg := message.GetConsensus()
+	blockHash := consensusMsg.BlockHash
+	leaderAddress := blsPubKeyToAddress(consensus.leader.ConsensusPubKey)
+
+	if err := consensus.checkVerifiedConsensusMessage(message, consensus.leader.ConsensusPubKey); err != nil {
 		utils.GetLogInstance().Debug("processPreparedMessage error", "error", err)
-		return
+		return err
+	}
+
+	payload, err := decodeMultiSigPayload(consensusMsg.Payload)
+	if err != nil {
+		utils.GetLogInstance().Warn("Failed to read the prepared message payload", "error", err, "leader Address", leaderAddress)
+		return ErrMalformedMessage
 	}
+	multiSig := payload.Signature
+	bitmap := payload.Bitmap
 
 	// Add attack model of IncorrectResponse.
-	if attack.GetInstance().IncorrectResponse() {
+	if consensus.attackIncorrectResponse() {
 		utils.GetLogInstance().Warn("IncorrectResponse attacked")
-		return
+		return ErrAttacked
 	}
 
-	consensus.mutex.Lock()
-	defer consensus.mutex.Unlock()
-
 	// Verify the multi-sig for prepare phase
 	deserializedMultiSig := bls.Sign{}
 	err = deserializedMultiSig.Deserialize(multiSig)
 	if err != nil {
 		utils.GetLogInstance().Warn("Failed to deserialize the multi signature for prepare phase", "Error", err, "leader Address", leaderAddress)
-		return
+		return ErrMalformedMessage
 	}
 	mask, err := bls_cosi.NewMask(consensus.PublicKeys, nil)
-	mask.SetMask(bitmap)
-	if !deserializedMultiSig.VerifyHash(mask.AggregatePublic, blockHash) || err != nil {
-		utils.GetLogInstance().Warn("Failed to verify the multi signature for prepare phase", "Error", err, "leader Address", leaderAddress, "PubKeys", len(consensus.PublicKeys))
-		return
+	if err != nil {
+		utils.GetLogInstance().Warn("Failed to create the mask for prepare phase", "Error", err, "leader Address", leaderAddress)
+		return err
+	}
+	if err := mask.SetMask(bitmap); err != nil {
+		utils.GetLogInstance().Warn("Failed to set the bitmap for prepare phase", "Error", err, "leader Address", leaderAddress)
+		return ErrMalformedMessage
+	}
+	if !deserializedMultiSig.VerifyHash(mask.AggregatePublic, blockHash) {
+		utils.GetLogInstance().Warn("Failed to verify the multi signature for prepare phase", "leader Address", leaderAddress, "PubKeys", len(consensus.PublicKeys))
+		return ErrBadSignature
 	}
 	consensus.aggregatedPrepareSig = &deserializedMultiSig
 	consensus.prepareBitmap = mask
 
 	// Construct and send the commit message
-	multiSigAndBitmap := append(multiSig, bitmap...)
-	msgToSend := consensus.constructCommitMessage(multiSigAndBitmap)
-	utils.GetLogInstance().Warn("[Consensus]", "sent commit message", len(msgToSend))
-	consensus.host.SendMessageToGroups([]p2p.GroupID{p2p.NewGroupIDByShardID(p2p.ShardID(consensus.ShardID))}, host.ConstructP2pMessage(byte(17), msgToSend))
+	multiSigAndBitmap := payload.sigAndBitmap()
+	for _, msgToSend := range consensus.constructCommitMessages(multiSigAndBitmap) {
+		utils.GetLogInstance().Warn("[Consensus]", "sent commit message", len(msgToSend))
+		consensus.sendToLeader(msgToSend)
+	}
 
-	consensus.state = CommitDone
+	consensus.setState(CommitDone)
+	consensus.consensusTimeout[timeoutPrepared].Stop()
+	consensus.startPhaseTimeout(timeoutCommitted)
+	return nil
 }
 
 // Processes the committed message sent from the leader
-func (consensus *Consensus) processCommittedMessage(message *msg_pb.Message) {
+func (consensus *Consensus) processCommittedMessage(message *msg_pb.Message) error {
 	utils.GetLogInstance().Warn("Received Committed Message", "ValidatorAddress", consensus.SelfAddress)
 
 	consensusMsg := message.GetConsensus()
@@ -181,99 +413,276 @@ func (consensus *Consensus) processCommittedMessage(message *msg_pb.Message) {
 	pubKey, err := bls_cosi.BytesToBlsPublicKey(consensusMsg.SenderPubkey)
 	if err != nil {
 		utils.GetLogInstance().Debug("Failed to deserialize BLS public key", "error", err)
-		return
+		return ErrMalformedMessage
 	}
-	addrBytes := pubKey.GetAddress()
-	leaderAddress := common.BytesToAddress(addrBytes[:]).Hex()
-	messagePayload := consensusMsg.Payload
+	leaderAddress := blsPubKeyToAddress(pubKey)
+	payload, err := decodeMultiSigPayload(consensusMsg.Payload)
+	if err != nil {
+		utils.GetLogInstance().Warn("Failed to read the committed message payload", "error", err, "leader Address", leaderAddress)
+		return ErrMalformedMessage
+	}
+	multiSig := payload.Signature
+	bitmap := payload.Bitmap
 
-	//#### Read payload data
-	offset := 0
-	// 48 byte of multi-sig
-	multiSig := messagePayload[offset : offset+48]
-	offset += 48
+	// Update readyByConsensus for attack.
+	consensus.attackUpdateConsensusReady(viewID)
 
-	// bitmap
-	bitmap := messagePayload[offset:]
-	//#### END Read payload data
+	consensus.mutex.Lock()
+	defer consensus.mutex.Unlock()
 
-	// Update readyByConsensus for attack.
-	attack.GetInstance().UpdateConsensusReady(viewID)
+	if !bytes.Equal(consensusMsg.SenderPubkey, consensus.leader.ConsensusPubKey.Serialize()) {
+		utils.GetLogInstance().Warn("Committed message not sent by the leader", "sender Address", leaderAddress, "leader Address", blsPubKeyToAddress(consensus.leader.ConsensusPubKey))
+		return ErrUnknownLeader
+	}
+
+	if viewID < consensus.viewID && !consensus.ignoreViewIDCheck {
+		// The view is already committed, e.g. from a block response, so the message applies no block.
+		if err := verifyMessageSig(consensus.leader.ConsensusPubKey, message); err != nil {
+			return ErrBadSignature
+		}
+		consensus.reportCommittedEvent(CommittedEvent{ViewID: consensus.viewID})
+		return ErrStaleView
+	}
+	if viewID > consensus.viewID && !consensus.ignoreViewIDCheck {
+		// The leader committed a later view, so the blocks up to it can be requested.
+		if err := verifyMessageSig(consensus.leader.ConsensusPubKey, message); err == nil && viewID > consensus.highestCommittedViewID {
+			consensus.highestCommittedViewID = viewID
+			consensus.catchupBlocks = 0
+			consensus.requestMissingBlock()
+		}
+	}
 
 	if err := consensus.checkConsensusMessage(message, consensus.leader.ConsensusPubKey); err != nil {
 		utils.GetLogInstance().Debug("processCommittedMessage error", "error", err)
-		return
+		return err
 	}
 
 	// Add attack model of IncorrectResponse.
-	if attack.GetInstance().IncorrectResponse() {
+	if consensus.attackIncorrectResponse() {
 		utils.GetLogInstance().Warn("IncorrectResponse attacked")
-		return
+		return ErrAttacked
 	}
 
-	consensus.mutex.Lock()
-	defer consensus.mutex.Unlock()
-
 	// Verify the multi-sig for commit phase
 	deserializedMultiSig := bls.Sign{}
 	err = deserializedMultiSig.Deserialize(multiSig)
 	if err != nil {
 		utils.GetLogInstance().Warn("Failed to deserialize the multi signature for commit phase", "Error", err, "leader Address", leaderAddress)
-		return
+		return ErrMalformedMessage
 	}
 	mask, err := bls_cosi.NewMask(consensus.PublicKeys, nil)
-	mask.SetMask(bitmap)
+	if err != nil {
+		utils.GetLogInstance().Warn("Failed to create the mask for commit phase", "Error", err, "leader Address", leaderAddress)
+		return err
+	}
+	if err := mask.SetMask(bitmap); err != nil {
+		utils.GetLogInstance().Warn("Failed to set the bitmap for commit phase", "Error", err, "leader Address", leaderAddress)
+		return ErrMalformedMessage
+	}
+	if !consensus.IsQuorumAchieved(mask) {
+		utils.GetLogInstance().Warn("The commit phase signature is not signed by a quorum", "leader Address", leaderAddress, "numSigners", mask.CountEnabled())
+		return ErrNoQuorum
+	}
+	if consensus.aggregatedPrepareSig == nil || consensus.prepareBitmap == nil {
+		utils.GetLogInstance().Warn("Received the commit phase signature before the prepare phase one", "leader Address", leaderAddress)
+		return ErrOutOfOrder
+	}
 	prepareMultiSigAndBitmap := append(consensus.aggregatedPrepareSig.Serialize(), consensus.prepareBitmap.Bitmap...)
-	if !deserializedMultiSig.VerifyHash(mask.AggregatePublic, prepareMultiSigAndBitmap) || err != nil {
-		utils.GetLogInstance().Warn("Failed to verify the multi signature for commit phase", "Error", err, "leader Address", leaderAddress)
-		return
+	if !deserializedMultiSig.VerifyHash(mask.AggregatePublic, prepareMultiSigAndBitmap) {
+		utils.GetLogInstance().Warn("Failed to verify the multi signature for commit phase", "leader Address", leaderAddress)
+		return ErrBadSignature
 	}
 	consensus.aggregatedCommitSig = &deserializedMultiSig
 	consensus.commitBitmap = mask
 
-	consensus.state = CommittedDone
-	// TODO: the block catch up logic is a temporary workaround for full failure node catchup. Implement the full node catchup logic
-	// The logic is to roll up to the latest blocks one by one to try catching up with the leader.
-	// but because of checkConsensusMessage, the catchup logic will never be used here
-	for {
-		val, ok := consensus.blocksReceived[consensus.viewID]
-		if ok {
-			delete(consensus.blocksReceived, consensus.viewID)
-
-			consensus.blockHash = [32]byte{}
-			consensus.viewID = viewID + 1 // roll up one by one, until the next block is not received yet.
-
-			var blockObj types.Block
-			err := rlp.DecodeBytes(val.block, &blockObj)
-			if err != nil {
-				utils.GetLogInstance().Debug("failed to construct the new block after consensus")
-			}
-			// check block data (transactions
-			if err := consensus.VerifyHeader(consensus.ChainReader, blockObj.Header(), false); err != nil {
-				utils.GetLogInstance().Debug("[WARNING] Block content is not verified successfully", "viewID", consensus.viewID)
-				return
-			}
-
-			// Put the signatures into the block
-			blockObj.SetPrepareSig(
-				consensus.aggregatedPrepareSig.Serialize(),
-				consensus.prepareBitmap.Bitmap)
-			blockObj.SetCommitSig(
-				consensus.aggregatedCommitSig.Serialize(),
-				consensus.commitBitmap.Bitmap)
-			utils.GetLogInstance().Info("Adding block to chain", "numTx", len(blockObj.Transactions()))
-			consensus.OnConsensusDone(&blockObj)
-			consensus.ResetState()
-
-			select {
-			case consensus.VerifiedNewBlock <- &blockObj:
-			default:
-				utils.GetLogInstance().Info("[SYNC] consensus verified block send to chan failed", "blockHash", blockObj.Hash())
-				continue
-			}
-		} else {
-			break
+	consensus.setState(CommittedDone)
+	consensus.stopPhaseTimeouts()
+	consensus.numPhaseTimeouts = 0
+	consensus.resetLeaderFaults()
+	numBlocks := 0
+	numSigners := mask.CountEnabled()
+	defer func(margin int) {
+		consensus.reportCommittedEvent(CommittedEvent{
+			ViewID:           consensus.viewID,
+			NumBlocks:        numBlocks,
+			NumCommitSigners: numSigners,
+			QuorumMargin:     margin,
+		})
+	}(consensus.quorumPolicy().Margin(mask))
+
+	// The signatures only cover the block of this view. The blocks received for the later
+	// views wait for their own committed message, or are requested with their signatures.
+	val, ok := consensus.blocksReceived[consensus.viewID]
+	if !ok {
+		consensus.requestMissingBlock()
+		return nil
+	}
+	var blockObj types.Block
+	if err := rlp.DecodeBytes(val.block, &blockObj); err != nil {
+		utils.GetLogInstance().Debug("failed to construct the new block after consensus")
+		return ErrInvalidBlock
+	}
+	// check block data (transactions
+	if err := consensus.VerifyHeader(consensus.ChainReader, blockObj.Header(), false); err != nil {
+		utils.GetLogInstance().Debug("[WARNING] Block content is not verified successfully", "viewID", consensus.viewID)
+		return ErrInvalidBlock
+	}
+	delete(consensus.blocksReceived, consensus.viewID)
+	delete(consensus.announceMessages, consensus.viewID)
+	consensus.blockHash = [32]byte{}
+	consensus.viewID++
+	consensus.applyValidatorSetUpdate()
+
+	// Put the signatures into the block
+	blockObj.SetPrepareSig(consensus.aggregatedPrepareSig.Serialize(), consensus.prepareBitmap.Bitmap)
+	blockObj.SetCommitSig(consensus.aggregatedCommitSig.Serialize(), consensus.commitBitmap.Bitmap)
+	utils.GetLogInstance().Info("Adding block to chain", "numTx", len(blockObj.Transactions()))
+	consensus.OnConsensusDone(&blockObj)
+	consensus.metrics().RoundCompleted()
+	consensus.ResetState()
+	numBlocks++
+	consensus.catchupBlocks = 0
+
+	select {
+	case consensus.VerifiedNewBlock <- &blockObj:
+	default:
+		utils.GetLogInstance().Info("[SYNC] consensus verified block send to chan failed", "blockHash", blockObj.Hash())
+	}
+	consensus.requestMissingBlock()
+	consensus.pruneSeenMessages()
+	consensus.startPipelinedView()
+	return nil
+}
+
+// isPipelinedView returns whether an announce for viewID is for a later view
+// within MaxInFlightViews. The caller must hold consensus.mutex.
+func (consensus *Consensus) isPipelinedView(viewID uint32) bool {
+	return !consensus.ignoreViewIDCheck && viewID > consensus.viewID &&
+		viewID-consensus.viewID < uint32(consensus.MaxInFlightViews)
+}
+
+// pipelineAnnounce holds the announce of a later view until the views before it commit.
+// The caller must hold consensus.mutex.
+func (consensus *Consensus) pipelineAnnounce(message *msg_pb.Message) error {
+	consensusMsg := message.GetConsensus()
+	leaderKey := consensus.leader.ConsensusPubKey
+	if err := verifyMessageSig(leaderKey, message); err != nil {
+		utils.GetLogInstance().Debug("Failed to verify the pipelined announce", "error", err, "viewID", consensusMsg.ViewId)
+		return ErrBadSignature
+	}
+	if err := consensus.checkNonce(message, leaderKey); err != nil {
+		return err
+	}
+	consensus.acceptNonce(message, leaderKey)
+	consensus.announceMessages[consensusMsg.ViewId] = message
+	consensus.pipelinedAnnounces[consensusMsg.ViewId] = message
+	utils.GetLogInstance().Info("Pipelined announce", "viewID", consensusMsg.ViewId, "myViewID", consensus.viewID)
+	return nil
+}
+
+// startPipelinedView prepares the block of the current view if its announce was pipelined.
+// The caller must hold consensus.mutex.
+func (consensus *Consensus) startPipelinedView() {
+	for viewID := range consensus.pipelinedAnnounces {
+		if viewID < consensus.viewID {
+			delete(consensus.pipelinedAnnounces, viewID)
 		}
+	}
+	message, ok := consensus.pipelinedAnnounces[consensus.viewID]
+	if !ok {
+		return
+	}
+	delete(consensus.pipelinedAnnounces, consensus.viewID)
+
+	consensusMsg := message.GetConsensus()
+	consensus.blocksReceived[consensus.viewID] = &BlockConsensusStatus{consensusMsg.Payload, consensus.state}
+	copy(consensus.blockHash[:], consensusMsg.BlockHash)
+	consensus.block = consensusMsg.Payload
+	if err := consensus.prepareAnnouncedBlock(message); err != nil {
+		consensus.reportRejection(message, err)
+	}
+}
 
+// requestMissingBlock asks for the block of the current view if a later view
+// has already been received or committed, i.e. the node lags behind the leader.
+// The request is retried with exponential backoff until the block arrives,
+// the node moves past the view, or blockRequestMaxRetries is reached.
+// The caller must hold consensus.mutex.
+func (consensus *Consensus) requestMissingBlock() {
+	if consensus.RequestMissingBlock == nil {
+		return
+	}
+	viewID := consensus.viewID
+	if consensus.blockRequests[viewID] {
+		return
+	}
+	if consensus.highestCommittedViewID > viewID {
+		utils.GetLogInstance().Info("Missing committed block, requesting it", "viewID", viewID, "committedViewID", consensus.highestCommittedViewID)
+		consensus.blockRequests[viewID] = true
+		consensus.workers.Add(1)
+		go consensus.retryBlockRequest(viewID)
+		return
+	}
+	for receivedViewID := range consensus.blocksReceived {
+		if receivedViewID > viewID {
+			utils.GetLogInstance().Info("Missing block in catch up, requesting it", "viewID", viewID, "receivedViewID", receivedViewID)
+			consensus.blockRequests[viewID] = true
+			consensus.workers.Add(1)
+			go consensus.retryBlockRequest(viewID)
+			return
+		}
+	}
+}
+
+func (consensus *Consensus) retryBlockRequest(viewID uint32) {
+	defer consensus.workers.Done()
+	backoff := p2p.NewExpBackoff(blockRequestMinBackoff, blockRequestMaxBackoff, 2)
+	for i := 0; i < blockRequestMaxRetries && consensus.isBlockMissing(viewID); i++ {
+		consensus.RequestMissingBlock(viewID)
+		backoff.Sleep()
+	}
+	consensus.mutex.Lock()
+	delete(consensus.blockRequests, viewID)
+	consensus.mutex.Unlock()
+}
+
+// isBlockMissing returns whether the node is still waiting for the block of the given view.
+func (consensus *Consensus) isBlockMissing(viewID uint32) bool {
+	consensus.mutex.Lock()
+	defer consensus.mutex.Unlock()
+	_, ok := consensus.blocksReceived[viewID]
+	return !consensus.stopped && consensus.viewID <= viewID && (!ok || consensus.highestCommittedViewID > viewID)
+}
+
+// reportCommittedEvent delivers the event to CommittedEventChan if anyone listens, without blocking.
+func (consensus *Consensus) reportCommittedEvent(event CommittedEvent) {
+	if consensus.CommittedEventChan == nil {
+		return
+	}
+	select {
+	case consensus.CommittedEventChan <- event:
+	default:
+		utils.GetLogInstance().Info("committed event send to chan failed", "viewID", event.ViewID, "numBlocks", event.NumBlocks)
+	}
+}
+
+// getAttackModel returns the attack model consulted by the validator hooks.
+var getAttackModel = attack.GetInstance
+
+// attackIncorrectResponse returns whether the attack model wants an incorrect response.
+// It never consults the attack model unless EnableAttackModel is set.
+func (consensus *Consensus) attackIncorrectResponse() bool {
+	if !consensus.EnableAttackModel {
+		return false
+	}
+	return getAttackModel().IncorrectResponse()
+}
+
+// attackUpdateConsensusReady updates the attack model with the current viewID
+// if EnableAttackModel is set.
+func (consensus *Consensus) attackUpdateConsensusReady(viewID uint32) {
+	if !consensus.EnableAttackModel {
+		return
 	}
+	getAttackModel().UpdateConsensusReady(viewID)
 }
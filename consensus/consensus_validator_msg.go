@@ -1,6 +1,8 @@
 package consensus
 
 import (
+	"github.com/harmony-one/bls/ffi/go/bls"
+
 	"github.com/harmony-one/harmony/api/proto"
 	msg_pb "github.com/harmony-one/harmony/api/proto/message"
 	"github.com/harmony-one/harmony/internal/utils"
@@ -8,6 +10,24 @@ import (
 
 // Construct the prepare message to send to leader (assumption the consensus data is already verified)
 func (consensus *Consensus) constructPrepareMessage() []byte {
+	return consensus.constructPrepareMessageWithKey(consensus.priKey)
+}
+
+// constructPrepareMessages constructs the prepare message of every validator seat this node holds,
+// except those whose SignState refuses the current view.
+func (consensus *Consensus) constructPrepareMessages() [][]byte {
+	msgs := [][]byte{}
+	for _, priKey := range consensus.priKeys() {
+		if !consensus.canSign(priKey, msg_pb.MessageType_PREPARE) {
+			continue
+		}
+		msgs = append(msgs, consensus.constructPrepareMessageWithKey(priKey))
+	}
+	return msgs
+}
+
+// constructPrepareMessageWithKey constructs the prepare message of the seat of the given key.
+func (consensus *Consensus) constructPrepareMessageWithKey(priKey *bls.SecretKey) []byte {
 	message := &msg_pb.Message{
 		ServiceType: msg_pb.ServiceType_CONSENSUS,
 		Type:        msg_pb.MessageType_PREPARE,
@@ -18,14 +38,15 @@ func (consensus *Consensus) constructPrepareMessage() []byte {
 
 	consensusMsg := message.GetConsensus()
 	consensus.populateMessageFields(consensusMsg)
+	consensusMsg.SenderPubkey = priKey.GetPublicKey().Serialize()
 
 	// 48 byte of bls signature
-	sign := consensus.priKey.SignHash(consensusMsg.BlockHash)
+	sign := priKey.SignHash(consensusMsg.BlockHash)
 	if sign != nil {
 		consensusMsg.Payload = sign.Serialize()
 	}
 
-	marshaledMessage, err := consensus.signAndMarshalConsensusMessage(message)
+	marshaledMessage, err := signAndMarshalConsensusMessageWithKey(priKey, message)
 	if err != nil {
 		utils.GetLogInstance().Error("Failed to sign and marshal the Prepare message", "error", err)
 	}
@@ -34,6 +55,24 @@ func (consensus *Consensus) constructPrepareMessage() []byte {
 
 // Construct the commit message which contains the signature on the multi-sig of prepare phase.
 func (consensus *Consensus) constructCommitMessage(multiSigAndBitmap []byte) []byte {
+	return consensus.constructCommitMessageWithKey(consensus.priKey, multiSigAndBitmap)
+}
+
+// constructCommitMessages constructs the commit message of every validator seat this node holds,
+// except those whose SignState refuses the current view.
+func (consensus *Consensus) constructCommitMessages(multiSigAndBitmap []byte) [][]byte {
+	msgs := [][]byte{}
+	for _, priKey := range consensus.priKeys() {
+		if !consensus.canSign(priKey, msg_pb.MessageType_COMMIT) {
+			continue
+		}
+		msgs = append(msgs, consensus.constructCommitMessageWithKey(priKey, multiSigAndBitmap))
+	}
+	return msgs
+}
+
+// constructCommitMessageWithKey constructs the commit message of the seat of the given key.
+func (consensus *Consensus) constructCommitMessageWithKey(priKey *bls.SecretKey, multiSigAndBitmap []byte) []byte {
 	message := &msg_pb.Message{
 		ServiceType: msg_pb.ServiceType_CONSENSUS,
 		Type:        msg_pb.MessageType_COMMIT,
@@ -44,14 +83,15 @@ func (consensus *Consensus) constructCommitMessage(multiSigAndBitmap []byte) []b
 
 	consensusMsg := message.GetConsensus()
 	consensus.populateMessageFields(consensusMsg)
+	consensusMsg.SenderPubkey = priKey.GetPublicKey().Serialize()
 
 	// 48 byte of bls signature
-	sign := consensus.priKey.SignHash(multiSigAndBitmap)
+	sign := priKey.SignHash(multiSigAndBitmap)
 	if sign != nil {
 		consensusMsg.Payload = sign.Serialize()
 	}
 
-	marshaledMessage, err := consensus.signAndMarshalConsensusMessage(message)
+	marshaledMessage, err := signAndMarshalConsensusMessageWithKey(priKey, message)
 	if err != nil {
 		utils.GetLogInstance().Error("Failed to sign and marshal the Commit message", "error", err)
 	}
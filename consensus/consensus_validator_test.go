@@ -3,18 +3,25 @@ package consensus
 import (
 	"encoding/hex"
 	"math/big"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/golang/mock/gomock"
 	protobuf "github.com/golang/protobuf/proto"
+	"github.com/harmony-one/bls/ffi/go/bls"
+	"github.com/stretchr/testify/assert"
 
 	"github.com/harmony-one/harmony/api/proto"
 	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	consensus_engine "github.com/harmony-one/harmony/consensus/engine"
 	"github.com/harmony-one/harmony/core/types"
 	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/crypto/hash"
+	"github.com/harmony-one/harmony/internal/attack"
 	"github.com/harmony-one/harmony/internal/utils"
 	"github.com/harmony-one/harmony/p2p"
 	mock_host "github.com/harmony-one/harmony/p2p/host/mock"
@@ -393,3 +400,1161 @@ func TestProcessMessageValidatorCommitted(test *testing.T) {
 	//	assert.Equal(test, Finished, consensusValidator1.state)
 	time.Sleep(1 * time.Second)
 }
+
+// testRound holds the leader messages of a complete consensus round on testBlockBytes.
+type testRound struct {
+	announce  *msg_pb.Message
+	prepared  *msg_pb.Message
+	committed *msg_pb.Message
+}
+
+// newTestRound has a single-member committee leader construct the announce,
+// prepared and committed messages of a round at the given viewID.
+func newTestRound(test *testing.T, ctrl *gomock.Controller, leader p2p.Peer, leaderPriKey *bls.SecretKey, viewID uint32) *testRound {
+	return newTestCommitteeRound(test, ctrl, leader, leaderPriKey, viewID, nil, 0)
+}
+
+// newTestCommitteeRound is newTestRound for a committee of the leader and the
+// given validators. All validators sign the prepare, but only the first
+// numCommitters of them sign the commit along with the leader.
+func newTestCommitteeRound(test *testing.T, ctrl *gomock.Controller, leader p2p.Peer, leaderPriKey *bls.SecretKey, viewID uint32, validatorPriKeys []*bls.SecretKey, numCommitters int) *testRound {
+	m := mock_host.NewMockHost(ctrl)
+	m.EXPECT().GetSelfPeer().Return(leader)
+	consensusLeader, err := New(m, 0, leader, leaderPriKey)
+	if err != nil {
+		test.Fatalf("Cannot craeate consensus: %v", err)
+	}
+	pubKeys := []*bls.PublicKey{leader.ConsensusPubKey}
+	for _, priKey := range validatorPriKeys {
+		pubKeys = append(pubKeys, priKey.GetPublicKey())
+	}
+	consensusLeader.UpdatePublicKeys(pubKeys)
+	consensusLeader.viewID = viewID
+	blockBytes, err := testBlockBytes()
+	if err != nil {
+		test.Fatalf("Cannot decode blockByte: %v", err)
+	}
+	consensusLeader.block = blockBytes
+	consensusLeader.blockHash = testBlockHash(test)
+
+	announceMsg := consensusLeader.constructAnnounceMessage()
+	consensusLeader.prepareSigs[consensusLeader.SelfAddress] = consensusLeader.priKey.SignHash(consensusLeader.blockHash[:])
+	for _, priKey := range validatorPriKeys {
+		consensusLeader.prepareSigs[utils.GetBlsAddress(priKey.GetPublicKey())] = priKey.SignHash(consensusLeader.blockHash[:])
+		consensusLeader.prepareBitmap.SetKey(priKey.GetPublicKey(), true)
+	}
+	preparedMsg, aggSig := consensusLeader.constructPreparedMessage()
+	multiSigAndBitmap := append(aggSig.Serialize(), consensusLeader.prepareBitmap.Bitmap...)
+	consensusLeader.commitSigs[consensusLeader.SelfAddress] = consensusLeader.priKey.SignHash(multiSigAndBitmap)
+	for _, priKey := range validatorPriKeys[:numCommitters] {
+		consensusLeader.commitSigs[utils.GetBlsAddress(priKey.GetPublicKey())] = priKey.SignHash(multiSigAndBitmap)
+		consensusLeader.commitBitmap.SetKey(priKey.GetPublicKey(), true)
+	}
+	committedMsg, _ := consensusLeader.constructCommittedMessage()
+
+	return &testRound{
+		announce:  testConsensusMessage(test, announceMsg),
+		prepared:  testConsensusMessage(test, preparedMsg),
+		committed: testConsensusMessage(test, committedMsg),
+	}
+}
+
+// newTestValidator returns a validator following the single-member committee of the given leader.
+func newTestValidator(test *testing.T, ctrl *gomock.Controller, leader p2p.Peer) *Consensus {
+	m := mock_host.NewMockHost(ctrl)
+	m.EXPECT().GetSelfPeer().Return(leader)
+	m.EXPECT().SendMessageToGroups([]p2p.GroupID{p2p.GroupIDBeacon}, gomock.Any()).AnyTimes()
+	consensusValidator, err := New(m, 0, leader, bls_cosi.RandPrivateKey())
+	if err != nil {
+		test.Fatalf("Cannot craeate consensus: %v", err)
+	}
+	consensusValidator.UpdatePublicKeys([]*bls.PublicKey{leader.ConsensusPubKey})
+	consensusValidator.ChainReader = MockChainReader{}
+	consensusValidator.OnConsensusDone = func(newBlock *types.Block) {}
+	// Verify announced blocks inline so that tests can check the state right after each message.
+	consensusValidator.NumBlockVerifiers = 0
+	return consensusValidator
+}
+
+func testBlockHash(test *testing.T) [32]byte {
+	hashBytes, err := hex.DecodeString("bdd66a8211ffcbf0ad431b506c854b49264951fd9f690928e9cf44910c381053")
+	if err != nil {
+		test.Fatalf("Cannot decode hashByte: %v", err)
+	}
+	var hash [32]byte
+	copy(hash[:], hashBytes)
+	return hash
+}
+
+func testConsensusMessage(test *testing.T, msgBytes []byte) *msg_pb.Message {
+	msgPayload, err := proto.GetConsensusMessagePayload(msgBytes)
+	if err != nil {
+		test.Fatalf("Failed to get consensus message: %v", err)
+	}
+	message := &msg_pb.Message{}
+	if err := protobuf.Unmarshal(msgPayload, message); err != nil {
+		test.Fatalf("Failed to unmarshal message payload: %v", err)
+	}
+	return message
+}
+
+func TestProcessMessageValidatorCommittedOnlySignedBlock(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.CommittedEventChan = make(chan CommittedEvent, 1)
+
+	consensusValidator.processAnnounceMessage(round.announce)
+	consensusValidator.processPreparedMessage(round.prepared)
+
+	// Blocks of the following views were received while the node lagged behind.
+	blockBytes, _ := testBlockBytes()
+	consensusValidator.blocksReceived[1] = &BlockConsensusStatus{blockBytes, PrepareDone}
+	consensusValidator.blocksReceived[2] = &BlockConsensusStatus{blockBytes, PrepareDone}
+
+	consensusValidator.processCommittedMessage(round.committed)
+
+	select {
+	case event := <-consensusValidator.CommittedEventChan:
+		assert.Equal(test, CommittedEvent{ViewID: 1, NumBlocks: 1, NumCommitSigners: 1}, event)
+	default:
+		test.Fatal("no committed event reported")
+	}
+	assert.Equal(test, uint32(1), consensusValidator.GetViewID())
+	// The signatures of view 0 do not cover the later blocks, which wait for their own commit.
+	assert.Equal(test, 2, len(consensusValidator.blocksReceived))
+}
+
+func TestProcessMessageValidatorCommittedStaleView(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.CommittedEventChan = make(chan CommittedEvent, 1)
+
+	consensusValidator.processAnnounceMessage(round.announce)
+	consensusValidator.processPreparedMessage(round.prepared)
+	assert.NoError(test, consensusValidator.processCommittedMessage(round.committed))
+	<-consensusValidator.CommittedEventChan
+
+	// The view is already committed: the message is reported as applying no block.
+	assert.Equal(test, ErrStaleView, consensusValidator.processCommittedMessage(round.committed))
+	select {
+	case event := <-consensusValidator.CommittedEventChan:
+		assert.Equal(test, CommittedEvent{ViewID: 1}, event)
+	default:
+		test.Fatal("no committed event reported")
+	}
+	assert.Equal(test, uint32(1), consensusValidator.GetViewID())
+}
+
+func TestProcessMessageValidatorConcurrentAnnounceAndCommit(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+
+	consensusValidator.processAnnounceMessage(round.announce)
+	consensusValidator.processPreparedMessage(round.prepared)
+	assert.Equal(test, testBlockHash(test), consensusValidator.GetBlockHash())
+
+	// Run with -race: announce and commit processing both touch blockHash and block.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			consensusValidator.processAnnounceMessage(protobuf.Clone(round.announce).(*msg_pb.Message))
+		}()
+		go func() {
+			defer wg.Done()
+			consensusValidator.processCommittedMessage(protobuf.Clone(round.committed).(*msg_pb.Message))
+		}()
+		go func() {
+			defer wg.Done()
+			consensusValidator.GetBlockHash()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(test, uint32(1), consensusValidator.GetViewID())
+}
+
+func TestProcessMessageValidatorAttackModelDisabled(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	consulted := false
+	defer func(f func() *attack.Model) { getAttackModel = f }(getAttackModel)
+	getAttackModel = func() *attack.Model {
+		consulted = true
+		return attack.GetInstance()
+	}
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	assert.False(test, consensusValidator.EnableAttackModel)
+
+	consensusValidator.processAnnounceMessage(round.announce)
+	consensusValidator.processPreparedMessage(round.prepared)
+	consensusValidator.processCommittedMessage(round.committed)
+
+	assert.Equal(test, uint32(1), consensusValidator.GetViewID())
+	assert.False(test, consulted, "attack model consulted with EnableAttackModel off")
+
+	// Sanity check that the hooks do reach the attack model once enabled.
+	consensusValidator.EnableAttackModel = true
+	consensusValidator.attackUpdateConsensusReady(1)
+	assert.True(test, consulted)
+}
+
+func TestRoundStatus(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+
+	consensusValidator.processAnnounceMessage(round.announce)
+	status := consensusValidator.RoundStatus()
+	assert.Equal(test, PrepareDone.String(), status.State)
+	assert.Empty(test, status.PrepareSig)
+
+	consensusValidator.processPreparedMessage(round.prepared)
+	status = consensusValidator.RoundStatus()
+	blockHash := testBlockHash(test)
+	assert.Equal(test, CommitDone.String(), status.State)
+	assert.Equal(test, 1, status.NumPrepareSigners)
+	payload, err := decodeMultiSigPayload(round.prepared.GetConsensus().Payload)
+	if err != nil {
+		test.Fatalf("Cannot decode prepared payload: %v", err)
+	}
+	assert.Equal(test, hex.EncodeToString(payload.Signature), status.PrepareSig)
+	assert.Equal(test, hex.EncodeToString(blockHash[:]), status.BlockHash)
+	assert.Empty(test, status.CommitSig)
+}
+
+// resignTestMessage signs message again with priKey after it was modified.
+func resignTestMessage(test *testing.T, message *msg_pb.Message, priKey *bls.SecretKey) {
+	message.Signature = nil
+	marshaledMessage, err := protobuf.Marshal(message)
+	if err != nil {
+		test.Fatalf("Cannot marshal message: %v", err)
+	}
+	msgHash := hash.Keccak256(marshaledMessage)
+	message.Signature = priKey.SignHash(msgHash[:]).Serialize()
+}
+
+func TestProcessMessageValidatorPreparedWrongSender(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.processAnnounceMessage(round.announce)
+
+	// A prepared message claiming another sender, still carrying a valid leader signature.
+	prepared := protobuf.Clone(round.prepared).(*msg_pb.Message)
+	prepared.GetConsensus().SenderPubkey = bls_cosi.RandPrivateKey().GetPublicKey().Serialize()
+	resignTestMessage(test, prepared, leaderPriKey)
+	assert.NoError(test, verifyMessageSig(leader.ConsensusPubKey, prepared))
+
+	assert.Equal(test, ErrUnknownLeader, consensusValidator.processPreparedMessage(prepared))
+	assert.Equal(test, PrepareDone, consensusValidator.state)
+	assert.Nil(test, consensusValidator.aggregatedPrepareSig)
+
+	consensusValidator.processPreparedMessage(round.prepared)
+	assert.Equal(test, CommitDone, consensusValidator.state)
+}
+
+func TestProcessMessageValidatorCommittedRequestsMissingBlock(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	requested := make(chan uint32, 1)
+	consensusValidator.RequestMissingBlock = func(viewID uint32) {
+		select {
+		case requested <- viewID:
+		default:
+		}
+	}
+
+	consensusValidator.processAnnounceMessage(round.announce)
+	consensusValidator.processPreparedMessage(round.prepared)
+
+	// The block of view 2 was received, but the one of view 1 is missing.
+	blockBytes, _ := testBlockBytes()
+	consensusValidator.blocksReceived[2] = &BlockConsensusStatus{blockBytes, PrepareDone}
+
+	consensusValidator.processCommittedMessage(round.committed)
+	assert.Equal(test, uint32(1), consensusValidator.GetViewID())
+
+	select {
+	case viewID := <-requested:
+		assert.Equal(test, uint32(1), viewID)
+	case <-time.After(time.Second):
+		test.Fatal("no block request emitted for the missing view")
+	}
+}
+
+// testBlockBytesWithTime returns the test block with its timestamp set to blockTime.
+func testBlockBytesWithTime(test *testing.T, blockTime time.Time) []byte {
+	blockBytes, err := testBlockBytes()
+	if err != nil {
+		test.Fatalf("Cannot decode blockByte: %v", err)
+	}
+	var block types.Block
+	if err := rlp.DecodeBytes(blockBytes, &block); err != nil {
+		test.Fatalf("Cannot decode block: %v", err)
+	}
+	header := block.Header()
+	header.Time = big.NewInt(blockTime.Unix())
+	blockBytes, err = rlp.EncodeToBytes(types.NewBlockWithHeader(header))
+	if err != nil {
+		test.Fatalf("Cannot encode block: %v", err)
+	}
+	return blockBytes
+}
+
+func TestProcessMessageValidatorAnnounceForged(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+
+	// An announce of the leader signed with another key
+	forged := protobuf.Clone(round.announce).(*msg_pb.Message)
+	resignTestMessage(test, forged, bls_cosi.RandPrivateKey())
+
+	assert.Equal(test, ErrBadSignature, consensusValidator.processAnnounceMessage(forged))
+	assert.Equal(test, [32]byte{}, consensusValidator.GetBlockHash())
+	assert.Empty(test, consensusValidator.block)
+	assert.Empty(test, consensusValidator.blocksReceived)
+}
+
+func TestProcessMessageValidatorAnnounceFutureBlock(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	blockHash := testBlockHash(test)
+
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.MaxFutureBlockTime = time.Minute
+
+	farFuture := testBlockBytesWithTime(test, time.Now().Add(time.Hour))
+	consensusValidator.processAnnounceMessage(newTestAnnounce(test, ctrl, leader, leaderPriKey, 0, farFuture, blockHash))
+	assert.Equal(test, Finished, consensusValidator.state)
+
+	withinTolerance := testBlockBytesWithTime(test, time.Now().Add(30*time.Second))
+	consensusValidator.processAnnounceMessage(newTestAnnounce(test, ctrl, leader, leaderPriKey, 0, withinTolerance, blockHash))
+	assert.Equal(test, PrepareDone, consensusValidator.state)
+}
+
+func TestProcessMessageValidatorPreparedStaleNonce(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.processAnnounceMessage(round.announce)
+	assert.Equal(test, PrepareDone, consensusValidator.state)
+
+	// A correctly signed prepared message with a nonce below the one of the announce is
+	// accepted, as the gossip may deliver the messages of different phases out of order.
+	announceNonce := round.announce.GetConsensus().Nonce
+	prepared := protobuf.Clone(round.prepared).(*msg_pb.Message)
+	prepared.GetConsensus().Nonce = announceNonce - 1
+	resignTestMessage(test, prepared, leaderPriKey)
+
+	consensusValidator.processPreparedMessage(protobuf.Clone(prepared).(*msg_pb.Message))
+	assert.Equal(test, CommitDone, consensusValidator.state)
+
+	// Replaying the same message is rejected, and so is an older one of the same type
+	older := protobuf.Clone(round.prepared).(*msg_pb.Message)
+	older.GetConsensus().Nonce = announceNonce - 2
+	resignTestMessage(test, older, leaderPriKey)
+	for _, message := range []*msg_pb.Message{prepared, older} {
+		consensusValidator.mutex.Lock()
+		err := consensusValidator.checkConsensusMessage(message, leader.ConsensusPubKey)
+		consensusValidator.mutex.Unlock()
+		assert.Equal(test, consensus_engine.ErrInvalidConsensusMessage, err)
+	}
+}
+
+func TestProcessMessageValidatorNonceFlagDay(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	// A leader of a version before the nonces does not set them.
+	announce := protobuf.Clone(round.announce).(*msg_pb.Message)
+	announce.GetConsensus().Nonce = 0
+	resignTestMessage(test, announce, leaderPriKey)
+
+	// Before the flag day, the message is accepted.
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.NonceFlagDay = time.Now().Add(time.Hour)
+	consensusValidator.processAnnounceMessage(protobuf.Clone(announce).(*msg_pb.Message))
+	assert.Equal(test, PrepareDone, consensusValidator.state)
+
+	// After it, the message is rejected as a replay.
+	consensusValidator = newTestValidator(test, ctrl, leader)
+	consensusValidator.NonceFlagDay = time.Now().Add(-time.Hour)
+	consensusValidator.processAnnounceMessage(protobuf.Clone(announce).(*msg_pb.Message))
+	assert.NotEqual(test, PrepareDone, consensusValidator.state)
+}
+
+func TestProcessMessageValidatorConcurrentLeaderRotation(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	nextLeader := p2p.Peer{IP: "127.0.0.1", Port: "7783", ConsensusPubKey: bls_cosi.RandPrivateKey().GetPublicKey()}
+
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+
+	// Run with -race: the handlers read the leader while it rotates.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			consensusValidator.processAnnounceMessage(protobuf.Clone(round.announce).(*msg_pb.Message))
+		}()
+		go func() {
+			defer wg.Done()
+			consensusValidator.processPreparedMessage(protobuf.Clone(round.prepared).(*msg_pb.Message))
+		}()
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				consensusValidator.SetLeader(nextLeader)
+			} else {
+				consensusValidator.SetLeader(leader)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			consensusValidator.GetLeaderPubKey()
+		}()
+	}
+	wg.Wait()
+
+	consensusValidator.SetLeader(nextLeader)
+	assert.Equal(test, nextLeader, consensusValidator.Leader())
+	assert.True(test, consensusValidator.GetLeaderPubKey().IsEqual(nextLeader.ConsensusPubKey))
+}
+
+func TestProcessMessageValidatorCommittedReportsQuorumMargin(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	validatorPriKeys := []*bls.SecretKey{bls_cosi.RandPrivateKey(), bls_cosi.RandPrivateKey(), bls_cosi.RandPrivateKey()}
+	pubKeys := []*bls.PublicKey{leader.ConsensusPubKey}
+	for _, priKey := range validatorPriKeys {
+		pubKeys = append(pubKeys, priKey.GetPublicKey())
+	}
+
+	commit := func(numCommitters int) CommittedEvent {
+		round := newTestCommitteeRound(test, ctrl, leader, leaderPriKey, 0, validatorPriKeys, numCommitters)
+		consensusValidator := newTestValidator(test, ctrl, leader)
+		consensusValidator.UpdatePublicKeys(pubKeys)
+		consensusValidator.CommittedEventChan = make(chan CommittedEvent, 1)
+
+		consensusValidator.processAnnounceMessage(round.announce)
+		consensusValidator.processPreparedMessage(round.prepared)
+		consensusValidator.processCommittedMessage(round.committed)
+		select {
+		case event := <-consensusValidator.CommittedEventChan:
+			return event
+		default:
+			test.Fatal("no committed event reported")
+		}
+		return CommittedEvent{}
+	}
+
+	// 4 members: the quorum is 3
+	exactQuorum := commit(2)
+	assert.Equal(test, CommittedEvent{ViewID: 1, NumBlocks: 1, NumCommitSigners: 3, QuorumMargin: 0}, exactQuorum)
+	superMajority := commit(3)
+	assert.Equal(test, CommittedEvent{ViewID: 1, NumBlocks: 1, NumCommitSigners: 4, QuorumMargin: 1}, superMajority)
+
+	// A commit below the quorum is rejected
+	round := newTestCommitteeRound(test, ctrl, leader, leaderPriKey, 0, validatorPriKeys, 1)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.UpdatePublicKeys(pubKeys)
+	consensusValidator.processAnnounceMessage(round.announce)
+	consensusValidator.processPreparedMessage(round.prepared)
+	assert.Equal(test, ErrNoQuorum, consensusValidator.processCommittedMessage(round.committed))
+	assert.Equal(test, uint32(0), consensusValidator.GetViewID())
+}
+
+func TestProcessMessageValidatorPipelinedAnnounce(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+
+	m := mock_host.NewMockHost(ctrl)
+	m.EXPECT().GetSelfPeer().Return(leader)
+	consensusLeader, err := New(m, 0, leader, leaderPriKey)
+	if err != nil {
+		test.Fatalf("Cannot craeate consensus: %v", err)
+	}
+	consensusLeader.UpdatePublicKeys([]*bls.PublicKey{leader.ConsensusPubKey})
+	blockBytes, err := testBlockBytes()
+	if err != nil {
+		test.Fatalf("Cannot decode blockByte: %v", err)
+	}
+	consensusLeader.block = blockBytes
+	blockHashes := [2][32]byte{testBlockHash(test), testBlockHash(test)}
+	blockHashes[1][0] ^= 0xff
+
+	// The leader announces view 1 before view 0 commits.
+	announces := make([]*msg_pb.Message, 2)
+	for viewID := range announces {
+		consensusLeader.viewID = uint32(viewID)
+		consensusLeader.blockHash = blockHashes[viewID]
+		announces[viewID] = testConsensusMessage(test, consensusLeader.constructAnnounceMessage())
+	}
+	prepareds := make([]*msg_pb.Message, 2)
+	committeds := make([]*msg_pb.Message, 2)
+	for viewID := range prepareds {
+		consensusLeader.ResetState()
+		consensusLeader.viewID = uint32(viewID)
+		consensusLeader.blockHash = blockHashes[viewID]
+		consensusLeader.prepareSigs[consensusLeader.SelfAddress] = consensusLeader.priKey.SignHash(consensusLeader.blockHash[:])
+		preparedMsg, aggSig := consensusLeader.constructPreparedMessage()
+		multiSigAndBitmap := append(aggSig.Serialize(), consensusLeader.prepareBitmap.Bitmap...)
+		consensusLeader.commitSigs[consensusLeader.SelfAddress] = consensusLeader.priKey.SignHash(multiSigAndBitmap)
+		committedMsg, _ := consensusLeader.constructCommittedMessage()
+		prepareds[viewID] = testConsensusMessage(test, preparedMsg)
+		committeds[viewID] = testConsensusMessage(test, committedMsg)
+	}
+
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.MaxInFlightViews = 2
+	consensusValidator.CommittedEventChan = make(chan CommittedEvent, 2)
+
+	consensusValidator.processAnnounceMessage(announces[0])
+	consensusValidator.processAnnounceMessage(announces[1])
+	assert.Equal(test, blockHashes[0], consensusValidator.GetBlockHash())
+	// The commit of the pipelined view is not accepted before its own prepare.
+	consensusValidator.processCommittedMessage(committeds[1])
+	assert.Equal(test, uint32(0), consensusValidator.GetViewID())
+
+	consensusValidator.processPreparedMessage(prepareds[0])
+	consensusValidator.processCommittedMessage(committeds[0])
+	assert.Equal(test, uint32(1), consensusValidator.GetViewID())
+	assert.Equal(test, blockHashes[1], consensusValidator.GetBlockHash())
+	assert.Equal(test, PrepareDone, consensusValidator.state)
+
+	consensusValidator.processPreparedMessage(prepareds[1])
+	consensusValidator.processCommittedMessage(committeds[1])
+	assert.Equal(test, uint32(2), consensusValidator.GetViewID())
+	assert.Equal(test, CommittedEvent{ViewID: 1, NumBlocks: 1, NumCommitSigners: 1}, <-consensusValidator.CommittedEventChan)
+	assert.Equal(test, CommittedEvent{ViewID: 2, NumBlocks: 1, NumCommitSigners: 1}, <-consensusValidator.CommittedEventChan)
+}
+
+func TestLastAnnouncedTransactions(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+
+	blockBytes, err := testBlockBytes()
+	if err != nil {
+		test.Fatalf("Cannot decode blockByte: %v", err)
+	}
+	var block types.Block
+	if err := rlp.DecodeBytes(blockBytes, &block); err != nil {
+		test.Fatalf("Cannot decode block: %v", err)
+	}
+	txs := []*types.Transaction{
+		types.NewTransaction(0, common.HexToAddress("0x1"), 0, big.NewInt(1), 21000, big.NewInt(1), nil),
+		types.NewTransaction(1, common.HexToAddress("0x2"), 0, big.NewInt(2), 21000, big.NewInt(1), nil),
+	}
+	blockBytes, err = rlp.EncodeToBytes(types.NewBlock(block.Header(), txs, nil))
+	if err != nil {
+		test.Fatalf("Cannot encode block: %v", err)
+	}
+
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	assert.Nil(test, consensusValidator.LastAnnouncedTransactions())
+
+	consensusValidator.processAnnounceMessage(newTestAnnounce(test, ctrl, leader, leaderPriKey, 0, blockBytes, testBlockHash(test)))
+
+	announcedTxs := consensusValidator.LastAnnouncedTransactions()
+	if assert.Equal(test, len(txs), len(announcedTxs)) {
+		for i, tx := range txs {
+			assert.Equal(test, tx.Hash(), announcedTxs[i].Hash())
+		}
+	}
+}
+
+func TestProcessMessageValidatorBLSFailures(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+
+	corrupt := func(message *msg_pb.Message, corruptPayload func(*multiSigPayload)) *msg_pb.Message {
+		corrupted := protobuf.Clone(message).(*msg_pb.Message)
+		consensusMsg := corrupted.GetConsensus()
+		payload, err := decodeMultiSigPayload(consensusMsg.Payload)
+		if err != nil {
+			test.Fatalf("Cannot decode payload: %v", err)
+		}
+		corruptPayload(payload)
+		if consensusMsg.Payload, err = rlp.EncodeToBytes(payload); err != nil {
+			test.Fatalf("Cannot encode payload: %v", err)
+		}
+		resignTestMessage(test, corrupted, leaderPriKey)
+		return corrupted
+	}
+	badMultiSig := func(payload *multiSigPayload) {
+		for i := range payload.Signature {
+			payload.Signature[i] = 0xff
+		}
+	}
+	badBitmap := func(payload *multiSigPayload) {
+		payload.Bitmap = append(payload.Bitmap, 0)
+	}
+
+	for name, corruptPayload := range map[string]func(*multiSigPayload){"multiSig": badMultiSig, "bitmap": badBitmap} {
+		consensusValidator := newTestValidator(test, ctrl, leader)
+		consensusValidator.processAnnounceMessage(round.announce)
+		consensusValidator.processPreparedMessage(corrupt(round.prepared, corruptPayload))
+		assert.Equal(test, PrepareDone, consensusValidator.state, "prepared with bad %s", name)
+		assert.Nil(test, consensusValidator.aggregatedPrepareSig, "prepared with bad %s", name)
+
+		consensusValidator = newTestValidator(test, ctrl, leader)
+		consensusValidator.processAnnounceMessage(round.announce)
+		consensusValidator.processPreparedMessage(round.prepared)
+		consensusValidator.processCommittedMessage(corrupt(round.committed, corruptPayload))
+		assert.Equal(test, CommitDone, consensusValidator.state, "committed with bad %s", name)
+		assert.Nil(test, consensusValidator.aggregatedCommitSig, "committed with bad %s", name)
+		assert.Equal(test, uint32(0), consensusValidator.GetViewID(), "committed with bad %s", name)
+	}
+}
+
+func TestProcessMessageValidatorCommittedBeforePrepared(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+
+	consensusValidator.processAnnounceMessage(round.announce)
+	assert.Equal(test, ErrOutOfOrder, consensusValidator.processCommittedMessage(round.committed))
+	assert.Equal(test, PrepareDone, consensusValidator.state)
+	assert.Equal(test, uint32(0), consensusValidator.GetViewID())
+}
+
+func TestProcessMessageValidatorSendsToShardGroup(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+
+	m := mock_host.NewMockHost(ctrl)
+	m.EXPECT().GetSelfPeer().Return(leader)
+	consensusValidator, err := New(m, 2, leader, bls_cosi.RandPrivateKey())
+	if err != nil {
+		test.Fatalf("Cannot craeate consensus: %v", err)
+	}
+	consensusValidator.UpdatePublicKeys([]*bls.PublicKey{leader.ConsensusPubKey})
+	consensusValidator.ChainReader = MockChainReader{}
+	consensusValidator.NumBlockVerifiers = 0
+
+	var groups [][]p2p.GroupID
+	m.EXPECT().SendMessageToGroups(gomock.Any(), gomock.Any()).Do(func(groupIDs []p2p.GroupID, msg []byte) {
+		groups = append(groups, groupIDs)
+	}).Times(2)
+
+	consensusValidator.processAnnounceMessage(round.announce)
+	consensusValidator.processPreparedMessage(round.prepared)
+
+	shard2 := []p2p.GroupID{"harmony/0.0.1/node/shard/2"}
+	assert.Equal(test, [][]p2p.GroupID{shard2, shard2}, groups)
+}
+
+func TestProcessMessageValidatorDeadLetter(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.DeadLetterChan = make(chan DeadLetter, 1)
+
+	payload, err := protobuf.Marshal(&msg_pb.Message{
+		ServiceType: msg_pb.ServiceType_DRAND,
+		Type:        msg_pb.MessageType_DRAND_INIT,
+		Signature:   make([]byte, 2*deadLetterPreviewLen),
+	})
+	if err != nil {
+		test.Fatalf("Cannot marshal message: %v", err)
+	}
+	consensusValidator.ProcessMessageValidator(payload)
+	select {
+	case letter := <-consensusValidator.DeadLetterChan:
+		assert.Equal(test, msg_pb.MessageType_DRAND_INIT, letter.Type)
+		assert.NoError(test, letter.Err)
+		assert.Equal(test, payload[:deadLetterPreviewLen], letter.Payload)
+	default:
+		test.Fatal("no dead letter reported")
+	}
+
+	consensusValidator.ProcessMessageValidator([]byte{0xff, 0xff})
+	select {
+	case letter := <-consensusValidator.DeadLetterChan:
+		assert.Error(test, letter.Err)
+		assert.Equal(test, []byte{0xff, 0xff}, letter.Payload)
+	default:
+		test.Fatal("no dead letter reported for an unparseable payload")
+	}
+
+	// A full channel drops the letter instead of blocking.
+	consensusValidator.ProcessMessageValidator(payload)
+	consensusValidator.ProcessMessageValidator(payload)
+	assert.Equal(test, 1, len(consensusValidator.DeadLetterChan))
+}
+
+func TestProcessMessageValidatorRejections(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.RejectionChan = make(chan Rejection, 1)
+
+	// Signed by another key than the leader, and reported once dispatched
+	forged := protobuf.Clone(round.announce).(*msg_pb.Message)
+	resignTestMessage(test, forged, bls_cosi.RandPrivateKey())
+	payload, err := protobuf.Marshal(forged)
+	if err != nil {
+		test.Fatalf("Cannot marshal message: %v", err)
+	}
+	consensusValidator.ProcessMessageValidator(payload)
+	select {
+	case rejection := <-consensusValidator.RejectionChan:
+		assert.Equal(test, msg_pb.MessageType_ANNOUNCE, rejection.Type)
+		assert.Equal(test, uint32(0), rejection.ViewID)
+		assert.Equal(test, ErrBadSignature, rejection.Err)
+	default:
+		test.Fatal("no rejection reported")
+	}
+
+	// For a view already committed
+	consensusValidator.viewID = 1
+	assert.Equal(test, ErrStaleView, consensusValidator.processAnnounceMessage(round.announce))
+	consensusValidator.viewID = 0
+	assert.NoError(test, consensusValidator.processAnnounceMessage(round.announce))
+
+	// For another block
+	prepared := protobuf.Clone(round.prepared).(*msg_pb.Message)
+	prepared.GetConsensus().BlockHash = make([]byte, 32)
+	resignTestMessage(test, prepared, leaderPriKey)
+	assert.Equal(test, ErrBlockHashMismatch, consensusValidator.processPreparedMessage(prepared))
+
+	assert.NoError(test, consensusValidator.processPreparedMessage(round.prepared))
+	assert.NoError(test, consensusValidator.processCommittedMessage(round.committed))
+	assert.Empty(test, consensusValidator.RejectionChan)
+}
+
+// headChainReader is a MockChainReader whose current head is the block of the given number.
+type headChainReader struct {
+	MockChainReader
+	number uint64
+}
+
+func (reader headChainReader) CurrentHeader() *types.Header {
+	return &types.Header{Number: new(big.Int).SetUint64(reader.number)}
+}
+
+func TestInitFromChainHead(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	blockBytes, err := testBlockBytes()
+	if err != nil {
+		test.Fatalf("Cannot decode blockByte: %v", err)
+	}
+
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	assert.Error(test, consensusValidator.InitFromChainHead(MockChainReader{}))
+	assert.NoError(test, consensusValidator.InitFromChainHead(headChainReader{number: 100}))
+	assert.Equal(test, uint32(101), consensusValidator.GetViewID())
+
+	// The views up to the head are already committed.
+	for _, viewID := range []uint32{0, 99, 100} {
+		consensusValidator.processAnnounceMessage(newTestAnnounce(test, ctrl, leader, leaderPriKey, viewID, blockBytes, testBlockHash(test)))
+		assert.Equal(test, Finished, consensusValidator.state, "announce for view %d", viewID)
+		assert.Equal(test, uint32(101), consensusValidator.GetViewID())
+	}
+
+	consensusValidator.processAnnounceMessage(newTestAnnounce(test, ctrl, leader, leaderPriKey, 101, blockBytes, testBlockHash(test)))
+	assert.Equal(test, PrepareDone, consensusValidator.state)
+}
+
+func TestProcessMessageValidatorAsyncBlockVerification(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.NumBlockVerifiers = 1
+	verifying := make(chan struct{})
+	release := make(chan struct{})
+	consensusValidator.BlockVerifier = func(block *types.Block) error {
+		close(verifying)
+		<-release
+		return nil
+	}
+
+	consensusValidator.processAnnounceMessage(round.announce)
+	<-verifying
+	// The handler returned and the node keeps serving while the block is verified.
+	assert.Equal(test, Finished.String(), consensusValidator.RoundStatus().State)
+	assert.Equal(test, testBlockHash(test), consensusValidator.GetBlockHash())
+
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for consensusValidator.RoundStatus().State != PrepareDone.String() {
+		if time.Now().After(deadline) {
+			test.Fatal("prepare not sent after block verification")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	consensusValidator.processPreparedMessage(round.prepared)
+	assert.Equal(test, CommitDone.String(), consensusValidator.RoundStatus().State)
+}
+
+func TestProcessMessageValidatorPreparedDuringBlockVerification(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.NumBlockVerifiers = 1
+	verifying := make(chan struct{})
+	release := make(chan struct{})
+	consensusValidator.BlockVerifier = func(block *types.Block) error {
+		close(verifying)
+		<-release
+		return nil
+	}
+
+	consensusValidator.processAnnounceMessage(round.announce)
+	<-verifying
+	// The validator does not commit a block it has not verified yet.
+	assert.NoError(test, consensusValidator.processPreparedMessage(round.prepared))
+	assert.Equal(test, Finished.String(), consensusValidator.RoundStatus().State)
+
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for consensusValidator.RoundStatus().State != CommitDone.String() {
+		if time.Now().After(deadline) {
+			test.Fatal("commit not sent after block verification")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A prepared message for a round the validator did not announce is out of order.
+	consensusValidator.mutex.Lock()
+	consensusValidator.setState(Finished)
+	consensusValidator.blockHash = [32]byte{}
+	consensusValidator.mutex.Unlock()
+	assert.Equal(test, ErrOutOfOrder, consensusValidator.processPreparedMessage(round.prepared))
+}
+
+func TestProcessMessageValidatorLateBlockVerification(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.processAnnounceMessage(round.announce)
+	consensusValidator.processPreparedMessage(round.prepared)
+	assert.Equal(test, CommitDone, consensusValidator.state)
+
+	var block types.Block
+	if err := rlp.DecodeBytes(round.announce.GetConsensus().Payload, &block); err != nil {
+		test.Fatalf("Cannot decode block: %v", err)
+	}
+	// A verification result arriving after the round moved on does not send a prepare again.
+	consensusValidator.onBlockVerified(round.announce, &block, nil)
+	assert.Equal(test, CommitDone, consensusValidator.state)
+
+	consensusValidator.processCommittedMessage(round.committed)
+	consensusValidator.onBlockVerified(round.announce, &block, nil)
+	assert.Equal(test, CommittedDone, consensusValidator.state)
+}
+
+func TestProcessMessageValidatorDropsDuplicates(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	marshal := func(message *msg_pb.Message) []byte {
+		payload, err := protobuf.Marshal(message)
+		if err != nil {
+			test.Fatalf("Cannot marshal message: %v", err)
+		}
+		return payload
+	}
+	announce, prepared, committed := marshal(round.announce), marshal(round.prepared), marshal(round.committed)
+
+	consensusValidator.ProcessMessageValidator(announce)
+	assert.Equal(test, PrepareDone, consensusValidator.state)
+	assert.True(test, consensusValidator.isDuplicateMessage(round.announce, announce))
+
+	// The same message resent by another sender is not a duplicate.
+	resent := protobuf.Clone(round.announce).(*msg_pb.Message)
+	resent.GetConsensus().SenderPubkey = bls_cosi.RandPrivateKey().GetPublicKey().Serialize()
+	assert.False(test, consensusValidator.isDuplicateMessage(resent, marshal(resent)))
+
+	consensusValidator.ProcessMessageValidator(prepared)
+	consensusValidator.ProcessMessageValidator(prepared)
+	assert.Equal(test, CommitDone, consensusValidator.state)
+	consensusValidator.ProcessMessageValidator(committed)
+	consensusValidator.ProcessMessageValidator(committed)
+	assert.Equal(test, uint32(1), consensusValidator.GetViewID())
+
+	// The messages of the committed view are forgotten.
+	assert.Equal(test, 0, len(consensusValidator.seenMessages[0]))
+	assert.Equal(test, 0, consensusValidator.numSeenMessages)
+}
+
+func TestProcessMessageValidatorSignsForEveryKey(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	validatorPriKeys := []*bls.SecretKey{bls_cosi.RandPrivateKey(), bls_cosi.RandPrivateKey()}
+	round := newTestCommitteeRound(test, ctrl, leader, leaderPriKey, 0, validatorPriKeys, 0)
+
+	m := mock_host.NewMockHost(ctrl)
+	m.EXPECT().GetSelfPeer().Return(leader)
+	consensusValidator, err := New(m, 0, leader, validatorPriKeys[0])
+	if err != nil {
+		test.Fatalf("Cannot craeate consensus: %v", err)
+	}
+	consensusValidator.AddPriKey(validatorPriKeys[1])
+	consensusValidator.UpdatePublicKeys([]*bls.PublicKey{
+		leader.ConsensusPubKey, validatorPriKeys[0].GetPublicKey(), validatorPriKeys[1].GetPublicKey()})
+	consensusValidator.ChainReader = MockChainReader{}
+	consensusValidator.NumBlockVerifiers = 0
+
+	var sent []*msg_pb.Message
+	m.EXPECT().SendMessageToGroups(gomock.Any(), gomock.Any()).Do(func(groupIDs []p2p.GroupID, msg []byte) {
+		sent = append(sent, testConsensusMessage(test, msg[5:]))
+	}).AnyTimes()
+
+	consensusValidator.processAnnounceMessage(round.announce)
+	consensusValidator.processPreparedMessage(round.prepared)
+
+	if !assert.Len(test, sent, 4) {
+		return
+	}
+	for i, msgType := range []msg_pb.MessageType{msg_pb.MessageType_PREPARE, msg_pb.MessageType_PREPARE, msg_pb.MessageType_COMMIT, msg_pb.MessageType_COMMIT} {
+		assert.Equal(test, msgType, sent[i].Type)
+		assert.Equal(test, validatorPriKeys[i%2].GetPublicKey().Serialize(), sent[i].GetConsensus().SenderPubkey)
+		assert.NoError(test, verifyMessageSig(validatorPriKeys[i%2].GetPublicKey(), sent[i]))
+	}
+}
+
+func TestProcessMessageValidatorPhaseTimeouts(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.TimeoutConfig.Prepared = time.Nanosecond
+	consensusValidator.TimeoutConfig.Committed = time.Nanosecond
+	expired := func(timeoutType TimeoutType) bool {
+		time.Sleep(time.Millisecond)
+		return consensusValidator.consensusTimeout[timeoutType].CheckExpire()
+	}
+
+	consensusValidator.processAnnounceMessage(round.announce)
+	assert.True(test, expired(timeoutPrepared))
+	assert.False(test, expired(timeoutCommitted))
+
+	consensusValidator.processPreparedMessage(round.prepared)
+	assert.False(test, expired(timeoutPrepared))
+	assert.True(test, expired(timeoutCommitted))
+
+	consensusValidator.numPhaseTimeouts = 2
+	consensusValidator.processCommittedMessage(round.committed)
+	assert.False(test, expired(timeoutPrepared))
+	assert.False(test, expired(timeoutCommitted))
+	assert.Equal(test, 0, consensusValidator.numPhaseTimeouts)
+}
+
+func TestPhaseTimeoutBackoff(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leader.ConsensusPubKey = bls_cosi.RandPrivateKey().GetPublicKey()
+	consensus := newTestValidator(test, ctrl, leader)
+	consensus.TimeoutConfig = ConsensusTimeoutConfig{
+		Prepared:      time.Second,
+		Committed:     2 * time.Second,
+		BackoffFactor: 2,
+		MaxTimeout:    5 * time.Second,
+	}
+
+	for numTimeouts, expected := range []time.Duration{2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		consensus.numPhaseTimeouts = numTimeouts
+		consensus.startPhaseTimeout(timeoutCommitted)
+		assert.Equal(test, expected, consensus.consensusTimeout[timeoutCommitted].Duration(), "numTimeouts %d", numTimeouts)
+	}
+	consensus.numPhaseTimeouts = 1
+	consensus.startPhaseTimeout(timeoutPrepared)
+	assert.Equal(test, 2*time.Second, consensus.consensusTimeout[timeoutPrepared].Duration())
+}
+
+// testMetrics records the measurements reported by the consensus.
+type testMetrics struct {
+	rounds      int
+	phases      []string
+	numVerified int
+	dropped     []error
+}
+
+func (metrics *testMetrics) RoundCompleted() { metrics.rounds++ }
+func (metrics *testMetrics) PhaseCompleted(phase string, duration time.Duration) {
+	metrics.phases = append(metrics.phases, phase)
+}
+func (metrics *testMetrics) SignatureVerified(duration time.Duration) { metrics.numVerified++ }
+func (metrics *testMetrics) MessageDropped(err error)                 { metrics.dropped = append(metrics.dropped, err) }
+func (metrics *testMetrics) ViewChangeStarted(viewID uint32)          {}
+
+func TestProcessMessageValidatorMetrics(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	metrics := &testMetrics{}
+	consensusValidator.Metrics = metrics
+
+	consensusValidator.processAnnounceMessage(round.announce)
+	consensusValidator.processPreparedMessage(round.prepared)
+	consensusValidator.processCommittedMessage(round.committed)
+
+	assert.Equal(test, 1, metrics.rounds)
+	assert.Equal(test, []string{"PrepareDone", "CommitDone", "CommittedDone"}, metrics.phases)
+	assert.Equal(test, 3, metrics.numVerified)
+	assert.Empty(test, metrics.dropped)
+
+	// A replayed message is dropped
+	consensusValidator.processPreparedMessage(round.prepared)
+	assert.Len(test, metrics.dropped, 1)
+}
@@ -0,0 +1,202 @@
+package consensus
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+
+	protobuf "github.com/golang/protobuf/proto"
+	"github.com/harmony-one/bls/ffi/go/bls"
+
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/crypto/hash"
+	"github.com/harmony-one/harmony/internal/utils"
+)
+
+// EquivocationEvidence is the proof that a node signed two different blocks
+// for the same view. Both signed messages are kept as received so that a
+// slashing module can check the evidence on its own with Verify.
+type EquivocationEvidence struct {
+	ViewID        uint32
+	Signer        *bls.PublicKey
+	FirstMessage  *msg_pb.Message
+	SecondMessage *msg_pb.Message
+}
+
+// Verify returns nil if the evidence proves an equivocation: both messages are
+// of the same type and view, for different blocks, and signed by Signer.
+func (evidence *EquivocationEvidence) Verify() error {
+	if evidence.Signer == nil || evidence.FirstMessage == nil || evidence.SecondMessage == nil {
+		return errors.New("incomplete equivocation evidence")
+	}
+	first := evidence.FirstMessage.GetConsensus()
+	second := evidence.SecondMessage.GetConsensus()
+	if first == nil || second == nil {
+		return errors.New("not consensus messages")
+	}
+	if evidence.FirstMessage.Type != evidence.SecondMessage.Type {
+		return errors.New("message types differ")
+	}
+	if first.ViewId != evidence.ViewID || second.ViewId != evidence.ViewID {
+		return errors.New("viewIDs differ")
+	}
+	if bytes.Equal(first.BlockHash, second.BlockHash) {
+		return errors.New("same block hash")
+	}
+	signer := evidence.Signer.Serialize()
+	if !bytes.Equal(first.SenderPubkey, signer) || !bytes.Equal(second.SenderPubkey, signer) {
+		return errors.New("sender is not the signer")
+	}
+	// verifyMessageSig modifies the message, so check copies
+	for _, message := range []*msg_pb.Message{evidence.FirstMessage, evidence.SecondMessage} {
+		if err := verifyMessageSig(evidence.Signer, protobuf.Clone(message).(*msg_pb.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reportEquivocation reports the evidence of the leader announcing the block
+// of second for a view it already announced another block in first.
+// second must not have been verified yet; first must have been.
+func (consensus *Consensus) reportEquivocation(first, second *msg_pb.Message) {
+	signer, err := bls_cosi.BytesToBlsPublicKey(second.GetConsensus().SenderPubkey)
+	if err != nil {
+		utils.GetLogInstance().Debug("Failed to deserialize BLS public key", "error", err)
+		return
+	}
+	evidence := EquivocationEvidence{
+		ViewID:        second.GetConsensus().ViewId,
+		Signer:        signer,
+		FirstMessage:  first,
+		SecondMessage: second,
+	}
+	if err := evidence.Verify(); err != nil {
+		utils.GetLogInstance().Debug("Conflicting message is not an equivocation", "viewID", evidence.ViewID, "error", err)
+		return
+	}
+	utils.GetLogInstance().Warn("Equivocation detected", "viewID", evidence.ViewID, "signer Address", blsPubKeyToAddress(signer))
+	if consensus.EquivocationChan == nil {
+		return
+	}
+	select {
+	case consensus.EquivocationChan <- evidence:
+	default:
+		utils.GetLogInstance().Info("equivocation evidence send to chan failed", "viewID", evidence.ViewID)
+	}
+}
+
+// Hash returns the hash of the two conflicting messages, which the reporter of the evidence signs.
+func (evidence *EquivocationEvidence) Hash() ([]byte, error) {
+	first, err := protobuf.Marshal(evidence.FirstMessage)
+	if err != nil {
+		return nil, err
+	}
+	second, err := protobuf.Marshal(evidence.SecondMessage)
+	if err != nil {
+		return nil, err
+	}
+	return hash.Keccak256(first, second), nil
+}
+
+// SignedEvidence is the evidence of a validator signing two different blocks in
+// the prepare or commit phase of a view, signed by the node which detected it
+// so that the node layer can broadcast or persist it.
+type SignedEvidence struct {
+	EquivocationEvidence
+	Reporter  *bls.PublicKey
+	Signature *bls.Sign
+}
+
+// Verify returns nil if the evidence proves an equivocation and is signed by Reporter.
+func (evidence *SignedEvidence) Verify() error {
+	if err := evidence.EquivocationEvidence.Verify(); err != nil {
+		return err
+	}
+	if evidence.Reporter == nil || evidence.Signature == nil {
+		return errors.New("unsigned evidence")
+	}
+	evidenceHash, err := evidence.Hash()
+	if err != nil {
+		return err
+	}
+	if !evidence.Signature.VerifyHash(evidence.Reporter, evidenceHash) {
+		return errors.New("failed to verify the reporter signature")
+	}
+	return nil
+}
+
+// signedMessageKey identifies the prepare or commit message a validator signed for a view.
+type signedMessageKey struct {
+	msgType msg_pb.MessageType
+	viewID  uint32
+	signer  string
+}
+
+// recordSignedMessage remembers the prepare and commit messages signed by each
+// validator and reports the evidence if a validator signs a second message of
+// the same type and view for another block. The message signature must have
+// been verified. The caller must hold consensus.mutex.
+func (consensus *Consensus) recordSignedMessage(message *msg_pb.Message, signer *bls.PublicKey) {
+	if message.Type != msg_pb.MessageType_PREPARE && message.Type != msg_pb.MessageType_COMMIT {
+		return
+	}
+	consensusMsg := message.GetConsensus()
+	key := signedMessageKey{
+		msgType: message.Type,
+		viewID:  consensusMsg.ViewId,
+		signer:  hex.EncodeToString(signer.Serialize()),
+	}
+	if first, ok := consensus.signedMessages[key]; ok {
+		if !bytes.Equal(first.GetConsensus().BlockHash, consensusMsg.BlockHash) {
+			consensus.reportSignedEvidence(first, message, signer)
+		}
+		return
+	}
+	if len(consensus.signedMessages) >= maxSignedMessages {
+		for oldKey := range consensus.signedMessages {
+			if oldKey.viewID < consensus.viewID {
+				delete(consensus.signedMessages, oldKey)
+			}
+		}
+		if len(consensus.signedMessages) >= maxSignedMessages {
+			utils.GetLogInstance().Debug("Too many signed messages to record", "viewID", consensusMsg.ViewId)
+			return
+		}
+	}
+	consensus.signedMessages[key] = message
+}
+
+// reportSignedEvidence signs and reports the evidence of signer signing both
+// first and second, which are of the same type and view but for different blocks.
+func (consensus *Consensus) reportSignedEvidence(first, second *msg_pb.Message, signer *bls.PublicKey) {
+	evidence := SignedEvidence{
+		EquivocationEvidence: EquivocationEvidence{
+			ViewID:        second.GetConsensus().ViewId,
+			Signer:        signer,
+			FirstMessage:  first,
+			SecondMessage: second,
+		},
+		Reporter: consensus.PubKey,
+	}
+	if err := evidence.EquivocationEvidence.Verify(); err != nil {
+		utils.GetLogInstance().Debug("Conflicting message is not an equivocation", "viewID", evidence.ViewID, "error", err)
+		return
+	}
+	utils.GetLogInstance().Warn("Double sign detected", "msgType", second.Type, "viewID", evidence.ViewID, "signer Address", blsPubKeyToAddress(signer))
+	if consensus.SlashingEvidenceChan == nil {
+		return
+	}
+	evidenceHash, err := evidence.Hash()
+	if err != nil {
+		utils.GetLogInstance().Debug("Failed to hash the evidence", "error", err)
+		return
+	}
+	evidence.Signature = consensus.priKey.SignHash(evidenceHash)
+	select {
+	case consensus.SlashingEvidenceChan <- evidence:
+	default:
+		utils.GetLogInstance().Info("slashing evidence send to chan failed", "viewID", evidence.ViewID)
+	}
+}
@@ -0,0 +1,129 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	protobuf "github.com/golang/protobuf/proto"
+	"github.com/harmony-one/bls/ffi/go/bls"
+	"github.com/stretchr/testify/assert"
+
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/p2p"
+	mock_host "github.com/harmony-one/harmony/p2p/host/mock"
+)
+
+func newTestAnnounce(test *testing.T, ctrl *gomock.Controller, leader p2p.Peer, leaderPriKey *bls.SecretKey, viewID uint32, blockBytes []byte, blockHash [32]byte) *msg_pb.Message {
+	m := mock_host.NewMockHost(ctrl)
+	m.EXPECT().GetSelfPeer().Return(leader)
+	consensusLeader, err := New(m, 0, leader, leaderPriKey)
+	if err != nil {
+		test.Fatalf("Cannot craeate consensus: %v", err)
+	}
+	consensusLeader.viewID = viewID
+	consensusLeader.block = blockBytes
+	consensusLeader.blockHash = blockHash
+	return testConsensusMessage(test, consensusLeader.constructAnnounceMessage())
+}
+
+func TestProcessAnnounceMessageEquivocation(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.EquivocationChan = make(chan EquivocationEvidence, 1)
+
+	consensusValidator.processAnnounceMessage(round.announce)
+	blockHash := testBlockHash(test)
+	assert.Equal(test, blockHash, consensusValidator.GetBlockHash())
+
+	otherBlockHash := blockHash
+	otherBlockHash[0] ^= 0xff
+	blockBytes, _ := testBlockBytes()
+	consensusValidator.processAnnounceMessage(newTestAnnounce(test, ctrl, leader, leaderPriKey, 0, blockBytes, otherBlockHash))
+
+	var evidence EquivocationEvidence
+	select {
+	case evidence = <-consensusValidator.EquivocationChan:
+	default:
+		test.Fatal("no equivocation evidence reported")
+	}
+	assert.NoError(test, evidence.Verify())
+	assert.Equal(test, uint32(0), evidence.ViewID)
+	assert.True(test, evidence.Signer.IsEqual(leader.ConsensusPubKey))
+	assert.Equal(test, blockHash[:], evidence.FirstMessage.GetConsensus().BlockHash)
+	assert.Equal(test, otherBlockHash[:], evidence.SecondMessage.GetConsensus().BlockHash)
+	// The conflicting announce is not taken into account
+	assert.Equal(test, blockHash, consensusValidator.GetBlockHash())
+
+	// Evidence with a tampered message does not verify
+	tampered := evidence
+	tampered.SecondMessage = protobuf.Clone(evidence.SecondMessage).(*msg_pb.Message)
+	tampered.SecondMessage.GetConsensus().BlockHash = otherBlockHash[1:]
+	assert.Error(test, tampered.Verify())
+	tampered.SecondMessage = evidence.FirstMessage
+	assert.Error(test, tampered.Verify())
+}
+
+func newTestPrepare(test *testing.T, ctrl *gomock.Controller, leader p2p.Peer, priKey *bls.SecretKey, blockHash [32]byte) *msg_pb.Message {
+	m := mock_host.NewMockHost(ctrl)
+	m.EXPECT().GetSelfPeer().Return(leader)
+	consensusValidator, err := New(m, 0, leader, priKey)
+	if err != nil {
+		test.Fatalf("Cannot craeate consensus: %v", err)
+	}
+	consensusValidator.blockHash = blockHash
+	return testConsensusMessage(test, consensusValidator.constructPrepareMessage())
+}
+
+func TestProcessPrepareMessageDoubleSign(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	validatorPriKey := bls_cosi.RandPrivateKey()
+
+	m := mock_host.NewMockHost(ctrl)
+	m.EXPECT().GetSelfPeer().Return(leader)
+	consensusLeader, err := New(m, 0, leader, leaderPriKey)
+	if err != nil {
+		test.Fatalf("Cannot craeate consensus: %v", err)
+	}
+	consensusLeader.UpdatePublicKeys([]*bls.PublicKey{leader.ConsensusPubKey, validatorPriKey.GetPublicKey(), bls_cosi.RandPrivateKey().GetPublicKey()})
+	consensusLeader.ignoreViewIDCheck = false
+	consensusLeader.blockHash = testBlockHash(test)
+	consensusLeader.SlashingEvidenceChan = make(chan SignedEvidence, 1)
+
+	consensusLeader.processPrepareMessage(newTestPrepare(test, ctrl, leader, validatorPriKey, consensusLeader.blockHash))
+	assert.Len(test, consensusLeader.pendingPrepares, 1)
+
+	otherBlockHash := consensusLeader.blockHash
+	otherBlockHash[0] ^= 0xff
+	consensusLeader.processPrepareMessage(newTestPrepare(test, ctrl, leader, validatorPriKey, otherBlockHash))
+
+	var evidence SignedEvidence
+	select {
+	case evidence = <-consensusLeader.SlashingEvidenceChan:
+	default:
+		test.Fatal("no slashing evidence reported")
+	}
+	assert.NoError(test, evidence.Verify())
+	assert.Equal(test, msg_pb.MessageType_PREPARE, evidence.FirstMessage.Type)
+	assert.True(test, evidence.Signer.IsEqual(validatorPriKey.GetPublicKey()))
+	assert.True(test, evidence.Reporter.IsEqual(leader.ConsensusPubKey))
+	// The conflicting prepare is not taken into account
+	assert.Len(test, consensusLeader.pendingPrepares, 1)
+
+	// Evidence signed by someone else than the reporter does not verify
+	forged := evidence
+	forged.Reporter = validatorPriKey.GetPublicKey()
+	assert.Error(test, forged.Verify())
+}
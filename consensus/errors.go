@@ -0,0 +1,37 @@
+package consensus
+
+import "errors"
+
+// The reasons the validator rejects a message from the leader.
+var (
+	// ErrMalformedMessage is returned when a message, its payload or its block cannot be decoded.
+	ErrMalformedMessage = errors.New("malformed consensus message")
+
+	// ErrUnknownLeader is returned when a message meant to come from the leader is sent by another key.
+	ErrUnknownLeader = errors.New("message not sent by the leader")
+
+	// ErrBadSignature is returned when the signature of a message, or the multi-signature it carries,
+	// does not verify.
+	ErrBadSignature = errors.New("bad signature")
+
+	// ErrNoQuorum is returned when a multi-signature is not signed by a quorum of the committee.
+	ErrNoQuorum = errors.New("not signed by a quorum")
+
+	// ErrStaleView is returned when a message is not for the current view.
+	ErrStaleView = errors.New("message not for the current view")
+
+	// ErrBlockHashMismatch is returned when a message is not for the block of the current round.
+	ErrBlockHashMismatch = errors.New("message not for the current block")
+
+	// ErrOutOfOrder is returned when a message arrives before the phase it depends on.
+	ErrOutOfOrder = errors.New("message out of phase order")
+
+	// ErrEquivocation is returned when the leader announces two blocks for one view.
+	ErrEquivocation = errors.New("leader equivocation")
+
+	// ErrInvalidBlock is returned when an announced or committed block fails verification.
+	ErrInvalidBlock = errors.New("invalid block")
+
+	// ErrAttacked is returned when the attack model drops the message on purpose.
+	ErrAttacked = errors.New("dropped by the attack model")
+)
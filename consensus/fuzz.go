@@ -0,0 +1,18 @@
+// +build gofuzz
+
+package consensus
+
+import "github.com/harmony-one/bls/ffi/go/bls"
+
+// fuzzConsensus is the consensus of a committee of four validating the fuzzed messages.
+var fuzzConsensus = &Consensus{PublicKeys: make([]*bls.PublicKey, 4)}
+
+// Fuzz is the entry point for the go-fuzz tool
+//
+// This returns 1 for the messages passing ValidateMessage, 0 otherwise.
+func Fuzz(input []byte) int {
+	if _, err := fuzzConsensus.ValidateMessage(input); err != nil {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,96 @@
+package consensus
+
+import (
+	"bytes"
+
+	protobuf "github.com/golang/protobuf/proto"
+	"github.com/harmony-one/bls/ffi/go/bls"
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/harmony-one/harmony/p2p/host"
+)
+
+// leaderPeer is the p2p peer a leader key was last seen sending its messages from.
+type leaderPeer struct {
+	pubKey []byte
+	id     libp2p_peer.ID
+}
+
+// LearnLeaderPeer records sender as the p2p peer of the leader if payload is a message only
+// the leader sends, signed by the key of the current leader, so that the votes can be sent
+// to the leader directly. sender must be the authenticated author of the message.
+func (consensus *Consensus) LearnLeaderPeer(payload []byte, sender libp2p_peer.ID) {
+	message, err := consensus.ValidateMessage(payload)
+	if err != nil || sender == "" {
+		return
+	}
+	switch message.Type {
+	case msg_pb.MessageType_ANNOUNCE, msg_pb.MessageType_PREPARED, msg_pb.MessageType_COMMITTED:
+	default:
+		return
+	}
+	senderKey := message.GetConsensus().SenderPubkey
+	consensus.leaderPeerMutex.Lock()
+	known := consensus.leaderPeer.id == sender && bytes.Equal(consensus.leaderPeer.pubKey, senderKey)
+	consensus.leaderPeerMutex.Unlock()
+	if known {
+		return
+	}
+
+	var leaderKey *bls.PublicKey
+	for _, key := range []*bls.PublicKey{consensus.LeaderPubKey, consensus.GetLeaderPubKey()} {
+		if key != nil && bytes.Equal(key.Serialize(), senderKey) {
+			leaderKey = key
+			break
+		}
+	}
+	if leaderKey == nil || verifyMessageSig(leaderKey, message) != nil {
+		return
+	}
+	consensus.leaderPeerMutex.Lock()
+	consensus.leaderPeer = leaderPeer{pubKey: senderKey, id: sender}
+	consensus.leaderPeerMutex.Unlock()
+	utils.GetLogInstance().Debug("Learned the peer of the leader", "leader", blsPubKeyToAddress(leaderKey), "peer", sender.Pretty())
+}
+
+// leaderPeerID returns the p2p peer of the current leader, if known.
+// The caller must hold consensus.mutex.
+func (consensus *Consensus) leaderPeerID() (libp2p_peer.ID, bool) {
+	leaderKey := consensus.LeaderPubKey
+	if consensus.ConsensusVersion != "v2" || leaderKey == nil {
+		leaderKey = consensus.leader.ConsensusPubKey
+	}
+	if leaderKey == nil {
+		return "", false
+	}
+	consensus.leaderPeerMutex.Lock()
+	defer consensus.leaderPeerMutex.Unlock()
+	if consensus.leaderPeer.id == "" || !bytes.Equal(consensus.leaderPeer.pubKey, leaderKey.Serialize()) {
+		return "", false
+	}
+	return consensus.leaderPeer.id, true
+}
+
+// sendToLeader sends a vote to the leader over a direct stream, instead of flooding the
+// shard with it, and records it in the transcript. The vote is broadcast to the shard if
+// the peer of the leader is unknown or cannot be reached. The caller must hold consensus.mutex.
+func (consensus *Consensus) sendToLeader(msgToSend []byte) {
+	id, ok := consensus.leaderPeerID()
+	if !ok || id == consensus.host.GetID() {
+		consensus.sendMessage(msgToSend)
+		return
+	}
+	if err := consensus.host.SendMessageToPeer(id, host.ConstructP2pMessage(msgToSend)); err != nil {
+		utils.GetLogInstance().Debug("Cannot send vote to the leader, broadcasting it", "peer", id.Pretty(), "error", err)
+		consensus.sendMessage(msgToSend)
+		return
+	}
+	if consensus.Transcript != nil {
+		message := &msg_pb.Message{}
+		if err := protobuf.Unmarshal(msgToSend, message); err == nil {
+			consensus.recordMessage(TranscriptSent, message, msgToSend)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package consensus
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/harmony-one/bls/ffi/go/bls"
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+	"github.com/stretchr/testify/assert"
+
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/p2p"
+	mock_host "github.com/harmony-one/harmony/p2p/host/mock"
+)
+
+func TestSendToLeader(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	rounds := newTestRounds(test, ctrl, leader, leaderPriKey, 1)
+	impostor := p2p.Peer{IP: "127.0.0.1", Port: "7783"}
+	impostorPriKey := bls_cosi.RandPrivateKey()
+	impostor.ConsensusPubKey = impostorPriKey.GetPublicKey()
+	impostorRounds := newTestRounds(test, ctrl, impostor, impostorPriKey, 1)
+
+	m := mock_host.NewMockHost(ctrl)
+	m.EXPECT().GetSelfPeer().Return(leader)
+	consensusValidator, err := New(m, 0, leader, bls_cosi.RandPrivateKey())
+	if err != nil {
+		test.Fatalf("Cannot craeate consensus: %v", err)
+	}
+	consensusValidator.UpdatePublicKeys([]*bls.PublicKey{leader.ConsensusPubKey})
+	vote := []byte("vote")
+	// The votes are sent and the peer of the leader looked up with the consensus mutex held.
+	sendToLeader := func() {
+		consensusValidator.mutex.Lock()
+		defer consensusValidator.mutex.Unlock()
+		consensusValidator.sendToLeader(vote)
+	}
+	leaderPeerID := func() (libp2p_peer.ID, bool) {
+		consensusValidator.mutex.Lock()
+		defer consensusValidator.mutex.Unlock()
+		return consensusValidator.leaderPeerID()
+	}
+
+	// The votes are broadcast until the peer of the leader is known.
+	m.EXPECT().SendMessageToGroups(gomock.Any(), gomock.Any())
+	sendToLeader()
+
+	// Only the messages signed by the leader tell its peer.
+	consensusValidator.LearnLeaderPeer(impostorRounds[0][0], libp2p_peer.ID("impostor"))
+	_, ok := leaderPeerID()
+	assert.False(test, ok)
+	consensusValidator.LearnLeaderPeer(rounds[0][0], libp2p_peer.ID("leader"))
+	id, ok := leaderPeerID()
+	assert.True(test, ok)
+	assert.Equal(test, libp2p_peer.ID("leader"), id)
+
+	m.EXPECT().GetID().Return(libp2p_peer.ID("validator")).Times(2)
+	m.EXPECT().SendMessageToPeer(libp2p_peer.ID("leader"), gomock.Any()).Return(nil)
+	sendToLeader()
+
+	// A vote which cannot reach the leader is broadcast.
+	m.EXPECT().SendMessageToPeer(libp2p_peer.ID("leader"), gomock.Any()).Return(errors.New("unreachable"))
+	m.EXPECT().SendMessageToGroups(gomock.Any(), gomock.Any())
+	sendToLeader()
+}
+
+func TestProcessMessageValidatorV1AnnounceSendsPrepare(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	rounds := newTestRounds(test, ctrl, leader, leaderPriKey, 1)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.ConsensusVersion = "v1"
+
+	// The prepare is sent to the leader while the announce is handled.
+	done := make(chan struct{})
+	go func() {
+		consensusValidator.ProcessMessageValidator(rounds[0][0])
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		test.Fatal("validator stuck on the announce")
+	}
+	assert.Equal(test, PrepareDone, consensusValidator.state)
+}
@@ -0,0 +1,47 @@
+package consensus
+
+import (
+	"github.com/harmony-one/bls/ffi/go/bls"
+
+	"github.com/harmony-one/harmony/internal/utils"
+)
+
+// LeaderRotationPolicy picks the next leader of a committee. It must be
+// deterministic so that all the validators agree on the leader without talking.
+type LeaderRotationPolicy interface {
+	// NextLeader returns the leader taking over from current, after the block
+	// of blockNum is committed or on a view change during it.
+	NextLeader(publicKeys []*bls.PublicKey, current *bls.PublicKey, blockNum uint64) *bls.PublicKey
+}
+
+// RoundRobinRotation hands the leadership over to the next key in the committee.
+type RoundRobinRotation struct{}
+
+// NextLeader returns the key following current in publicKeys, or the first one if
+// current is not in the committee.
+func (RoundRobinRotation) NextLeader(publicKeys []*bls.PublicKey, current *bls.PublicKey, blockNum uint64) *bls.PublicKey {
+	idx := -1
+	for k, v := range publicKeys {
+		if v.IsEqual(current) {
+			idx = k
+			break
+		}
+	}
+	if idx == -1 {
+		utils.GetLogInstance().Warn("NextLeader: current leader key not found", "key", current.GetHexString())
+	}
+	return publicKeys[(idx+1)%len(publicKeys)]
+}
+
+// rotateLeader hands the leadership over to the next leader once every
+// LeaderRotationInterval blocks, after the block of blockNum is committed.
+// It returns whether this node took over. The caller must hold consensus.mutex.
+func (consensus *Consensus) rotateLeader(blockNum uint64) bool {
+	if consensus.LeaderRotationInterval == 0 || blockNum%consensus.LeaderRotationInterval != 0 {
+		return false
+	}
+	wasLeader := consensus.PubKey.IsEqual(consensus.LeaderPubKey)
+	consensus.LeaderPubKey = consensus.GetNextLeaderKey()
+	utils.GetLogInstance().Info("Leader rotated", "blockNum", blockNum, "nextLeader", consensus.LeaderPubKey.GetHexString())
+	return !wasLeader && consensus.PubKey.IsEqual(consensus.LeaderPubKey)
+}
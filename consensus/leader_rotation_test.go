@@ -0,0 +1,55 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/harmony-one/bls/ffi/go/bls"
+	"github.com/stretchr/testify/assert"
+
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/p2p"
+	mock_host "github.com/harmony-one/harmony/p2p/host/mock"
+)
+
+func TestRoundRobinRotation(test *testing.T) {
+	publicKeys := []*bls.PublicKey{}
+	for i := 0; i < 3; i++ {
+		publicKeys = append(publicKeys, bls_cosi.RandPrivateKey().GetPublicKey())
+	}
+	rotation := RoundRobinRotation{}
+	assert.True(test, rotation.NextLeader(publicKeys, publicKeys[0], 1).IsEqual(publicKeys[1]))
+	assert.True(test, rotation.NextLeader(publicKeys, publicKeys[2], 1).IsEqual(publicKeys[0]))
+	outsider := bls_cosi.RandPrivateKey().GetPublicKey()
+	assert.True(test, rotation.NextLeader(publicKeys, outsider, 1).IsEqual(publicKeys[0]))
+}
+
+func TestRotateLeader(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	validatorPriKey := bls_cosi.RandPrivateKey()
+
+	m := mock_host.NewMockHost(ctrl)
+	m.EXPECT().GetSelfPeer().Return(leader)
+	consensus, err := New(m, 0, leader, validatorPriKey)
+	if err != nil {
+		test.Fatalf("Cannot craeate consensus: %v", err)
+	}
+	consensus.UpdatePublicKeys([]*bls.PublicKey{leader.ConsensusPubKey, validatorPriKey.GetPublicKey()})
+
+	// No rotation by default
+	assert.False(test, consensus.rotateLeader(1))
+	assert.True(test, consensus.LeaderPubKey.IsEqual(leader.ConsensusPubKey))
+
+	consensus.LeaderRotationInterval = 2
+	assert.False(test, consensus.rotateLeader(1))
+	assert.True(test, consensus.LeaderPubKey.IsEqual(leader.ConsensusPubKey))
+	assert.True(test, consensus.rotateLeader(2))
+	assert.True(test, consensus.LeaderPubKey.IsEqual(validatorPriKey.GetPublicKey()))
+	assert.False(test, consensus.rotateLeader(4))
+	assert.True(test, consensus.LeaderPubKey.IsEqual(leader.ConsensusPubKey))
+}
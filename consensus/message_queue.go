@@ -0,0 +1,135 @@
+package consensus
+
+import (
+	"bytes"
+	"container/heap"
+
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	"github.com/harmony-one/harmony/internal/utils"
+)
+
+// queuedMessage is a message from the leader held until the validator reaches its view.
+type queuedMessage struct {
+	message *msg_pb.Message
+	payload []byte
+	seq     uint64 // arrival order, breaking the ties
+}
+
+// phaseRank orders the messages of a view the way the validator handles them.
+func phaseRank(msgType msg_pb.MessageType) int {
+	switch msgType {
+	case msg_pb.MessageType_ANNOUNCE:
+		return 0
+	case msg_pb.MessageType_PREPARED:
+		return 1
+	case msg_pb.MessageType_COMMITTED:
+		return 2
+	}
+	return 3
+}
+
+// messageQueue is a min-heap of queued messages ordered by (viewID, phase, arrival).
+type messageQueue []*queuedMessage
+
+func (queue messageQueue) Len() int { return len(queue) }
+
+func (queue messageQueue) Less(i, j int) bool {
+	viewI, viewJ := queue[i].message.GetConsensus().ViewId, queue[j].message.GetConsensus().ViewId
+	if viewI != viewJ {
+		return viewI < viewJ
+	}
+	rankI, rankJ := phaseRank(queue[i].message.Type), phaseRank(queue[j].message.Type)
+	if rankI != rankJ {
+		return rankI < rankJ
+	}
+	return queue[i].seq < queue[j].seq
+}
+
+func (queue messageQueue) Swap(i, j int) { queue[i], queue[j] = queue[j], queue[i] }
+
+func (queue *messageQueue) Push(x interface{}) { *queue = append(*queue, x.(*queuedMessage)) }
+
+func (queue *messageQueue) Pop() interface{} {
+	old := *queue
+	last := old[len(old)-1]
+	old[len(old)-1] = nil
+	*queue = old[:len(old)-1]
+	return last
+}
+
+// isPendingView returns whether the message is a phase message for one of the next
+// maxPendingViews views, too early to be handled. The announces of the pipelined views
+// are handled right away. The caller must hold consensus.mutex.
+func (consensus *Consensus) isPendingView(message *msg_pb.Message) bool {
+	consensusMsg := message.GetConsensus()
+	if consensusMsg == nil || consensus.ignoreViewIDCheck || phaseRank(message.Type) > 2 {
+		return false
+	}
+	viewID := consensusMsg.ViewId
+	if viewID <= consensus.viewID || viewID-consensus.viewID > maxPendingViews {
+		return false
+	}
+	return message.Type != msg_pb.MessageType_ANNOUNCE || !consensus.isPipelinedView(viewID)
+}
+
+// queueMessage holds a message for one of the next views in pendingMessages, or
+// dispatches it right away. Only the messages signed by the current leader are held.
+// Once pendingMessages is full, the messages are dispatched, i.e. rejected as not for
+// the current view.
+func (consensus *Consensus) queueMessage(message *msg_pb.Message, payload []byte) {
+	consensus.mutex.Lock()
+	pending := consensus.isPendingView(message)
+	leaderKey := consensus.leader.ConsensusPubKey
+	consensus.mutex.Unlock()
+
+	if pending {
+		if !bytes.Equal(message.GetConsensus().SenderPubkey, leaderKey.Serialize()) {
+			utils.GetLogInstance().Debug("Message for a later view not sent by the leader", "msgType", message.Type, "viewID", message.GetConsensus().ViewId)
+			consensus.mutex.Lock()
+			consensus.reportRejection(message, ErrUnknownLeader)
+			consensus.mutex.Unlock()
+			return
+		}
+		if err := consensus.verifyConsensusMessageSig(message, leaderKey); err != nil {
+			consensus.mutex.Lock()
+			consensus.reportRejection(message, err)
+			consensus.mutex.Unlock()
+			return
+		}
+		consensus.pendingMutex.Lock()
+		if len(consensus.pendingMessages) < maxPendingMessages {
+			consensus.pendingSeq++
+			heap.Push(&consensus.pendingMessages, &queuedMessage{message, payload, consensus.pendingSeq})
+			consensus.pendingMutex.Unlock()
+			utils.GetLogInstance().Debug("Deferred message for a later view", "msgType", message.Type, "viewID", message.GetConsensus().ViewId)
+			return
+		}
+		consensus.pendingMutex.Unlock()
+		utils.GetLogInstance().Warn("Too many messages for later views", "msgType", message.Type, "viewID", message.GetConsensus().ViewId)
+	}
+	consensus.dispatchMessageValidator(message, payload)
+}
+
+// dispatchPendingMessages dispatches the pending messages of the views the validator
+// reached, in (viewID, phase) order.
+func (consensus *Consensus) dispatchPendingMessages() {
+	for {
+		consensus.pendingMutex.Lock()
+		if len(consensus.pendingMessages) == 0 {
+			consensus.pendingMutex.Unlock()
+			return
+		}
+		next := consensus.pendingMessages[0]
+		consensus.mutex.Lock()
+		pending := consensus.isPendingView(next.message)
+		consensus.mutex.Unlock()
+		if pending {
+			consensus.pendingMutex.Unlock()
+			return
+		}
+		heap.Pop(&consensus.pendingMessages)
+		consensus.pendingMutex.Unlock()
+
+		consensus.dispatchMessageValidator(next.message, next.payload)
+	}
+}
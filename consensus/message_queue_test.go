@@ -0,0 +1,151 @@
+package consensus
+
+import (
+	"container/heap"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	protobuf "github.com/golang/protobuf/proto"
+	"github.com/harmony-one/bls/ffi/go/bls"
+	"github.com/stretchr/testify/assert"
+
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/p2p"
+	mock_host "github.com/harmony-one/harmony/p2p/host/mock"
+)
+
+// newTestRounds has a single-member committee leader construct the rounds of views
+// 0 to numViews-1 one after the other, as the raw payloads of the messages.
+func newTestRounds(test *testing.T, ctrl *gomock.Controller, leader p2p.Peer, leaderPriKey *bls.SecretKey, numViews int) [][3][]byte {
+	m := mock_host.NewMockHost(ctrl)
+	m.EXPECT().GetSelfPeer().Return(leader)
+	consensusLeader, err := New(m, 0, leader, leaderPriKey)
+	if err != nil {
+		test.Fatalf("Cannot craeate consensus: %v", err)
+	}
+	consensusLeader.UpdatePublicKeys([]*bls.PublicKey{leader.ConsensusPubKey})
+	blockBytes, err := testBlockBytes()
+	if err != nil {
+		test.Fatalf("Cannot decode blockByte: %v", err)
+	}
+	marshal := func(msgBytes []byte) []byte {
+		payload, err := protobuf.Marshal(testConsensusMessage(test, msgBytes))
+		if err != nil {
+			test.Fatalf("Cannot marshal message: %v", err)
+		}
+		return payload
+	}
+
+	rounds := make([][3][]byte, numViews)
+	for viewID := range rounds {
+		consensusLeader.ResetState()
+		consensusLeader.viewID = uint32(viewID)
+		consensusLeader.block = blockBytes
+		consensusLeader.blockHash = testBlockHash(test)
+		rounds[viewID][0] = marshal(consensusLeader.constructAnnounceMessage())
+		consensusLeader.prepareSigs[consensusLeader.SelfAddress] = consensusLeader.priKey.SignHash(consensusLeader.blockHash[:])
+		preparedMsg, aggSig := consensusLeader.constructPreparedMessage()
+		rounds[viewID][1] = marshal(preparedMsg)
+		multiSigAndBitmap := append(aggSig.Serialize(), consensusLeader.prepareBitmap.Bitmap...)
+		consensusLeader.commitSigs[consensusLeader.SelfAddress] = consensusLeader.priKey.SignHash(multiSigAndBitmap)
+		committedMsg, _ := consensusLeader.constructCommittedMessage()
+		rounds[viewID][2] = marshal(committedMsg)
+	}
+	return rounds
+}
+
+func TestProcessMessageValidatorQueuesLaterViews(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	rounds := newTestRounds(test, ctrl, leader, leaderPriKey, 2)
+
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.RejectionChan = make(chan Rejection, 1)
+
+	// The round of view 1 is delivered first, in reverse order.
+	for phase := 2; phase >= 0; phase-- {
+		consensusValidator.ProcessMessageValidator(rounds[1][phase])
+	}
+	assert.Equal(test, uint32(0), consensusValidator.GetViewID())
+	assert.Len(test, consensusValidator.pendingMessages, 3)
+
+	consensusValidator.ProcessMessageValidator(rounds[0][0])
+	consensusValidator.ProcessMessageValidator(rounds[0][1])
+	assert.Equal(test, CommitDone, consensusValidator.state)
+	consensusValidator.ProcessMessageValidator(rounds[0][2])
+
+	// Committing view 0 handed the held round of view 1 to the handlers.
+	assert.Equal(test, uint32(2), consensusValidator.GetViewID())
+	assert.Empty(test, consensusValidator.pendingMessages)
+	assert.Empty(test, consensusValidator.RejectionChan)
+}
+
+func TestProcessMessageValidatorQueueBounds(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	rounds := newTestRounds(test, ctrl, leader, leaderPriKey, maxPendingViews+2)
+
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.RejectionChan = make(chan Rejection, 1)
+
+	// Too far ahead to be held
+	consensusValidator.ProcessMessageValidator(rounds[maxPendingViews+1][0])
+	assert.Empty(test, consensusValidator.pendingMessages)
+	rejection := <-consensusValidator.RejectionChan
+	assert.Equal(test, ErrStaleView, rejection.Err)
+
+	consensusValidator.ProcessMessageValidator(rounds[maxPendingViews][1])
+	assert.Len(test, consensusValidator.pendingMessages, 1)
+
+	// Messages not signed by the leader are not held.
+	forged := &msg_pb.Message{}
+	if err := protobuf.Unmarshal(rounds[1][1], forged); err != nil {
+		test.Fatalf("Cannot unmarshal message: %v", err)
+	}
+	resignTestMessage(test, forged, bls_cosi.RandPrivateKey())
+	forgedPayload, err := protobuf.Marshal(forged)
+	if err != nil {
+		test.Fatalf("Cannot marshal message: %v", err)
+	}
+	consensusValidator.ProcessMessageValidator(forgedPayload)
+	assert.Len(test, consensusValidator.pendingMessages, 1)
+	rejection = <-consensusValidator.RejectionChan
+	assert.Equal(test, ErrBadSignature, rejection.Err)
+
+	// Messages of the earlier views are not held.
+	consensusValidator.viewID = 1
+	consensusValidator.ProcessMessageValidator(rounds[0][0])
+	rejection = <-consensusValidator.RejectionChan
+	assert.Equal(test, msg_pb.MessageType_ANNOUNCE, rejection.Type)
+	assert.Equal(test, ErrStaleView, rejection.Err)
+}
+
+func TestMessageQueueOrder(test *testing.T) {
+	queue := messageQueue{}
+	push := func(viewID uint32, msgType msg_pb.MessageType, seq uint64) {
+		message := &msg_pb.Message{
+			Type:    msgType,
+			Request: &msg_pb.Message_Consensus{Consensus: &msg_pb.ConsensusRequest{ViewId: viewID}},
+		}
+		heap.Push(&queue, &queuedMessage{message: message, seq: seq})
+	}
+	push(2, msg_pb.MessageType_ANNOUNCE, 1)
+	push(1, msg_pb.MessageType_COMMITTED, 2)
+	push(1, msg_pb.MessageType_PREPARED, 3)
+	push(1, msg_pb.MessageType_ANNOUNCE, 4)
+	push(1, msg_pb.MessageType_PREPARED, 5)
+
+	expected := []uint64{4, 3, 5, 2, 1}
+	for _, seq := range expected {
+		assert.Equal(test, seq, heap.Pop(&queue).(*queuedMessage).seq)
+	}
+}
@@ -0,0 +1,43 @@
+package consensus
+
+import "time"
+
+// Metrics receives the measurements of the consensus, for an operator to export
+// them e.g. to a Prometheus registry and alert on stalled shards.
+// The methods are called with consensus.mutex held, so they must not block.
+type Metrics interface {
+	// RoundCompleted is called for every block the node commits.
+	RoundCompleted()
+	// PhaseCompleted is called when the node completes a phase of a round, with the time it took.
+	PhaseCompleted(phase string, duration time.Duration)
+	// SignatureVerified is called with the time taken to verify the signature of a consensus message.
+	SignatureVerified(duration time.Duration)
+	// MessageDropped is called with the reason of every message rejected by checkConsensusMessage.
+	MessageDropped(err error)
+	// ViewChangeStarted is called for every view change the node starts.
+	ViewChangeStarted(viewID uint32)
+}
+
+// noopMetrics is the Metrics of a Consensus nobody measures.
+type noopMetrics struct{}
+
+func (noopMetrics) RoundCompleted()                      {}
+func (noopMetrics) PhaseCompleted(string, time.Duration) {}
+func (noopMetrics) SignatureVerified(time.Duration)      {}
+func (noopMetrics) MessageDropped(error)                 {}
+func (noopMetrics) ViewChangeStarted(uint32)             {}
+
+// metrics returns the Metrics to report to.
+func (consensus *Consensus) metrics() Metrics {
+	if consensus.Metrics == nil {
+		return noopMetrics{}
+	}
+	return consensus.Metrics
+}
+
+// setState moves the round to state and reports the time taken to reach it.
+func (consensus *Consensus) setState(state State) {
+	consensus.metrics().PhaseCompleted(state.String(), time.Since(consensus.phaseStartTime))
+	consensus.phaseStartTime = time.Now()
+	consensus.state = state
+}
@@ -0,0 +1,59 @@
+package consensus
+
+import (
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/harmony-one/bls/ffi/go/bls"
+
+	"github.com/harmony-one/harmony/internal/ctxerror"
+)
+
+const (
+	// multiSigPayloadVersion is the version of the multi-sig payload this node writes.
+	multiSigPayloadVersion = 1
+	// multiSigSize is the size of a serialized BLS signature.
+	multiSigSize = 48
+)
+
+// multiSigPayload is the payload of the PREPARED and COMMITTED messages: the aggregated
+// signature of a phase and the bitmap of its signers.
+//
+// It is RLP encoded after its version. Later versions may only append fields, which
+// Rest keeps so that an older validator can still decode the fields it knows.
+type multiSigPayload struct {
+	Version   uint
+	Signature []byte
+	Bitmap    []byte
+	Rest      []rlp.RawValue `rlp:"tail"`
+}
+
+// encodeMultiSigPayload returns the payload carrying the given signature and bitmap.
+func encodeMultiSigPayload(sig *bls.Sign, bitmap []byte) ([]byte, error) {
+	return rlp.EncodeToBytes(&multiSigPayload{
+		Version:   multiSigPayloadVersion,
+		Signature: sig.Serialize(),
+		Bitmap:    bitmap,
+	})
+}
+
+// decodeMultiSigPayload decodes and validates a multi-sig payload.
+func decodeMultiSigPayload(payload []byte) (*multiSigPayload, error) {
+	decoded := &multiSigPayload{}
+	if err := rlp.DecodeBytes(payload, decoded); err != nil {
+		return nil, ctxerror.New("cannot decode multi-sig payload").WithCause(err)
+	}
+	if decoded.Version < 1 {
+		return nil, ctxerror.New("unknown multi-sig payload version",
+			"version", decoded.Version)
+	}
+	if len(decoded.Signature) != multiSigSize {
+		return nil, ctxerror.New("invalid multi-sig length",
+			"expected", multiSigSize, "actual", len(decoded.Signature))
+	}
+	return decoded, nil
+}
+
+// sigAndBitmap returns the signature followed by the bitmap, which is what the
+// validators sign in the commit phase.
+func (payload *multiSigPayload) sigAndBitmap() []byte {
+	return append(append([]byte{}, payload.Signature...), payload.Bitmap...)
+}
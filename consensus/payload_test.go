@@ -0,0 +1,61 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/assert"
+
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+)
+
+func TestMultiSigPayloadRoundTrip(test *testing.T) {
+	sig := bls_cosi.RandPrivateKey().SignHash([]byte("hash"))
+	encoded, err := encodeMultiSigPayload(sig, []byte{0x05})
+	if err != nil {
+		test.Fatalf("Cannot encode payload: %v", err)
+	}
+	payload, err := decodeMultiSigPayload(encoded)
+	if assert.NoError(test, err) {
+		assert.Equal(test, uint(multiSigPayloadVersion), payload.Version)
+		assert.Equal(test, sig.Serialize(), payload.Signature)
+		assert.Equal(test, []byte{0x05}, payload.Bitmap)
+		assert.Equal(test, append(sig.Serialize(), 0x05), payload.sigAndBitmap())
+	}
+}
+
+func TestMultiSigPayloadFutureVersion(test *testing.T) {
+	sig := bls_cosi.RandPrivateKey().SignHash([]byte("hash"))
+	// A later version appending a field the current one does not know.
+	encoded, err := rlp.EncodeToBytes([]interface{}{uint(2), sig.Serialize(), []byte{0x01}, []byte("new field")})
+	if err != nil {
+		test.Fatalf("Cannot encode payload: %v", err)
+	}
+	payload, err := decodeMultiSigPayload(encoded)
+	if assert.NoError(test, err) {
+		assert.Equal(test, sig.Serialize(), payload.Signature)
+		assert.Equal(test, []byte{0x01}, payload.Bitmap)
+	}
+}
+
+func TestMultiSigPayloadInvalid(test *testing.T) {
+	sig := bls_cosi.RandPrivateKey().SignHash([]byte("hash")).Serialize()
+	encode := func(fields ...interface{}) []byte {
+		encoded, err := rlp.EncodeToBytes(fields)
+		if err != nil {
+			test.Fatalf("Cannot encode payload: %v", err)
+		}
+		return encoded
+	}
+
+	for name, payload := range map[string][]byte{
+		"empty":          {},
+		"legacy layout":  append(sig, 0x01),
+		"no version":     encode(uint(0), sig, []byte{0x01}),
+		"short multiSig": encode(uint(1), sig[:47], []byte{0x01}),
+		"missing bitmap": encode(uint(1), sig),
+	} {
+		_, err := decodeMultiSigPayload(payload)
+		assert.Error(test, err, name)
+	}
+}
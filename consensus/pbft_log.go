@@ -292,7 +292,10 @@ func (consensus *Consensus) ParseNewViewMessage(msg *msg_pb.Message) (*PbftMessa
 			utils.GetLogInstance().Warn("ParseViewChangeMessage failed to create mask for multi signature", "error", err)
 			return nil, err
 		}
-		m3mask.SetMask(vcMsg.M3Bitmap)
+		if err := m3mask.SetMask(vcMsg.M3Bitmap); err != nil {
+			utils.GetLogInstance().Warn("ParseViewChangeMessage failed to set the mask for multi signature", "error", err)
+			return nil, err
+		}
 		pbftMsg.M3AggSig = &m3Sig
 		pbftMsg.M3Bitmap = m3mask
 	}
@@ -309,7 +312,10 @@ func (consensus *Consensus) ParseNewViewMessage(msg *msg_pb.Message) (*PbftMessa
 			utils.GetLogInstance().Warn("ParseViewChangeMessage failed to create mask for multi signature", "error", err)
 			return nil, err
 		}
-		m2mask.SetMask(vcMsg.M2Bitmap)
+		if err := m2mask.SetMask(vcMsg.M2Bitmap); err != nil {
+			utils.GetLogInstance().Warn("ParseViewChangeMessage failed to set the mask for multi signature", "error", err)
+			return nil, err
+		}
 		pbftMsg.M2AggSig = &m2Sig
 		pbftMsg.M2Bitmap = m2mask
 	}
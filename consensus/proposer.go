@@ -0,0 +1,79 @@
+package consensus
+
+import (
+	"context"
+	"time"
+
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/internal/ctxerror"
+	"github.com/harmony-one/harmony/internal/utils"
+)
+
+// Proposer builds the blocks the leader announces, so that another block builder, e.g.
+// one falling back to empty blocks or ordering transactions by fee, can be plugged in
+// without touching the consensus.
+type Proposer interface {
+	// ProposeBlock returns the next block to announce. It may wait for transactions, and
+	// returns ctx.Err() if ctx is done first.
+	ProposeBlock(ctx context.Context) (*types.Block, error)
+}
+
+// RunProposer asks Proposer for a block every time readySignal tells that the leader can
+// start a round, and hands the block to blockChannel, where the consensus loop announces
+// it. A failed proposal is retried every proposeRetryInterval. The pending proposal is canceled
+// when stopChan receives, and stoppedChan is closed once RunProposer returns.
+func (consensus *Consensus) RunProposer(readySignal chan struct{}, blockChannel chan *types.Block, stopChan chan struct{}, stoppedChan chan struct{}) {
+	defer close(stoppedChan)
+	if consensus.Proposer == nil {
+		utils.GetLogInstance().Error("No block proposer, the leader cannot start rounds")
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			utils.GetLogInstance().Debug("Consensus propose new block: STOPPED!")
+			return
+		case <-readySignal:
+		}
+		block := consensus.proposeBlock(ctx)
+		if block == nil {
+			return
+		}
+		utils.GetLogInstance().Debug("Consensus sending new block to block channel", "blockNum", block.NumberU64())
+		select {
+		case blockChannel <- block:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// proposeBlock asks Proposer for a block until it gets one, or returns nil once ctx is done.
+func (consensus *Consensus) proposeBlock(ctx context.Context) *types.Block {
+	for {
+		block, err := consensus.Proposer.ProposeBlock(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err == nil {
+			return block
+		}
+		ctxerror.Log15(utils.GetLogger().Error,
+			ctxerror.New("cannot propose new block").
+				WithCause(err))
+		select {
+		case <-time.After(proposeRetryInterval):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package consensus
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/harmony-one/harmony/core/types"
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/p2p"
+	mock_host "github.com/harmony-one/harmony/p2p/host/mock"
+)
+
+// testProposer fails its first proposal, then proposes blocks until ctx is done.
+type testProposer struct {
+	numCalls int
+	canceled chan struct{}
+}
+
+func (proposer *testProposer) ProposeBlock(ctx context.Context) (*types.Block, error) {
+	proposer.numCalls++
+	switch proposer.numCalls {
+	case 1:
+		return nil, errors.New("no transactions")
+	case 2:
+		return types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)}), nil
+	}
+	<-ctx.Done()
+	close(proposer.canceled)
+	return nil, ctx.Err()
+}
+
+func TestRunProposer(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "19999"}
+	priKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = priKey.GetPublicKey()
+	m := mock_host.NewMockHost(ctrl)
+	m.EXPECT().GetSelfPeer().Return(leader)
+	consensus, err := New(m, 0, leader, priKey)
+	if err != nil {
+		test.Fatalf("Cannot create consensus: %v", err)
+	}
+	proposer := &testProposer{canceled: make(chan struct{})}
+	consensus.Proposer = proposer
+
+	readySignal := make(chan struct{})
+	blockChannel := make(chan *types.Block)
+	stopChan := make(chan struct{})
+	stoppedChan := make(chan struct{})
+	go consensus.RunProposer(readySignal, blockChannel, stopChan, stoppedChan)
+
+	// The failed proposal is retried.
+	readySignal <- struct{}{}
+	select {
+	case block := <-blockChannel:
+		assert.Equal(test, uint64(1), block.NumberU64())
+	case <-time.After(5 * time.Second):
+		test.Fatal("No block proposed")
+	}
+	assert.Equal(test, 2, proposer.numCalls)
+
+	// Stopping cancels the pending proposal.
+	readySignal <- struct{}{}
+	stopChan <- struct{}{}
+	select {
+	case <-stoppedChan:
+	case <-time.After(5 * time.Second):
+		test.Fatal("Proposer not stopped")
+	}
+	<-proposer.canceled
+}
@@ -0,0 +1,75 @@
+package consensus
+
+import (
+	"encoding/hex"
+	"sort"
+
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+)
+
+// Quorum decides whether the signers of a phase are enough for the committee to agree.
+type Quorum interface {
+	// IsAchieved returns whether the signers set in mask form a quorum of its committee.
+	IsAchieved(mask *bls_cosi.Mask) bool
+
+	// Margin returns the number of signers set in mask who could have not signed with the
+	// quorum still achieved whichever they are, or a negative number if it is not achieved.
+	Margin(mask *bls_cosi.Mask) int
+}
+
+// CountQuorum is the quorum of 2f+1 members out of a committee of 3f+1, every member
+// having one vote.
+type CountQuorum struct{}
+
+// IsAchieved returns whether more than two thirds of the committee signed.
+func (CountQuorum) IsAchieved(mask *bls_cosi.Mask) bool {
+	return mask.CountEnabled() >= mask.CountTotal()*2/3+1
+}
+
+// Margin returns the number of signers above 2f+1.
+func (CountQuorum) Margin(mask *bls_cosi.Mask) int {
+	return mask.CountEnabled() - (mask.CountTotal()*2/3 + 1)
+}
+
+// StakeQuorum is the quorum of the members holding more than two thirds of the voting
+// power, e.g. the stake, of the committee.
+type StakeQuorum struct {
+	// The voting power of the members, keyed by the hex string of their blsKey.
+	// A member missing from the map has no voting power; a nil map gives every
+	// member the same voting power.
+	VotingPower map[string]uint64
+}
+
+// IsAchieved returns whether the signers hold more than two thirds of the voting power.
+func (quorum StakeQuorum) IsAchieved(mask *bls_cosi.Mask) bool {
+	return bls_cosi.NewWeightedThresholdPolicy(quorum.VotingPower).Check(mask)
+}
+
+// Margin returns the number of signers who could have not signed with the quorum still
+// achieved, the signers with the most voting power dropping out first.
+func (quorum StakeQuorum) Margin(mask *bls_cosi.Mask) int {
+	if !quorum.IsAchieved(mask) {
+		return -1
+	}
+	powers := []uint64{}
+	for _, key := range mask.GetPubKeyFromMask(true) {
+		power := uint64(1)
+		if quorum.VotingPower != nil {
+			power = quorum.VotingPower[hex.EncodeToString(key.Serialize())]
+		}
+		powers = append(powers, power)
+	}
+	sort.Slice(powers, func(i, j int) bool { return powers[i] > powers[j] })
+
+	enabled := mask.WeightEnabled(quorum.VotingPower)
+	total := mask.WeightTotal(quorum.VotingPower)
+	margin := 0
+	for _, power := range powers {
+		enabled -= power
+		if enabled*3 <= total*2 {
+			break
+		}
+		margin++
+	}
+	return margin
+}
@@ -0,0 +1,80 @@
+package consensus
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/harmony-one/bls/ffi/go/bls"
+	"github.com/stretchr/testify/assert"
+
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+)
+
+// testQuorumMask returns a mask of a committee of numMembers keys where the first numSigners signed.
+func testQuorumMask(test *testing.T, numMembers, numSigners int) (*bls_cosi.Mask, []*bls.PublicKey) {
+	publicKeys := []*bls.PublicKey{}
+	for i := 0; i < numMembers; i++ {
+		publicKeys = append(publicKeys, bls_cosi.RandPrivateKey().GetPublicKey())
+	}
+	mask, err := bls_cosi.NewMask(publicKeys, nil)
+	if err != nil {
+		test.Fatalf("Cannot create mask: %v", err)
+	}
+	for _, publicKey := range publicKeys[:numSigners] {
+		mask.SetKey(publicKey, true)
+	}
+	return mask, publicKeys
+}
+
+func TestCountQuorum(test *testing.T) {
+	for _, tc := range []struct {
+		numMembers, numSigners int
+		achieved               bool
+	}{
+		{1, 1, true},
+		{1, 0, false},
+		{3, 2, false},
+		{3, 3, true},
+		{4, 2, false},
+		{4, 3, true},
+		{7, 4, false},
+		{7, 5, true},
+	} {
+		mask, _ := testQuorumMask(test, tc.numMembers, tc.numSigners)
+		assert.Equal(test, tc.achieved, CountQuorum{}.IsAchieved(mask), "%d of %d", tc.numSigners, tc.numMembers)
+		assert.Equal(test, tc.achieved, CountQuorum{}.Margin(mask) >= 0, "%d of %d", tc.numSigners, tc.numMembers)
+	}
+	mask, _ := testQuorumMask(test, 7, 7)
+	assert.Equal(test, 2, CountQuorum{}.Margin(mask))
+}
+
+func TestStakeQuorum(test *testing.T) {
+	mask, publicKeys := testQuorumMask(test, 4, 1)
+	quorum := StakeQuorum{VotingPower: map[string]uint64{}}
+	for i, publicKey := range publicKeys {
+		quorum.VotingPower[hex.EncodeToString(publicKey.Serialize())] = []uint64{70, 10, 10, 10}[i]
+	}
+	// A single member holding most of the stake
+	assert.True(test, quorum.IsAchieved(mask))
+	assert.False(test, CountQuorum{}.IsAchieved(mask))
+	assert.Equal(test, 0, quorum.Margin(mask))
+
+	mask, _ = bls_cosi.NewMask(publicKeys, nil)
+	for _, publicKey := range publicKeys[1:] {
+		mask.SetKey(publicKey, true)
+	}
+	assert.False(test, quorum.IsAchieved(mask))
+	assert.True(test, CountQuorum{}.IsAchieved(mask))
+	assert.True(test, quorum.Margin(mask) < 0)
+
+	// Losing the largest member breaks the quorum, but any of the others can be lost
+	mask.SetKey(publicKeys[0], true)
+	assert.Equal(test, 0, quorum.Margin(mask))
+	quorum.VotingPower[hex.EncodeToString(publicKeys[0].Serialize())] = 10
+	assert.Equal(test, 1, quorum.Margin(mask))
+	mask.SetKey(publicKeys[0], false)
+
+	// Without voting powers, every member has the same.
+	assert.True(test, StakeQuorum{}.IsAchieved(mask))
+	assert.Equal(test, 0, StakeQuorum{}.Margin(mask))
+}
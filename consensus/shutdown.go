@@ -0,0 +1,77 @@
+package consensus
+
+import (
+	"context"
+	"time"
+
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	"github.com/harmony-one/harmony/internal/utils"
+)
+
+// Stop aborts the current round and stops handling consensus messages, for a clean
+// shutdown of the node. It drains the channels the consensus sends to, so that no
+// sender stays blocked, until the background block verifications and block requests
+// are finished or ctx is done. A stopped consensus cannot be started again.
+//
+// The consensus state is kept in memory only, so there is nothing to flush; the
+// round in progress is left to the catch up of the other validators.
+func (consensus *Consensus) Stop(ctx context.Context) error {
+	consensus.mutex.Lock()
+	if consensus.stopped {
+		consensus.mutex.Unlock()
+		return nil
+	}
+	consensus.stopped = true
+	for _, timeout := range consensus.consensusTimeout {
+		timeout.Stop()
+	}
+	utils.GetLogInstance().Info("Stopping consensus", "viewID", consensus.viewID, "state", consensus.state)
+	consensus.ResetState()
+	consensus.setState(Finished)
+	consensus.blocksReceived = make(map[uint32]*BlockConsensusStatus)
+	consensus.pipelinedAnnounces = make(map[uint32]*msg_pb.Message)
+	consensus.mutex.Unlock()
+
+	consensus.pendingMutex.Lock()
+	consensus.pendingMessages = nil
+	consensus.pendingMutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		consensus.workers.Wait()
+		close(done)
+	}()
+	for {
+		consensus.drainChannels()
+		select {
+		case <-done:
+			utils.GetLogInstance().Info("Consensus stopped")
+			return nil
+		case <-ctx.Done():
+			utils.GetLogInstance().Warn("Consensus stopped before its workers finished", "error", ctx.Err())
+			return ctx.Err()
+		case <-time.After(stopDrainInterval):
+		}
+	}
+}
+
+// IsStopped returns whether Stop was called.
+func (consensus *Consensus) IsStopped() bool {
+	consensus.mutex.Lock()
+	defer consensus.mutex.Unlock()
+	return consensus.stopped
+}
+
+// drainChannels discards whatever is waiting on the channels the consensus sends to.
+func (consensus *Consensus) drainChannels() {
+	for {
+		select {
+		case <-consensus.MsgChan:
+		case <-consensus.commitFinishChan:
+		case <-consensus.ReadySignal:
+		case <-consensus.VerifiedNewBlock:
+		default:
+			return
+		}
+	}
+}
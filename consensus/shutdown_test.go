@@ -0,0 +1,64 @@
+package consensus
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	protobuf "github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/harmony-one/harmony/core/types"
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/p2p"
+)
+
+func TestStop(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.VerifiedNewBlock = make(chan *types.Block, 1)
+	consensusValidator.VerifiedNewBlock <- &types.Block{}
+
+	consensusValidator.processAnnounceMessage(round.announce)
+	assert.Equal(test, PrepareDone, consensusValidator.state)
+	assert.True(test, consensusValidator.consensusTimeout[timeoutPrepared].IsActive())
+
+	assert.NoError(test, consensusValidator.Stop(context.Background()))
+	assert.True(test, consensusValidator.IsStopped())
+	assert.Equal(test, Finished, consensusValidator.state)
+	assert.False(test, consensusValidator.consensusTimeout[timeoutPrepared].IsActive())
+	assert.Empty(test, consensusValidator.VerifiedNewBlock)
+
+	// The messages are not handled any more.
+	payload, err := protobuf.Marshal(round.prepared)
+	if err != nil {
+		test.Fatalf("Cannot marshal message: %v", err)
+	}
+	consensusValidator.ProcessMessageValidator(payload)
+	assert.Equal(test, Finished, consensusValidator.state)
+	assert.Nil(test, consensusValidator.aggregatedPrepareSig)
+
+	assert.NoError(test, consensusValidator.Stop(context.Background()))
+}
+
+func TestStopTimeout(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leader.ConsensusPubKey = bls_cosi.RandPrivateKey().GetPublicKey()
+	consensusValidator := newTestValidator(test, ctrl, leader)
+
+	// A worker that never finishes
+	consensusValidator.workers.Add(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(test, context.Canceled, consensusValidator.Stop(ctx))
+	consensusValidator.workers.Done()
+}
@@ -0,0 +1,110 @@
+package consensus
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/harmony-one/bls/ffi/go/bls"
+
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	"github.com/harmony-one/harmony/internal/utils"
+)
+
+// errAlreadySigned is returned by SignState.Sign for a view at or below the watermark.
+var errAlreadySigned = errors.New("view already signed")
+
+// SignState keeps, for every key of the node, the highest viewID it signed a prepare and a
+// commit for, in a file which survives restarts. A validator recovering from a crash so
+// cannot be tricked into signing a view it may have signed before, for another block.
+type SignState struct {
+	mutex sync.Mutex
+	path  string
+	// hex public key => message type => highest signed viewID
+	watermarks map[string]map[string]uint32
+}
+
+// LoadSignState reads the sign state at path, or starts an empty one if there is no file yet.
+func LoadSignState(path string) (*SignState, error) {
+	state := &SignState{path: path, watermarks: map[string]map[string]uint32{}}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &state.watermarks); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Sign raises the watermark of pubKey for msgType to viewID and saves it, before the
+// message is signed. It returns errAlreadySigned if viewID is not above the watermark,
+// and the write error if the watermark cannot be saved; the message must not be signed then.
+func (state *SignState) Sign(pubKey *bls.PublicKey, msgType msg_pb.MessageType, viewID uint32) error {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	key := hex.EncodeToString(pubKey.Serialize())
+	watermark, signed := state.watermarks[key][msgType.String()]
+	if signed && viewID <= watermark {
+		return errAlreadySigned
+	}
+	if state.watermarks[key] == nil {
+		state.watermarks[key] = map[string]uint32{}
+	}
+	state.watermarks[key][msgType.String()] = viewID
+	if err := state.save(); err != nil {
+		if signed {
+			state.watermarks[key][msgType.String()] = watermark
+		} else {
+			delete(state.watermarks[key], msgType.String())
+		}
+		return err
+	}
+	return nil
+}
+
+// save replaces the file with the current watermarks, so that a crash while writing leaves
+// the previous ones.
+func (state *SignState) save() error {
+	data, err := json.Marshal(state.watermarks)
+	if err != nil {
+		return err
+	}
+	tmpPath := state.path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, state.path)
+}
+
+// canSign tells whether priKey may sign a message of msgType for the current view, raising
+// its watermark if so. Without a SignState every view can be signed.
+func (consensus *Consensus) canSign(priKey *bls.SecretKey, msgType msg_pb.MessageType) bool {
+	if consensus.SignState == nil {
+		return true
+	}
+	pubKey := priKey.GetPublicKey()
+	if err := consensus.SignState.Sign(pubKey, msgType, consensus.viewID); err != nil {
+		utils.GetLogInstance().Warn("Refusing to sign", "type", msgType, "viewID", consensus.viewID, "address", blsPubKeyToAddress(pubKey), "error", err)
+		return false
+	}
+	return true
+}
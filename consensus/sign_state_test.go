@@ -0,0 +1,68 @@
+package consensus
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/p2p"
+	mock_host "github.com/harmony-one/harmony/p2p/host/mock"
+)
+
+func TestSignState(test *testing.T) {
+	dir, err := ioutil.TempDir("", "sign_state")
+	if err != nil {
+		test.Fatalf("Cannot create the directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sign_state.json")
+
+	pubKey := bls_cosi.RandPrivateKey().GetPublicKey()
+	state, err := LoadSignState(path)
+	assert.NoError(test, err)
+	assert.NoError(test, state.Sign(pubKey, msg_pb.MessageType_PREPARE, 0))
+	assert.NoError(test, state.Sign(pubKey, msg_pb.MessageType_COMMIT, 0))
+	assert.NoError(test, state.Sign(pubKey, msg_pb.MessageType_PREPARE, 5))
+	assert.Equal(test, errAlreadySigned, state.Sign(pubKey, msg_pb.MessageType_PREPARE, 5))
+	assert.Equal(test, errAlreadySigned, state.Sign(pubKey, msg_pb.MessageType_PREPARE, 4))
+
+	// The watermarks survive a restart.
+	state, err = LoadSignState(path)
+	assert.NoError(test, err)
+	assert.Equal(test, errAlreadySigned, state.Sign(pubKey, msg_pb.MessageType_PREPARE, 5))
+	assert.NoError(test, state.Sign(pubKey, msg_pb.MessageType_COMMIT, 5))
+	assert.NoError(test, state.Sign(pubKey, msg_pb.MessageType_PREPARE, 6))
+	assert.NoError(test, state.Sign(bls_cosi.RandPrivateKey().GetPublicKey(), msg_pb.MessageType_PREPARE, 5))
+}
+
+func TestConstructPrepareMessagesSignState(test *testing.T) {
+	dir, err := ioutil.TempDir("", "sign_state")
+	if err != nil {
+		test.Fatalf("Cannot create the directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "19999"}
+	priKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = priKey.GetPublicKey()
+	m := mock_host.NewMockHost(ctrl)
+	m.EXPECT().GetSelfPeer().Return(leader)
+	consensus, err := New(m, 0, leader, priKey)
+	if err != nil {
+		test.Fatalf("Cannot create consensus: %v", err)
+	}
+	consensus.SignState, err = LoadSignState(filepath.Join(dir, "sign_state.json"))
+	assert.NoError(test, err)
+	consensus.viewID = 3
+	assert.Len(test, consensus.constructPrepareMessages(), 1)
+	assert.Len(test, consensus.constructPrepareMessages(), 0)
+	assert.Len(test, consensus.constructCommitMessages([]byte("multiSigAndBitmap")), 1)
+}
@@ -0,0 +1,134 @@
+package consensus
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	protobuf "github.com/golang/protobuf/proto"
+
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/harmony-one/harmony/p2p/host"
+)
+
+// TranscriptDirection tells whether a transcript entry was received or sent by the node.
+type TranscriptDirection uint8
+
+// The directions of the transcript entries
+const (
+	TranscriptReceived TranscriptDirection = iota
+	TranscriptSent
+)
+
+// TranscriptEntry is a consensus message of a round, as recorded in a transcript.
+// Payload is the protobuf-encoded msg_pb.Message with the signature of its sender, so
+// the announces, votes, aggregated signatures and bitmaps can all be verified again.
+type TranscriptEntry struct {
+	ViewID    uint32
+	Direction TranscriptDirection
+	Time      uint64 // Unix time in nanoseconds
+	Payload   []byte
+}
+
+// TranscriptRecorder receives every consensus message the node receives or sends, so
+// that a faulty round can be audited and replayed, see ReplayTranscript.
+// Record is called with consensus.mutex held in places, so it must not block for long.
+type TranscriptRecorder interface {
+	Record(entry *TranscriptEntry)
+}
+
+// TranscriptFile is a TranscriptRecorder appending the entries to a file as a stream
+// of RLP items, which ReadTranscript reads back.
+type TranscriptFile struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+// OpenTranscriptFile opens the transcript file at path for appending, creating it if needed.
+func OpenTranscriptFile(path string) (*TranscriptFile, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &TranscriptFile{file: file}, nil
+}
+
+// Record appends entry to the file.
+func (transcript *TranscriptFile) Record(entry *TranscriptEntry) {
+	transcript.mutex.Lock()
+	defer transcript.mutex.Unlock()
+	if err := rlp.Encode(transcript.file, entry); err != nil {
+		utils.GetLogInstance().Warn("Failed to record consensus message", "viewID", entry.ViewID, "error", err)
+	}
+}
+
+// Close closes the file.
+func (transcript *TranscriptFile) Close() error {
+	transcript.mutex.Lock()
+	defer transcript.mutex.Unlock()
+	return transcript.file.Close()
+}
+
+// ReadTranscript reads the entries of a transcript written by TranscriptFile.
+// An entry cut short by a crash ends the transcript without an error.
+func ReadTranscript(input io.Reader) ([]*TranscriptEntry, error) {
+	stream := rlp.NewStream(input, 0)
+	var entries []*TranscriptEntry
+	for {
+		entry := &TranscriptEntry{}
+		if err := stream.Decode(entry); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return entries, nil
+			}
+			return entries, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// ReplayTranscript feeds the messages the node received for the views fromViewID to
+// toViewID back through ProcessMessageValidator, in the order they were recorded, and
+// returns how many it fed. The consensus should be set up with the committee of these
+// views and start at fromViewID.
+func (consensus *Consensus) ReplayTranscript(entries []*TranscriptEntry, fromViewID, toViewID uint32) int {
+	numReplayed := 0
+	for _, entry := range entries {
+		if entry.Direction != TranscriptReceived || entry.ViewID < fromViewID || entry.ViewID > toViewID {
+			continue
+		}
+		consensus.ProcessMessageValidator(entry.Payload)
+		numReplayed++
+	}
+	return numReplayed
+}
+
+// recordMessage adds a consensus message to the transcript, if there is one.
+func (consensus *Consensus) recordMessage(direction TranscriptDirection, message *msg_pb.Message, payload []byte) {
+	if consensus.Transcript == nil {
+		return
+	}
+	viewID := message.GetConsensus().GetViewId()
+	if message.Type == msg_pb.MessageType_VIEWCHANGE || message.Type == msg_pb.MessageType_NEWVIEW {
+		viewID = message.GetViewchange().GetViewId()
+	}
+	consensus.Transcript.Record(&TranscriptEntry{
+		ViewID:    viewID,
+		Direction: direction,
+		Time:      uint64(time.Now().UnixNano()),
+		Payload:   payload,
+	})
+}
+
+// sendMessage broadcasts a consensus message to the shard and records it in the transcript.
+func (consensus *Consensus) sendMessage(msgToSend []byte) {
+	if consensus.Transcript != nil {
+		message := &msg_pb.Message{}
+		if err := protobuf.Unmarshal(msgToSend, message); err == nil {
+			consensus.recordMessage(TranscriptSent, message, msgToSend)
+		}
+	}
+	consensus.host.SendMessageToGroups(consensus.shardGroupIDs(), host.ConstructExpiringP2pMessage(msgToSend, messageTTL))
+}
@@ -0,0 +1,77 @@
+package consensus
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/harmony-one/harmony/core/types"
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/p2p"
+)
+
+func TestTranscriptReplay(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	rounds := newTestRounds(test, ctrl, leader, leaderPriKey, 2)
+
+	file, err := ioutil.TempFile("", "transcript")
+	if err != nil {
+		test.Fatalf("Cannot create the transcript file: %v", err)
+	}
+	path := file.Name()
+	file.Close()
+	defer os.Remove(path)
+	transcript, err := OpenTranscriptFile(path)
+	if err != nil {
+		test.Fatalf("Cannot open the transcript file: %v", err)
+	}
+
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.Transcript = transcript
+	for _, round := range rounds {
+		for _, payload := range round {
+			consensusValidator.ProcessMessageValidator(payload)
+		}
+	}
+	assert.Equal(test, uint32(2), consensusValidator.GetViewID())
+	assert.NoError(test, transcript.Close())
+
+	input, err := os.Open(path)
+	if err != nil {
+		test.Fatalf("Cannot open the transcript file: %v", err)
+	}
+	defer input.Close()
+	entries, err := ReadTranscript(input)
+	assert.NoError(test, err)
+	// The validator received the messages of the two rounds and sent its votes.
+	var numReceived, numSent int
+	for _, entry := range entries {
+		switch entry.Direction {
+		case TranscriptReceived:
+			numReceived++
+		case TranscriptSent:
+			numSent++
+		}
+	}
+	assert.Equal(test, 6, numReceived)
+	assert.Equal(test, 4, numSent)
+	assert.Equal(test, uint32(0), entries[0].ViewID)
+	assert.Equal(test, rounds[0][0], entries[0].Payload)
+
+	// Replaying the second round alone commits the same block.
+	replayed := newTestValidator(test, ctrl, leader)
+	replayed.viewID = 1
+	var committed *types.Block
+	replayed.OnConsensusDone = func(newBlock *types.Block) { committed = newBlock }
+	assert.Equal(test, 3, replayed.ReplayTranscript(entries, 1, 1))
+	assert.Equal(test, uint32(2), replayed.GetViewID())
+	assert.NotNil(test, committed)
+}
@@ -0,0 +1,70 @@
+package consensus
+
+import (
+	protobuf "github.com/golang/protobuf/proto"
+
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	"github.com/harmony-one/harmony/internal/ctxerror"
+)
+
+const (
+	// blsPubKeySize is the size of a serialized BLS public key.
+	blsPubKeySize = 96
+	// blockHashSize is the size of a block hash.
+	blockHashSize = 32
+)
+
+// ValidateMessage decodes a consensus message and performs the structural checks of the
+// messages handled by the validator: the presence and the length of their fields, and
+// the length of the bitmaps against the committee. It verifies no signature and reads
+// nothing from the node but the size of the committee, so it is safe on any input.
+// The decoded message is returned whenever the payload is a protobuf message.
+func (consensus *Consensus) ValidateMessage(payload []byte) (*msg_pb.Message, error) {
+	message := &msg_pb.Message{}
+	if err := protobuf.Unmarshal(payload, message); err != nil {
+		return nil, ctxerror.New("cannot unmarshal message").WithCause(err)
+	}
+
+	switch message.Type {
+	case msg_pb.MessageType_ANNOUNCE, msg_pb.MessageType_PREPARED, msg_pb.MessageType_COMMITTED,
+		msg_pb.MessageType_BLOCK_RESPONSE:
+	default:
+		// not handled by the validator
+		return message, nil
+	}
+
+	consensusMsg := message.GetConsensus()
+	if consensusMsg == nil {
+		return message, ctxerror.New("missing consensus request", "msgType", message.Type)
+	}
+	if len(message.Signature) != multiSigSize {
+		return message, ctxerror.New("invalid signature length",
+			"msgType", message.Type, "expected", multiSigSize, "actual", len(message.Signature))
+	}
+	if len(consensusMsg.SenderPubkey) != blsPubKeySize {
+		return message, ctxerror.New("invalid sender public key length",
+			"msgType", message.Type, "expected", blsPubKeySize, "actual", len(consensusMsg.SenderPubkey))
+	}
+	if len(consensusMsg.BlockHash) != blockHashSize {
+		return message, ctxerror.New("invalid block hash length",
+			"msgType", message.Type, "expected", blockHashSize, "actual", len(consensusMsg.BlockHash))
+	}
+	if len(consensusMsg.Payload) == 0 {
+		return message, ctxerror.New("missing payload", "msgType", message.Type)
+	}
+
+	if message.Type == msg_pb.MessageType_PREPARED || message.Type == msg_pb.MessageType_COMMITTED {
+		multiSigPayload, err := decodeMultiSigPayload(consensusMsg.Payload)
+		if err != nil {
+			return message, err
+		}
+		consensus.pubKeyLock.Lock()
+		bitmapLen := (len(consensus.PublicKeys) + 7) >> 3
+		consensus.pubKeyLock.Unlock()
+		if len(multiSigPayload.Bitmap) != bitmapLen {
+			return message, ctxerror.New("invalid bitmap length",
+				"msgType", message.Type, "expected", bitmapLen, "actual", len(multiSigPayload.Bitmap))
+		}
+	}
+	return message, nil
+}
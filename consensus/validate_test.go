@@ -0,0 +1,99 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/golang/mock/gomock"
+	protobuf "github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/p2p"
+)
+
+func TestValidateMessage(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+
+	marshal := func(message *msg_pb.Message) []byte {
+		payload, err := protobuf.Marshal(message)
+		if err != nil {
+			test.Fatalf("Cannot marshal message: %v", err)
+		}
+		return payload
+	}
+	malformed := func(message *msg_pb.Message, malform func(*msg_pb.Message)) []byte {
+		message = protobuf.Clone(message).(*msg_pb.Message)
+		malform(message)
+		return marshal(message)
+	}
+
+	for _, message := range []*msg_pb.Message{round.announce, round.prepared, round.committed} {
+		validated, err := consensusValidator.ValidateMessage(marshal(message))
+		if assert.NoError(test, err, "%s", message.Type) {
+			assert.True(test, protobuf.Equal(message, validated), "%s", message.Type)
+		}
+	}
+	// Messages the validator does not handle are left to the dispatch.
+	_, err := consensusValidator.ValidateMessage(marshal(&msg_pb.Message{Type: msg_pb.MessageType_DRAND_INIT}))
+	assert.NoError(test, err)
+
+	for name, payload := range map[string][]byte{
+		"not a message":    {0xff, 0xff},
+		"no request":       malformed(round.announce, func(message *msg_pb.Message) { message.Request = nil }),
+		"short signature":  malformed(round.announce, func(message *msg_pb.Message) { message.Signature = message.Signature[:47] }),
+		"short sender":     malformed(round.announce, func(message *msg_pb.Message) { message.GetConsensus().SenderPubkey = []byte{1} }),
+		"short block hash": malformed(round.prepared, func(message *msg_pb.Message) { message.GetConsensus().BlockHash = nil }),
+		"empty block":      malformed(round.announce, func(message *msg_pb.Message) { message.GetConsensus().Payload = nil }),
+		"bad multi-sig":    malformed(round.committed, func(message *msg_pb.Message) { message.GetConsensus().Payload = []byte{0x01} }),
+		"long bitmap": malformed(round.prepared, func(message *msg_pb.Message) {
+			payload, err := decodeMultiSigPayload(message.GetConsensus().Payload)
+			if err != nil {
+				test.Fatalf("Cannot decode payload: %v", err)
+			}
+			payload.Bitmap = append(payload.Bitmap, 0)
+			if message.GetConsensus().Payload, err = rlp.EncodeToBytes(payload); err != nil {
+				test.Fatalf("Cannot encode payload: %v", err)
+			}
+		}),
+	} {
+		_, err := consensusValidator.ValidateMessage(payload)
+		assert.Error(test, err, name)
+	}
+}
+
+func TestProcessMessageValidatorMalformed(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	round := newTestRound(test, ctrl, leader, leaderPriKey, 0)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+	consensusValidator.DeadLetterChan = make(chan DeadLetter, 1)
+
+	announce := protobuf.Clone(round.announce).(*msg_pb.Message)
+	announce.GetConsensus().BlockHash = announce.GetConsensus().BlockHash[:16]
+	payload, err := protobuf.Marshal(announce)
+	if err != nil {
+		test.Fatalf("Cannot marshal message: %v", err)
+	}
+	consensusValidator.ProcessMessageValidator(payload)
+	assert.Equal(test, Finished, consensusValidator.state)
+	select {
+	case letter := <-consensusValidator.DeadLetterChan:
+		assert.Equal(test, msg_pb.MessageType_ANNOUNCE, letter.Type)
+		assert.Error(test, letter.Err)
+	default:
+		test.Fatal("no dead letter reported")
+	}
+}
@@ -0,0 +1,83 @@
+package consensus
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/harmony-one/bls/ffi/go/bls"
+
+	"github.com/harmony-one/harmony/internal/ctxerror"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/harmony-one/harmony/p2p"
+)
+
+// validatorSetUpdate is a change of the committee waiting for the view it takes effect at.
+type validatorSetUpdate struct {
+	pubKeys []*bls.PublicKey
+	viewID  uint32
+}
+
+// UpdateValidatorSet replaces the committee with newKeys from the round of effectiveViewID
+// on, without restarting the node. The rounds before effectiveViewID, including the one in
+// progress, complete under the current committee; the keys and the signature masks are
+// swapped together when the round before effectiveViewID is committed, or right away if
+// the consensus is already between rounds at effectiveViewID. All the members must agree
+// on effectiveViewID. A later call replaces an update which has not taken effect yet.
+//
+// The leader is kept if it is still in the committee, and otherwise handed to newKeys[0],
+// as UpdatePublicKeys does.
+func (consensus *Consensus) UpdateValidatorSet(newKeys []*bls.PublicKey, effectiveViewID uint32) error {
+	if len(newKeys) == 0 {
+		return ctxerror.New("empty validator set")
+	}
+	seen := map[common.Address]bool{}
+	for _, pubKey := range newKeys {
+		address := utils.GetBlsAddress(pubKey)
+		if seen[address] {
+			return ctxerror.New("duplicate validator key", "key", pubKey.GetHexString())
+		}
+		seen[address] = true
+	}
+
+	consensus.mutex.Lock()
+	defer consensus.mutex.Unlock()
+	roundStarted := consensus.blockHash != [32]byte{}
+	if effectiveViewID < consensus.viewID || (effectiveViewID == consensus.viewID && roundStarted) {
+		return ctxerror.New("validator set update for a view already started",
+			"effectiveViewID", effectiveViewID, "viewID", consensus.viewID)
+	}
+	consensus.pendingValidatorSet = &validatorSetUpdate{
+		pubKeys: append(newKeys[:0:0], newKeys...),
+		viewID:  effectiveViewID,
+	}
+	utils.GetLogInstance().Info("Validator set update scheduled", "effectiveViewID", effectiveViewID, "numKeys", len(newKeys))
+	consensus.applyValidatorSetUpdate()
+	return nil
+}
+
+// applyValidatorSetUpdate swaps in the committee scheduled by UpdateValidatorSet once its
+// view is reached. It is called whenever a commit moves viewID on, before the next round
+// starts. The caller must hold consensus.mutex.
+func (consensus *Consensus) applyValidatorSetUpdate() {
+	update := consensus.pendingValidatorSet
+	if update == nil || consensus.viewID < update.viewID {
+		return
+	}
+	consensus.pendingValidatorSet = nil
+
+	consensus.pubKeyLock.Lock()
+	consensus.PublicKeys = update.pubKeys
+	consensus.CommitteeAddresses = map[common.Address]bool{}
+	for _, pubKey := range consensus.PublicKeys {
+		consensus.CommitteeAddresses[utils.GetBlsAddress(pubKey)] = true
+	}
+	if consensus.LeaderPubKey == nil || !consensus.CommitteeAddresses[utils.GetBlsAddress(consensus.LeaderPubKey)] {
+		consensus.LeaderPubKey = consensus.PublicKeys[0]
+		consensus.leader = p2p.Peer{ConsensusPubKey: consensus.LeaderPubKey}
+	}
+	consensus.pubKeyLock.Unlock()
+
+	consensus.prepareBitmap = consensus.newLeaderMask()
+	consensus.commitBitmap = consensus.newLeaderMask()
+	consensus.ResetViewChangeState()
+	utils.GetLogInstance().Info("Validator set updated", "viewID", consensus.viewID,
+		"numKeys", len(consensus.PublicKeys), "leader", consensus.LeaderPubKey.GetHexString())
+}
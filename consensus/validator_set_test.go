@@ -0,0 +1,51 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/harmony-one/bls/ffi/go/bls"
+	"github.com/stretchr/testify/assert"
+
+	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/p2p"
+)
+
+func TestUpdateValidatorSet(test *testing.T) {
+	ctrl := gomock.NewController(test)
+	defer ctrl.Finish()
+
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "7782"}
+	leaderPriKey := bls_cosi.RandPrivateKey()
+	leader.ConsensusPubKey = leaderPriKey.GetPublicKey()
+	rounds := newTestRounds(test, ctrl, leader, leaderPriKey, 1)
+	consensusValidator := newTestValidator(test, ctrl, leader)
+
+	newKeys := []*bls.PublicKey{leader.ConsensusPubKey, bls_cosi.RandPrivateKey().GetPublicKey()}
+	assert.NoError(test, consensusValidator.UpdateValidatorSet(newKeys, 1))
+
+	// The round of view 0 completes under the old committee.
+	consensusValidator.ProcessMessageValidator(rounds[0][0])
+	assert.Len(test, consensusValidator.PublicKeys, 1)
+	assert.Error(test, consensusValidator.UpdateValidatorSet(newKeys, 0))
+	consensusValidator.ProcessMessageValidator(rounds[0][1])
+	assert.Len(test, consensusValidator.PublicKeys, 1)
+	consensusValidator.ProcessMessageValidator(rounds[0][2])
+
+	assert.Equal(test, uint32(1), consensusValidator.GetViewID())
+	assert.Equal(test, newKeys, consensusValidator.PublicKeys)
+	assert.Len(test, consensusValidator.CommitteeAddresses, 2)
+	assert.Equal(test, 2, consensusValidator.prepareBitmap.CountTotal())
+	assert.Equal(test, 2, consensusValidator.viewIDBitmap.CountTotal())
+	assert.True(test, consensusValidator.LeaderPubKey.IsEqual(leader.ConsensusPubKey))
+	assert.Nil(test, consensusValidator.pendingValidatorSet)
+
+	// Between rounds, an update for the current view takes effect right away.
+	otherKey := bls_cosi.RandPrivateKey().GetPublicKey()
+	assert.NoError(test, consensusValidator.UpdateValidatorSet([]*bls.PublicKey{otherKey}, 1))
+	assert.Len(test, consensusValidator.PublicKeys, 1)
+	assert.True(test, consensusValidator.LeaderPubKey.IsEqual(otherKey))
+
+	assert.Error(test, consensusValidator.UpdateValidatorSet(nil, 2))
+	assert.Error(test, consensusValidator.UpdateValidatorSet([]*bls.PublicKey{otherKey, otherKey}, 2))
+}
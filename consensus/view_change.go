@@ -11,8 +11,6 @@ import (
 	msg_pb "github.com/harmony-one/harmony/api/proto/message"
 	bls_cosi "github.com/harmony-one/harmony/crypto/bls"
 	"github.com/harmony-one/harmony/internal/utils"
-	"github.com/harmony-one/harmony/p2p"
-	"github.com/harmony-one/harmony/p2p/host"
 )
 
 // PbftPhase  PBFT phases: pre-prepare, prepare and commit
@@ -25,6 +23,19 @@ const (
 	Commit
 )
 
+// Returns string name for the PbftPhase enum
+func (phase PbftPhase) String() string {
+	names := [...]string{
+		"Announce",
+		"Prepare",
+		"Commit"}
+
+	if phase < Announce || phase > Commit {
+		return "Unknown"
+	}
+	return names[phase]
+}
+
 // Mode determines whether a node is in normal or viewchanging mode
 type Mode int
 
@@ -85,27 +96,19 @@ func (consensus *Consensus) switchPhase(desirePhase PbftPhase) {
 		nextPhase = Announce
 	}
 	if nextPhase == desirePhase {
+		consensus.metrics().PhaseCompleted(consensus.phase.String(), time.Since(consensus.phaseStartTime))
+		consensus.phaseStartTime = time.Now()
 		consensus.phase = nextPhase
 	}
 }
 
 // GetNextLeaderKey uniquely determine who is the leader for given viewID
 func (consensus *Consensus) GetNextLeaderKey() *bls.PublicKey {
-	idx := consensus.getIndexOfPubKey(consensus.LeaderPubKey)
-	if idx == -1 {
-		utils.GetLogInstance().Warn("GetNextLeaderKey: currentLeaderKey not found", "key", consensus.LeaderPubKey.GetHexString())
+	rotation := consensus.LeaderRotation
+	if rotation == nil {
+		rotation = RoundRobinRotation{}
 	}
-	idx = (idx + 1) % len(consensus.PublicKeys)
-	return consensus.PublicKeys[idx]
-}
-
-func (consensus *Consensus) getIndexOfPubKey(pubKey *bls.PublicKey) int {
-	for k, v := range consensus.PublicKeys {
-		if v.IsEqual(pubKey) {
-			return k
-		}
-	}
-	return -1
+	return rotation.NextLeader(consensus.PublicKeys, consensus.LeaderPubKey, consensus.blockNum)
 }
 
 // ResetViewChangeState reset the state for viewchange
@@ -129,16 +132,53 @@ func createTimeout() map[TimeoutType]*utils.Timeout {
 	timeouts[timeoutConsensus] = utils.NewTimeout(phaseDuration)
 	timeouts[timeoutViewChange] = utils.NewTimeout(viewChangeDuration)
 	timeouts[timeoutBootstrap] = utils.NewTimeout(bootstrapDuration)
+	timeouts[timeoutPrepared] = utils.NewTimeout(DefaultConsensusTimeoutConfig.Prepared)
+	timeouts[timeoutCommitted] = utils.NewTimeout(DefaultConsensusTimeoutConfig.Committed)
 	return timeouts
 }
 
+// phaseTimeoutDuration returns the wait for a phase of base duration, backed off
+// by the number of phase timeouts in a row.
+func (consensus *Consensus) phaseTimeoutDuration(base time.Duration) time.Duration {
+	config := consensus.TimeoutConfig
+	duration := base
+	for i := 0; i < consensus.numPhaseTimeouts; i++ {
+		duration = time.Duration(float64(duration) * config.BackoffFactor)
+		if config.MaxTimeout > 0 && duration >= config.MaxTimeout {
+			return config.MaxTimeout
+		}
+	}
+	return duration
+}
+
+// startPhaseTimeout starts waiting for the leader message of the next validator phase.
+// The caller must hold consensus.mutex.
+func (consensus *Consensus) startPhaseTimeout(timeoutType TimeoutType) {
+	base := consensus.TimeoutConfig.Prepared
+	if timeoutType == timeoutCommitted {
+		base = consensus.TimeoutConfig.Committed
+	}
+	consensus.consensusTimeout[timeoutType].SetDuration(consensus.phaseTimeoutDuration(base))
+	consensus.consensusTimeout[timeoutType].Start()
+}
+
+// stopPhaseTimeouts stops waiting for the leader messages of the validator phases.
+// The caller must hold consensus.mutex.
+func (consensus *Consensus) stopPhaseTimeouts() {
+	consensus.consensusTimeout[timeoutPrepared].Stop()
+	consensus.consensusTimeout[timeoutCommitted].Stop()
+}
+
 // startViewChange send a  new view change
+// The caller must hold consensus.mutex.
 func (consensus *Consensus) startViewChange(viewID uint32) {
 	if consensus.disableViewChange {
 		return
 	}
+	consensus.metrics().ViewChangeStarted(viewID)
 	consensus.consensusTimeout[timeoutConsensus].Stop()
 	consensus.consensusTimeout[timeoutBootstrap].Stop()
+	consensus.stopPhaseTimeouts()
 	consensus.mode.SetMode(ViewChanging)
 	consensus.mode.SetViewID(viewID)
 	consensus.LeaderPubKey = consensus.GetNextLeaderKey()
@@ -148,7 +188,7 @@ func (consensus *Consensus) startViewChange(viewID uint32) {
 	utils.GetLogInstance().Info("startViewChange", "viewID", viewID, "timeoutDuration", duration, "nextLeader", consensus.LeaderPubKey.GetHexString()[:10])
 
 	msgToSend := consensus.constructViewChangeMessage()
-	consensus.host.SendMessageToGroups([]p2p.GroupID{p2p.NewGroupIDByShardID(p2p.ShardID(consensus.ShardID))}, host.ConstructP2pMessage(byte(17), msgToSend))
+	consensus.sendMessage(msgToSend)
 
 	consensus.consensusTimeout[timeoutViewChange].SetDuration(duration)
 	consensus.consensusTimeout[timeoutViewChange].Start()
@@ -161,7 +201,7 @@ func (consensus *Consensus) startNewView() {
 	consensus.switchPhase(Announce)
 
 	msgToSend := consensus.constructNewViewMessage()
-	consensus.host.SendMessageToGroups([]p2p.GroupID{p2p.NewGroupIDByShardID(p2p.ShardID(consensus.ShardID))}, host.ConstructP2pMessage(byte(17), msgToSend))
+	consensus.sendMessage(msgToSend)
 }
 
 func (consensus *Consensus) onViewChange(msg *msg_pb.Message) {
@@ -224,7 +264,7 @@ func (consensus *Consensus) onViewChange(msg *msg_pb.Message) {
 		consensus.viewIDBitmap.SetKey(consensus.PubKey, true)
 	}
 
-	if len(consensus.viewIDSigs) >= consensus.Quorum() {
+	if consensus.IsQuorumAchieved(consensus.viewIDBitmap) {
 		return
 	}
 
@@ -294,7 +334,7 @@ func (consensus *Consensus) onViewChange(msg *msg_pb.Message) {
 	consensus.viewIDSigs[validatorAddress] = recvMsg.ViewidSig
 	consensus.viewIDBitmap.SetKey(recvMsg.SenderPubkey, true) // Set the bitmap indicating that this validator signed.
 
-	if len(consensus.viewIDSigs) >= consensus.Quorum() {
+	if consensus.IsQuorumAchieved(consensus.viewIDBitmap) {
 		consensus.mode.SetMode(Normal)
 		consensus.LeaderPubKey = consensus.PubKey
 		consensus.ResetState()
@@ -314,14 +354,14 @@ func (consensus *Consensus) onViewChange(msg *msg_pb.Message) {
 			consensus.prepareBitmap = mask
 
 			// Leader sign the multi-sig and bitmap (for commit phase)
-			consensus.commitSigs[consensus.SelfAddress] = consensus.priKey.SignHash(consensus.m1Payload[32:])
+			consensus.commitSigs[consensus.SelfAddress] = consensus.priKey.SignHash(append(aggSig.Serialize(), mask.Bitmap...))
 		}
 
 		consensus.mode.SetViewID(recvMsg.ViewID)
 		msgToSend := consensus.constructNewViewMessage()
 
 		utils.GetLogInstance().Warn("onViewChange", "sent newview message", len(msgToSend))
-		consensus.host.SendMessageToGroups([]p2p.GroupID{p2p.NewGroupIDByShardID(p2p.ShardID(consensus.ShardID))}, host.ConstructP2pMessage(byte(17), msgToSend))
+		consensus.sendMessage(msgToSend)
 
 		consensus.viewID = recvMsg.ViewID
 		consensus.ResetViewChangeState()
@@ -411,9 +451,10 @@ func (consensus *Consensus) onNewView(msg *msg_pb.Message) {
 		consensus.viewID = consensus.mode.GetViewID()
 		// Construct and send the commit message
 		multiSigAndBitmap := append(aggSig.Serialize(), mask.Bitmap...)
-		msgToSend := consensus.constructCommitMessage(multiSigAndBitmap)
-		utils.GetLogInstance().Info("onNewView === commit", "sent commit message", len(msgToSend), "viewID", consensus.viewID)
-		consensus.host.SendMessageToGroups([]p2p.GroupID{p2p.NewGroupIDByShardID(p2p.ShardID(consensus.ShardID))}, host.ConstructP2pMessage(byte(17), msgToSend))
+		for _, msgToSend := range consensus.constructCommitMessages(multiSigAndBitmap) {
+			utils.GetLogInstance().Info("onNewView === commit", "sent commit message", len(msgToSend), "viewID", consensus.viewID)
+			consensus.sendMessage(msgToSend)
+		}
 		consensus.phase = Commit
 	} else {
 		consensus.ResetState()
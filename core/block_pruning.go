@@ -0,0 +1,87 @@
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+
+	"github.com/harmony-one/harmony/core/rawdb"
+	"github.com/harmony-one/harmony/internal/utils"
+)
+
+// minBlockRetention is the fewest recent blocks a pruning node keeps the bodies and
+// receipts of, so that it can still serve peers catching up from a few blocks behind.
+const minBlockRetention = 128
+
+// SetBlockRetention makes bc drop the bodies and receipts of the blocks older than the
+// last retention blocks as new blocks are inserted, keeping their headers. 0 keeps the
+// whole history. Pruning is skipped while holdPruning, if not nil, returns true, e.g.
+// while peers are syncing from this node.
+func (bc *BlockChain) SetBlockRetention(retention uint64, holdPruning func() bool) {
+	if retention != 0 && retention < minBlockRetention {
+		utils.GetLogInstance().Warn("Block retention too short, raising it",
+			"retention", retention, "minimum", minBlockRetention)
+		retention = minBlockRetention
+	}
+	bc.pruneMu.Lock()
+	defer bc.pruneMu.Unlock()
+	bc.retention = retention
+	bc.holdPruning = holdPruning
+}
+
+// BlockRetention returns the number of recent blocks bc keeps the bodies and receipts of,
+// or 0 if it keeps the whole history.
+func (bc *BlockChain) BlockRetention() uint64 {
+	bc.pruneMu.Lock()
+	defer bc.pruneMu.Unlock()
+	return bc.retention
+}
+
+// pruneBlocks deletes the bodies and receipts of the canonical blocks which fell out of
+// the retention window since the last pruning. The genesis block is never pruned.
+func (bc *BlockChain) pruneBlocks() {
+	bc.pruneMu.Lock()
+	defer bc.pruneMu.Unlock()
+	if bc.retention == 0 || (bc.holdPruning != nil && bc.holdPruning()) {
+		return
+	}
+	head := bc.CurrentBlock().NumberU64()
+	if head <= bc.retention {
+		return
+	}
+	last := head - bc.retention
+	first := uint64(1)
+	if pruned := rawdb.ReadLastPrunedBlockNumber(bc.db); pruned != nil {
+		first = *pruned + 1
+	}
+	if first > last {
+		return
+	}
+
+	batch := bc.db.NewBatch()
+	for number := first; number <= last; number++ {
+		hash := rawdb.ReadCanonicalHash(bc.db, number)
+		if hash == (common.Hash{}) {
+			continue
+		}
+		rawdb.DeleteBody(batch, hash, number)
+		rawdb.DeleteReceipts(batch, hash, number)
+		bc.bodyCache.Remove(hash)
+		bc.bodyRLPCache.Remove(hash)
+		bc.receiptsCache.Remove(hash)
+		bc.blockCache.Remove(hash)
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				utils.GetLogInstance().Warn("Cannot prune blocks", "error", err)
+				return
+			}
+			rawdb.WriteLastPrunedBlockNumber(bc.db, number)
+			batch.Reset()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		utils.GetLogInstance().Warn("Cannot prune blocks", "error", err)
+		return
+	}
+	rawdb.WriteLastPrunedBlockNumber(bc.db, last)
+	utils.GetLogInstance().Debug("Pruned blocks", "from", first, "to", last)
+}
@@ -137,6 +137,10 @@ type BlockChain struct {
 	shardStateCache *lru.Cache
 	epochCache      *lru.Cache // Cache epoch number → first block number
 
+	pruneMu     sync.Mutex  // block pruning lock
+	retention   uint64      // number of recent blocks to keep the bodies and receipts of, 0 for all
+	holdPruning func() bool // tells whether pruning must wait
+
 	quit    chan struct{} // blockchain quit channel
 	running int32         // running must be called atomically
 	// procInterrupt must be atomically called
@@ -1096,6 +1100,7 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 				}
 			}
 		}
+		bc.pruneBlocks()
 	}
 	return n, err
 }
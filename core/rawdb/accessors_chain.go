@@ -129,6 +129,25 @@ func WriteFastTrieProgress(db DatabaseWriter, count uint64) {
 	}
 }
 
+// ReadLastPrunedBlockNumber retrieves the number of the last block whose body and
+// receipts were pruned, or nil if none was.
+func ReadLastPrunedBlockNumber(db DatabaseReader) *uint64 {
+	data, _ := db.Get(lastPrunedBlockKey)
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteLastPrunedBlockNumber stores the number of the last block whose body and
+// receipts were pruned.
+func WriteLastPrunedBlockNumber(db DatabaseWriter, number uint64) {
+	if err := db.Put(lastPrunedBlockKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store last pruned block number", "err", err)
+	}
+}
+
 // ReadHeaderRLP retrieves a block header in its raw RLP database encoding.
 func ReadHeaderRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
 	data, _ := db.Get(headerKey(number, hash))
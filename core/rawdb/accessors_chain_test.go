@@ -282,6 +282,19 @@ func TestHeadStorage(t *testing.T) {
 	}
 }
 
+// Tests that the last pruned block number can be stored and retrieved.
+func TestLastPrunedBlockNumberStorage(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+
+	if entry := ReadLastPrunedBlockNumber(db); entry != nil {
+		t.Fatalf("Non last pruned block entry returned: %v", *entry)
+	}
+	WriteLastPrunedBlockNumber(db, 42)
+	if entry := ReadLastPrunedBlockNumber(db); entry == nil || *entry != 42 {
+		t.Fatalf("Last pruned block number mismatch: have %v, want %v", entry, 42)
+	}
+}
+
 // Tests that receipts associated with a single block can be stored and retrieved.
 func TestBlockReceiptStorage(t *testing.T) {
 	db := ethdb.NewMemDatabase()
@@ -1,7 +1,12 @@
 package bls
 
 import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
 	"errors"
+	"runtime"
+	"sync"
 
 	"github.com/harmony-one/bls/ffi/go/bls"
 	"github.com/harmony-one/harmony/internal/ctxerror"
@@ -26,7 +31,20 @@ func BytesToBlsPublicKey(bytes []byte) (*bls.PublicKey, error) {
 }
 
 // AggregateSig aggregates all the BLS signature into a single multi-signature.
+// Large sets of signatures are aggregated in shards, on all cores.
 func AggregateSig(sigs []*bls.Sign) *bls.Sign {
+	numShards := shardCount(len(sigs))
+	if numShards == 1 {
+		return aggregateSig(sigs)
+	}
+	shardSigs := make([]*bls.Sign, numShards)
+	forEachShard(len(sigs), numShards, func(shard, start, end int) {
+		shardSigs[shard] = aggregateSig(sigs[start:end])
+	})
+	return aggregateSig(shardSigs)
+}
+
+func aggregateSig(sigs []*bls.Sign) *bls.Sign {
 	var aggregatedSig bls.Sign
 	for _, sig := range sigs {
 		aggregatedSig.Add(sig)
@@ -34,6 +52,44 @@ func AggregateSig(sigs []*bls.Sign) *bls.Sign {
 	return &aggregatedSig
 }
 
+func aggregatePubKey(pubKeys []*bls.PublicKey) *bls.PublicKey {
+	aggregatedPubKey := &bls.PublicKey{}
+	for _, pubKey := range pubKeys {
+		aggregatedPubKey.Add(pubKey)
+	}
+	return aggregatedPubKey
+}
+
+// minShardSize is the number of signatures below which adding them up on a single core
+// is faster than handing them to several.
+const minShardSize = 32
+
+// shardCount returns the number of shards to split n signatures into, at most one per core.
+func shardCount(n int) int {
+	numShards := n / minShardSize
+	if numShards > runtime.NumCPU() {
+		numShards = runtime.NumCPU()
+	}
+	if numShards < 1 {
+		numShards = 1
+	}
+	return numShards
+}
+
+// forEachShard calls fn concurrently for the bounds of each of the numShards consecutive
+// shards of n items, and returns once all the calls are done.
+func forEachShard(n, numShards int, fn func(shard, start, end int)) {
+	var wg sync.WaitGroup
+	for shard := 0; shard < numShards; shard++ {
+		wg.Add(1)
+		go func(shard int) {
+			defer wg.Done()
+			fn(shard, shard*n/numShards, (shard+1)*n/numShards)
+		}(shard)
+	}
+	wg.Wait()
+}
+
 // Mask represents a cosigning participation bitmask.
 type Mask struct {
 	Bitmap          []byte
@@ -193,6 +249,38 @@ func (m *Mask) CountTotal() int {
 	return len(m.publics)
 }
 
+// WeightEnabled returns the aggregate weight of the enabled nodes in the CoSi
+// participation Bitmap. The weights are keyed by the hex string of the public
+// key; a nil map gives every node a weight of one.
+func (m *Mask) WeightEnabled(weights map[string]uint64) uint64 {
+	total := uint64(0)
+	for i, key := range m.publics {
+		byt := i >> 3
+		msk := byte(1) << uint(i&7)
+		if (m.Bitmap[byt] & msk) != 0 {
+			total += keyWeight(weights, key)
+		}
+	}
+	return total
+}
+
+// WeightTotal returns the aggregate weight of all the nodes this CoSi instance
+// knows. See WeightEnabled for the meaning of weights.
+func (m *Mask) WeightTotal(weights map[string]uint64) uint64 {
+	total := uint64(0)
+	for _, key := range m.publics {
+		total += keyWeight(weights, key)
+	}
+	return total
+}
+
+func keyWeight(weights map[string]uint64, key *bls.PublicKey) uint64 {
+	if weights == nil {
+		return 1
+	}
+	return weights[hex.EncodeToString(key.Serialize())]
+}
+
 // AggregateMasks computes the bitwise OR of the two given participation masks.
 func AggregateMasks(a, b []byte) ([]byte, error) {
 	if len(a) != len(b) {
@@ -244,3 +332,126 @@ func NewThresholdPolicy(thold int) *ThresholdPolicy {
 func (p ThresholdPolicy) Check(m *Mask) bool {
 	return m.CountEnabled() >= p.thold
 }
+
+// WeightedThresholdPolicy requires the cosigners to hold more than two thirds
+// of the total weight (e.g. stake) to make a collective signature valid.
+// With equal weights it is the same as a ThresholdPolicy of 2f+1.
+type WeightedThresholdPolicy struct {
+	weights map[string]uint64
+}
+
+// NewWeightedThresholdPolicy returns a new WeightedThresholdPolicy with the
+// given weights keyed by the hex string of the public key. A nil map gives
+// every participant the same weight.
+func NewWeightedThresholdPolicy(weights map[string]uint64) *WeightedThresholdPolicy {
+	return &WeightedThresholdPolicy{weights: weights}
+}
+
+// Check verifies that the participants who contributed to a collective
+// signature hold more than two thirds of the total weight.
+func (p WeightedThresholdPolicy) Check(m *Mask) bool {
+	return m.WeightEnabled(p.weights)*3 > m.WeightTotal(p.weights)*2
+}
+
+// VerifyBatch verifies that each of sigs is the signature of the public key of the same
+// index on hash. The signatures are checked together with a single pairing check on their
+// aggregate, and the batch is bisected on failure to find the invalid ones, whose indexes
+// are returned. Large batches are aggregated, and bisected, in shards on all cores.
+// Each signature and its public key are weighted by a random factor before aggregating, so
+// that invalid signatures cannot cancel each other out, e.g. two signatures swapped.
+func VerifyBatch(pubKeys []*bls.PublicKey, sigs []*bls.Sign, hash []byte) ([]int, error) {
+	if len(pubKeys) != len(sigs) {
+		return nil, ctxerror.New("mismatching number of public keys and signatures",
+			"numPubKeys", len(pubKeys),
+			"numSigs", len(sigs))
+	}
+	weights := make([]byte, 8*len(sigs))
+	if _, err := rand.Read(weights); err != nil {
+		return nil, ctxerror.New("cannot draw the batch weights").WithCause(err)
+	}
+	numShards := shardCount(len(sigs))
+	weightedPubKeys := make([]*bls.PublicKey, len(pubKeys))
+	weightedSigs := make([]*bls.Sign, len(sigs))
+	forEachShard(len(sigs), numShards, func(shard, start, end int) {
+		for i := start; i < end; i++ {
+			// an odd weight is never zero
+			weight := binary.LittleEndian.Uint64(weights[8*i:]) | 1
+			weightedPubKeys[i] = mulPubKey(pubKeys[i], weight)
+			weightedSigs[i] = mulSig(sigs[i], weight)
+		}
+	})
+	pubKeys, sigs = weightedPubKeys, weightedSigs
+	if numShards == 1 {
+		return verifyBatch(pubKeys, sigs, hash, 0), nil
+	}
+
+	shardSigs := make([]*bls.Sign, numShards)
+	shardPubKeys := make([]*bls.PublicKey, numShards)
+	forEachShard(len(sigs), numShards, func(shard, start, end int) {
+		shardSigs[shard] = aggregateSig(sigs[start:end])
+		shardPubKeys[shard] = aggregatePubKey(pubKeys[start:end])
+	})
+	if aggregateSig(shardSigs).VerifyHash(aggregatePubKey(shardPubKeys), hash) {
+		return nil, nil
+	}
+	shardInvalid := make([][]int, numShards)
+	forEachShard(len(sigs), numShards, func(shard, start, end int) {
+		if shardSigs[shard].VerifyHash(shardPubKeys[shard], hash) {
+			return
+		}
+		mid := (start + end) / 2
+		shardInvalid[shard] = append(verifyBatch(pubKeys[start:mid], sigs[start:mid], hash, start),
+			verifyBatch(pubKeys[mid:end], sigs[mid:end], hash, mid)...)
+	})
+	var invalid []int
+	for _, indexes := range shardInvalid {
+		invalid = append(invalid, indexes...)
+	}
+	return invalid, nil
+}
+
+func verifyBatch(pubKeys []*bls.PublicKey, sigs []*bls.Sign, hash []byte, offset int) []int {
+	switch len(sigs) {
+	case 0:
+		return nil
+	case 1:
+		if sigs[0].VerifyHash(pubKeys[0], hash) {
+			return nil
+		}
+		return []int{offset}
+	}
+	if aggregateSig(sigs).VerifyHash(aggregatePubKey(pubKeys), hash) {
+		return nil
+	}
+	mid := len(sigs) / 2
+	return append(verifyBatch(pubKeys[:mid], sigs[:mid], hash, offset),
+		verifyBatch(pubKeys[mid:], sigs[mid:], hash, offset+mid)...)
+}
+
+// mulSig returns sig multiplied by k, with double-and-add.
+func mulSig(sig *bls.Sign, k uint64) *bls.Sign {
+	var product bls.Sign
+	power := *sig
+	for ; k > 0; k >>= 1 {
+		if k&1 == 1 {
+			product.Add(&power)
+		}
+		double := power
+		power.Add(&double)
+	}
+	return &product
+}
+
+// mulPubKey returns pubKey multiplied by k, with double-and-add.
+func mulPubKey(pubKey *bls.PublicKey, k uint64) *bls.PublicKey {
+	var product bls.PublicKey
+	power := *pubKey
+	for ; k > 0; k >>= 1 {
+		if k&1 == 1 {
+			product.Add(&power)
+		}
+		double := power
+		power.Add(&double)
+	}
+	return &product
+}
@@ -1,6 +1,8 @@
 package bls
 
 import (
+	"encoding/hex"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -244,3 +246,129 @@ func TestSetMask(test *testing.T) {
 		test.Error("Expected mismatching Bitmap lengths")
 	}
 }
+
+func TestWeightedThresholdPolicy(test *testing.T) {
+	pubKey1 := RandPrivateKey().GetPublicKey()
+	pubKey2 := RandPrivateKey().GetPublicKey()
+	pubKey3 := RandPrivateKey().GetPublicKey()
+	pubKey4 := RandPrivateKey().GetPublicKey()
+
+	mask, err := NewMask([]*bls.PublicKey{pubKey1, pubKey2, pubKey3, pubKey4}, nil)
+	if err != nil {
+		test.Errorf("Failed to create a new Mask: %s", err)
+	}
+	weights := map[string]uint64{
+		hex.EncodeToString(pubKey1.Serialize()): 70,
+		hex.EncodeToString(pubKey2.Serialize()): 10,
+		hex.EncodeToString(pubKey3.Serialize()): 10,
+		hex.EncodeToString(pubKey4.Serialize()): 10,
+	}
+	policy := *NewWeightedThresholdPolicy(weights)
+
+	// A single signer holding most of the stake meets the quorum.
+	mask.SetKey(pubKey1, true)
+	if mask.WeightEnabled(weights) != 70 {
+		test.Errorf("Weight of enabled nodes: %d, expected 70", mask.WeightEnabled(weights))
+	}
+	if !policy.Check(mask) {
+		test.Error("Minority of signers with the majority of stake did not meet the quorum")
+	}
+
+	// The majority of signers holding little stake does not.
+	mask.SetKey(pubKey1, false)
+	mask.SetKey(pubKey2, true)
+	mask.SetKey(pubKey3, true)
+	mask.SetKey(pubKey4, true)
+	if mask.CountEnabled() != 3 {
+		test.Errorf("Number of enabled nodes: %d, expected count = 3", mask.CountEnabled())
+	}
+	if policy.Check(mask) {
+		test.Error("Majority of signers with a minority of stake met the quorum")
+	}
+
+	// With equal weights the majority of signers meets the quorum again.
+	if !NewWeightedThresholdPolicy(nil).Check(mask) {
+		test.Error("3 of 4 equally weighted signers did not meet the quorum")
+	}
+	mask.SetKey(pubKey4, false)
+	if NewWeightedThresholdPolicy(nil).Check(mask) {
+		test.Error("2 of 4 equally weighted signers met the quorum")
+	}
+}
+
+func TestVerifyBatch(test *testing.T) {
+	hash := []byte("block hash")
+	pubKeys := []*bls.PublicKey{}
+	sigs := []*bls.Sign{}
+	for i := 0; i < 7; i++ {
+		priKey := RandPrivateKey()
+		pubKeys = append(pubKeys, priKey.GetPublicKey())
+		sigs = append(sigs, priKey.SignHash(hash))
+	}
+
+	invalid, err := VerifyBatch(pubKeys, sigs, hash)
+	if err != nil || len(invalid) != 0 {
+		test.Errorf("Valid batch rejected: %v %v", invalid, err)
+	}
+
+	sigs[2] = RandPrivateKey().SignHash(hash)
+	sigs[5] = RandPrivateKey().SignHash(hash)
+	invalid, err = VerifyBatch(pubKeys, sigs, hash)
+	if err != nil || len(invalid) != 2 || invalid[0] != 2 || invalid[1] != 5 {
+		test.Errorf("Expected signatures 2 and 5 to be invalid, got %v %v", invalid, err)
+	}
+
+	if _, err := VerifyBatch(pubKeys[:1], sigs, hash); err == nil {
+		test.Error("Expected an error for mismatching lengths")
+	}
+
+	// Swapped signatures add up to a valid aggregate, but neither is valid on its own.
+	sigs[0], sigs[1] = sigs[1], sigs[0]
+	invalid, err = VerifyBatch(pubKeys[:2], sigs[:2], hash)
+	if err != nil || !reflect.DeepEqual(invalid, []int{0, 1}) {
+		test.Errorf("Expected the swapped signatures to be invalid, got %v %v", invalid, err)
+	}
+}
+
+func TestMulSig(test *testing.T) {
+	hash := []byte("block hash")
+	priKey := RandPrivateKey()
+	sig := priKey.SignHash(hash)
+	tripled := aggregateSig([]*bls.Sign{sig, sig, sig})
+	if !mulSig(sig, 3).IsEqual(tripled) {
+		test.Error("Signature times 3 differs from the sum of 3 signatures")
+	}
+	pubKey := priKey.GetPublicKey()
+	if !mulSig(sig, 0x9d).VerifyHash(mulPubKey(pubKey, 0x9d), hash) {
+		test.Error("Weighted signature does not verify with the weighted public key")
+	}
+}
+
+func TestVerifyBatchSharded(test *testing.T) {
+	hash := []byte("block hash")
+	numSigs := 4*minShardSize + 3
+	pubKeys := []*bls.PublicKey{}
+	sigs := []*bls.Sign{}
+	for i := 0; i < numSigs; i++ {
+		priKey := RandPrivateKey()
+		pubKeys = append(pubKeys, priKey.GetPublicKey())
+		sigs = append(sigs, priKey.SignHash(hash))
+	}
+
+	if !AggregateSig(sigs).IsEqual(aggregateSig(sigs)) {
+		test.Error("Sharded aggregation differs from the sequential one")
+	}
+	invalid, err := VerifyBatch(pubKeys, sigs, hash)
+	if err != nil || len(invalid) != 0 {
+		test.Errorf("Valid batch rejected: %v %v", invalid, err)
+	}
+
+	expected := []int{0, minShardSize + 1, numSigs - 1}
+	for _, i := range expected {
+		sigs[i] = RandPrivateKey().SignHash(hash)
+	}
+	invalid, err = VerifyBatch(pubKeys, sigs, hash)
+	if err != nil || !reflect.DeepEqual(invalid, expected) {
+		test.Errorf("Expected signatures %v to be invalid, got %v %v", expected, invalid, err)
+	}
+}
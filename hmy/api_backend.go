@@ -16,6 +16,8 @@ import (
 	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/state"
 	"github.com/harmony-one/harmony/core/types"
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	"github.com/harmony-one/harmony/p2p"
 )
 
 // APIBackend An implementation of Backend. Full client.
@@ -101,6 +103,45 @@ func (b *APIBackend) CurrentBlock() *types.Block {
 	return types.NewBlockWithHeader(b.hmy.blockchain.CurrentHeader())
 }
 
+// BlockRetention ...
+func (b *APIBackend) BlockRetention() uint64 {
+	return b.hmy.blockchain.BlockRetention()
+}
+
+// PeerScores returns the reputation of the misbehaving peers, keyed by peer ID.
+func (b *APIBackend) PeerScores() map[string]p2p.PeerScore {
+	scores := make(map[string]p2p.PeerScore)
+	for id, score := range b.hmy.nodeAPI.PeerScores() {
+		scores[id.Pretty()] = score
+	}
+	return scores
+}
+
+// Reachability ...
+func (b *APIBackend) Reachability() p2p.Reachability {
+	return b.hmy.nodeAPI.Reachability()
+}
+
+// BandwidthStats ...
+func (b *APIBackend) BandwidthStats() p2p.BandwidthStats {
+	return b.hmy.nodeAPI.BandwidthStats()
+}
+
+// AccessRules ...
+func (b *APIBackend) AccessRules() p2p.AccessRules {
+	return b.hmy.nodeAPI.AccessRules()
+}
+
+// NodeStatus ...
+func (b *APIBackend) NodeStatus() nodeconfig.Status {
+	return b.hmy.nodeAPI.Status()
+}
+
+// SetAccessRules ...
+func (b *APIBackend) SetAccessRules(rules p2p.AccessRules) error {
+	return b.hmy.nodeAPI.SetAccessRules(rules)
+}
+
 // AccountManager ...
 func (b *APIBackend) AccountManager() *accounts.Manager {
 	return b.hmy.accountManager
@@ -11,6 +11,9 @@ import (
 	"github.com/harmony-one/harmony/accounts"
 	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/types"
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	"github.com/harmony-one/harmony/p2p"
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
 )
 
 // Harmony implements the Harmony full node service.
@@ -39,6 +42,12 @@ type NodeAPI interface {
 	AccountManager() *accounts.Manager
 	GetBalanceOfAddress(address common.Address) (*big.Int, error)
 	GetNonceOfAddress(address common.Address) uint64
+	PeerScores() map[libp2p_peer.ID]p2p.PeerScore
+	Reachability() p2p.Reachability
+	BandwidthStats() p2p.BandwidthStats
+	AccessRules() p2p.AccessRules
+	SetAccessRules(rules p2p.AccessRules) error
+	Status() nodeconfig.Status
 }
 
 // New creates a new Harmony object (including the
@@ -82,10 +82,38 @@ type ConfigType struct {
 	// Database directory
 	DBDir string
 
+	// NetworkID identifies the network of the node over RPC, see NetworkIDs
+	NetworkID uint64
+
 	SelfPeer p2p.Peer
 	Leader   p2p.Peer
 }
 
+// Version is the version of the node software, as reported over RPC.
+var Version = "Harmony"
+
+// NetworkIDs are the IDs of the known networks, as reported over RPC by net_version.
+// They are distinct from the IDs of the Ethereum networks, so that the eth tooling
+// does not mistake a Harmony network for one of them.
+var NetworkIDs = map[string]uint64{
+	"mainnet":  1666600000,
+	"testnet":  1666700000,
+	"localnet": 1666800000,
+}
+
+// Status is a snapshot of the state of a node, as reported over RPC.
+type Status struct {
+	Version     string `json:"version"`
+	NetworkID   uint64 `json:"networkID"`
+	ShardID     uint32 `json:"shardID"`
+	Role        string `json:"role"`
+	IsLeader    bool   `json:"isLeader"`
+	State       string `json:"state"`
+	BlockNumber uint64 `json:"blockNumber"`
+	BlockHash   string `json:"blockHash"`
+	PeerCount   int    `json:"peerCount"`
+}
+
 // configs is a list of node configuration.
 // It has at least one configuration.
 // The first one is the default, global node configuration
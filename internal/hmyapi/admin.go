@@ -0,0 +1,28 @@
+package hmyapi
+
+import (
+	"context"
+
+	"github.com/harmony-one/harmony/p2p"
+)
+
+// AdminAPI Internal JSON RPC changing the node at runtime, only served on localhost
+type AdminAPI struct {
+	b Backend
+}
+
+// NewAdminAPI Creates a new AdminAPI instance
+func NewAdminAPI(b Backend) *AdminAPI {
+	return &AdminAPI{b}
+}
+
+// SetAccessRules replaces the rules restricting the peers the node connects with, and
+// disconnects the peers they deny
+// Example usage:
+//  curl -H "Content-Type: application/json" -d '{"method":"admin_setAccessRules","params":[{"allowNets":["10.0.0.0/8"],"denyPeers":["QmPeer..."]}],"id":1}' http://127.0.0.1:9900
+func (api *AdminAPI) SetAccessRules(ctx context.Context, rules p2p.AccessRules) (p2p.AccessRules, error) {
+	if err := api.b.SetAccessRules(rules); err != nil {
+		return p2p.AccessRules{}, err
+	}
+	return api.b.AccessRules(), nil
+}
@@ -13,6 +13,8 @@ import (
 	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/state"
 	"github.com/harmony-one/harmony/core/types"
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	"github.com/harmony-one/harmony/p2p"
 )
 
 // Backend interface provides the common API services (that are provided by
@@ -42,6 +44,7 @@ type Backend interface {
 	SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription
 	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
 	SubscribeChainSideEvent(ch chan<- core.ChainSideEvent) event.Subscription
+	BlockRetention() uint64
 
 	// TxPool API
 	SendTx(ctx context.Context, signedTx *types.Transaction) error
@@ -57,38 +60,49 @@ type Backend interface {
 	CurrentBlock() *types.Block
 	// Get balance
 	GetBalance(address common.Address) (*hexutil.Big, error)
+	// Reputation of the misbehaving peers, keyed by peer ID
+	PeerScores() map[string]p2p.PeerScore
+	// How the peers can reach the node
+	Reachability() p2p.Reachability
+	// Traffic of the node
+	BandwidthStats() p2p.BandwidthStats
+	// Peers the node connects with
+	AccessRules() p2p.AccessRules
+	SetAccessRules(rules p2p.AccessRules) error
+	// State of the node
+	NodeStatus() nodeconfig.Status
 }
 
-// GetAPIs returns all the APIs.
+// GetAPIs returns all the APIs. The public ones are also served in the eth namespace,
+// under the method names of eth, so that the existing eth tooling can connect.
 func GetAPIs(b Backend) []rpc.API {
 	nonceLock := new(AddrLocker)
-	return []rpc.API{
-		{
-			Namespace: "hmy",
-			Version:   "1.0",
-			Service:   NewPublicHarmonyAPI(b),
-			Public:    true,
-		},
-		{
-			Namespace: "hmy",
-			Version:   "1.0",
-			Service:   NewPublicBlockChainAPI(b),
-			Public:    true,
-		}, {
-			Namespace: "hmy",
-			Version:   "1.0",
-			Service:   NewPublicTransactionPoolAPI(b, nonceLock),
-			Public:    true,
-		}, {
-			Namespace: "hmy",
-			Version:   "1.0",
-			Service:   NewPublicAccountAPI(b.AccountManager()),
-			Public:    true,
-		}, {
-			Namespace: "hmy",
-			Version:   "1.0",
-			Service:   NewDebugAPI(b),
-			Public:    true, // FIXME: change to false once IPC implemented
-		},
+	public := []interface{}{
+		NewPublicHarmonyAPI(b),
+		NewPublicBlockChainAPI(b),
+		NewPublicTransactionPoolAPI(b, nonceLock),
+		NewPublicAccountAPI(b.AccountManager()),
 	}
+	var apis []rpc.API
+	for _, namespace := range []string{"hmy", "eth"} {
+		for _, service := range public {
+			apis = append(apis, rpc.API{
+				Namespace: namespace,
+				Version:   "1.0",
+				Service:   service,
+				Public:    true,
+			})
+		}
+	}
+	return append(apis, rpc.API{
+		Namespace: "hmy",
+		Version:   "1.0",
+		Service:   NewDebugAPI(b),
+		Public:    true, // FIXME: change to false once IPC implemented
+	}, rpc.API{
+		Namespace: "admin",
+		Version:   "1.0",
+		Service:   NewAdminAPI(b),
+		Public:    false,
+	})
 }
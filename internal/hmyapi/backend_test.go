@@ -0,0 +1,42 @@
+package hmyapi
+
+import (
+	"testing"
+
+	"github.com/harmony-one/harmony/accounts"
+)
+
+// testBackend is a Backend whose methods are not called while the APIs are created.
+type testBackend struct {
+	Backend
+}
+
+func (testBackend) AccountManager() *accounts.Manager { return nil }
+
+func TestGetAPIs(t *testing.T) {
+	public := map[string]int{}
+	private := map[string]int{}
+	for _, api := range GetAPIs(testBackend{}) {
+		if api.Public {
+			public[api.Namespace]++
+		} else {
+			private[api.Namespace]++
+		}
+	}
+	// The public services are served in both the hmy and eth namespaces, with the debug
+	// API in hmy only.
+	if public["hmy"] != public["eth"]+1 || public["eth"] == 0 {
+		t.Errorf("public namespaces: got %v, want the eth services in hmy too", public)
+	}
+	// The admin API is never public, as it changes the peers of the node.
+	if len(private) != 1 || private["admin"] != 1 || public["admin"] != 0 {
+		t.Errorf("private namespaces: got %v, want admin only", private)
+	}
+}
+
+func TestNetworkID(t *testing.T) {
+	api := NewPublicNetAPI(nil, 1666600000)
+	if version := api.Version(); version != "1666600000" {
+		t.Errorf("Version() = %s, want 1666600000", version)
+	}
+}
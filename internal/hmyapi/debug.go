@@ -6,6 +6,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/harmony-one/harmony/p2p"
 )
 
 // DebugAPI Internal JSON RPC for debugging purpose
@@ -30,3 +31,33 @@ func (*DebugAPI) SetLogVerbosity(ctx context.Context, level int) (map[string]int
 	utils.SetLogVerbosity(verbosity)
 	return map[string]interface{}{"verbosity": verbosity.String()}, nil
 }
+
+// PeerScores returns the reputation of the peers which misbehaved, keyed by peer ID
+// Example usage:
+//  curl -H "Content-Type: application/json" -d '{"method":"hmy_peerScores","params":[],"id":1}' http://localhost:9123
+func (api *DebugAPI) PeerScores(ctx context.Context) map[string]p2p.PeerScore {
+	return api.b.PeerScores()
+}
+
+// Reachability tells whether the peers can reach the node on a public address, through
+// a relay, or not at all
+// Example usage:
+//  curl -H "Content-Type: application/json" -d '{"method":"hmy_reachability","params":[],"id":1}' http://localhost:9123
+func (api *DebugAPI) Reachability(ctx context.Context) p2p.Reachability {
+	return api.b.Reachability()
+}
+
+// BandwidthStats returns the p2p traffic of the node in total, per topic, per kind of
+// message and per peer
+// Example usage:
+//  curl -H "Content-Type: application/json" -d '{"method":"hmy_bandwidthStats","params":[],"id":1}' http://localhost:9123
+func (api *DebugAPI) BandwidthStats(ctx context.Context) p2p.BandwidthStats {
+	return api.b.BandwidthStats()
+}
+
+// AccessRules returns the rules restricting the peers the node connects with
+// Example usage:
+//  curl -H "Content-Type: application/json" -d '{"method":"hmy_accessRules","params":[],"id":1}' http://localhost:9123
+func (api *DebugAPI) AccessRules(ctx context.Context) p2p.AccessRules {
+	return api.b.AccessRules()
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/harmony-one/harmony/api/proto"
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
 )
 
 // PublicHarmonyAPI provides an API to access Harmony related information.
@@ -36,6 +37,17 @@ func (s *PublicHarmonyAPI) Syncing() (interface{}, error) {
 	return false, nil
 }
 
+// BlockRetention returns the number of recent blocks this node keeps the bodies and receipts of,
+// or 0 if it keeps the whole history. Older blocks are served with their headers only.
+func (s *PublicHarmonyAPI) BlockRetention() hexutil.Uint64 {
+	return hexutil.Uint64(s.b.BlockRetention())
+}
+
+// NodeStatus returns the version, shard, role and chain head of the node, and its number of peers.
+func (s *PublicHarmonyAPI) NodeStatus() nodeconfig.Status {
+	return s.b.NodeStatus()
+}
+
 // GasPrice returns a suggestion for a gas price.
 func (s *PublicHarmonyAPI) GasPrice(ctx context.Context) (*hexutil.Big, error) {
 	// TODO(ricl): add SuggestPrice API
@@ -23,7 +23,12 @@ func (s *PublicNetAPI) PeerCount() hexutil.Uint {
 	return hexutil.Uint(s.net.GetPeerCount())
 }
 
-// NetworkID ...
+// Version returns the ID of the network, as net_version of eth
+func (s *PublicNetAPI) Version() string {
+	return s.NetworkID()
+}
+
+// NetworkID returns the ID of the network of the node, see nodeconfig.NetworkIDs
 func (s *PublicNetAPI) NetworkID() string {
-	return fmt.Sprintf("%d", 1) // TODO(ricl): we should add support for network id (https://github.com/ethereum/wiki/wiki/JSON-RPC#net_version)
+	return fmt.Sprintf("%d", s.networkID)
 }
@@ -0,0 +1,26 @@
+package hmyapi
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+)
+
+// PublicWeb3API offers the web3 RPC methods the eth tooling expects
+type PublicWeb3API struct{}
+
+// NewPublicWeb3API creates a new web3 API instance.
+func NewPublicWeb3API() *PublicWeb3API {
+	return &PublicWeb3API{}
+}
+
+// ClientVersion returns the version of the node software
+func (s *PublicWeb3API) ClientVersion() string {
+	return nodeconfig.Version
+}
+
+// Sha3 returns the Keccak-256 hash of input
+func (s *PublicWeb3API) Sha3(input hexutil.Bytes) hexutil.Bytes {
+	return crypto.Keccak256(input)
+}
@@ -1,6 +1,7 @@
 package node
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"encoding/hex"
 	"fmt"
@@ -31,6 +32,7 @@ import (
 	"github.com/harmony-one/harmony/internal/utils"
 	"github.com/harmony-one/harmony/node/worker"
 	"github.com/harmony-one/harmony/p2p"
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
 )
 
 // State is a state of a node.
@@ -112,15 +114,27 @@ type Node struct {
 	Worker       *worker.Worker
 	BeaconWorker *worker.Worker // worker for beacon chain
 
+	// Whether ProposeBlock has proposed a block already, see FirstTimeThreshold
+	proposedFirstBlock bool
+	// How long after its last block the leader proposes a block without enough transactions,
+	// possibly empty, so that the chain height keeps proving the liveness of an idle shard.
+	// 0 waits for transactions.
+	EmptyBlockPeriod time.Duration
+	lastProposedTime time.Time
+
 	// Syncing component.
 	syncID                 [SyncIDLength]byte // a unique ID for the node during the state syncing process with peers
 	downloaderServer       *downloader.Server
 	stateSync              *syncing.StateSync
+	SyncMode               syncing.SyncMode // what the shard state sync downloads
 	beaconSync             *syncing.StateSync
 	peerRegistrationRecord map[string]*syncConfig // record registration time (unixtime) of peers begin in syncing
+	lastSyncServed         int64                  // time (unixnano) the node last served blocks or headers to a syncing peer
 
 	// The p2p host used to send/receive p2p messages
 	host p2p.Host
+	// Scores the peers on their messages, throttling and banning the misbehaving ones
+	Reputation *p2p.Reputation
 
 	// Service manager.
 	serviceManager *service.Manager
@@ -152,15 +166,18 @@ type Node struct {
 	// For puzzle contracts
 	AddressNonce sync.Map
 
-	// Shard group Message Receiver
-	shardGroupReceiver p2p.GroupReceiver
+	// Subscriptions to the shard group, the global group communicating with the beacon
+	// chain or for cross-shard TX, and the client group to handle light client messages
+	groupManager *p2p.GroupManager
 
-	// Global group Message Receiver, communicate with beacon chain, or cross-shard TX
-	globalGroupReceiver p2p.GroupReceiver
+	// Queues the received messages by priority, so that consensus goes ahead of bulk traffic
+	dispatcher *p2p.PriorityDispatcher
 
-	// Client Message Receiver to handle light client messages
-	// Beacon leader needs to use this receiver to talk to new node
-	clientReceiver p2p.GroupReceiver
+	// Receiver of the messages sent to this node only, such as the votes to the leader
+	directReceiver p2p.GroupReceiver
+	// Stops receiving the direct messages, see Stop
+	stopReceiving context.CancelFunc
+	receiveCtx    context.Context
 
 	// Duplicated Ping Message Received
 	duplicatedPing sync.Map
@@ -231,9 +248,17 @@ func (node *Node) AddPendingTransaction(newTx *types.Transaction) {
 
 // Take out a subset of valid transactions from the pending transaction list
 // Note the pending transaction list will then contain the rest of the txs
+// The block limits of consensus bound the selection further, leaving BlockOverheadBytes for the rest of the block.
 func (node *Node) getTransactionsForNewBlock(maxNumTxs int) types.Transactions {
+	if node.Consensus.MaxBlockTxs > 0 && node.Consensus.MaxBlockTxs < maxNumTxs {
+		maxNumTxs = node.Consensus.MaxBlockTxs
+	}
+	maxTotalTxBytes := 0
+	if node.Consensus.MaxBlockBytes > 0 {
+		maxTotalTxBytes = node.Consensus.MaxBlockBytes - BlockOverheadBytes
+	}
 	node.pendingTxMutex.Lock()
-	selected, unselected, invalid := node.Worker.SelectTransactionsForNewBlock(node.pendingTransactions, maxNumTxs)
+	selected, unselected, invalid := node.Worker.SelectTransactionsForNewBlock(node.pendingTransactions, maxNumTxs, node.Consensus.MaxTxBytes, maxTotalTxBytes)
 
 	node.pendingTransactions = unselected
 	utils.GetLogInstance().Debug("Selecting Transactions", "remainPending", len(node.pendingTransactions), "selected", len(selected), "invalidDiscarded", len(invalid))
@@ -268,15 +293,26 @@ func (node *Node) GetSyncID() [SyncIDLength]byte {
 	return node.syncID
 }
 
+// GetConsensusStatus returns the status of the current consensus round
+func (node *Node) GetConsensusStatus() interface{} {
+	return node.Consensus.RoundStatus()
+}
+
 // New creates a new node.
 func New(host p2p.Host, consensusObj *consensus.Consensus, chainDBFactory shardchain.DBFactory, isArchival bool) *Node {
 	var err error
 
 	node := Node{}
 	copy(node.syncID[:], GenerateRandomString(SyncIDLength))
+	node.EmptyBlockPeriod = BlockPeriod
+	node.Reputation = p2p.NewReputation()
 	if host != nil {
 		node.host = host
 		node.SelfPeer = host.GetSelfPeer()
+		node.Reputation.OnBan = node.disconnectPeer
+		node.dispatcher = p2p.NewPriorityDispatcher(MessagePriority)
+		node.groupManager = p2p.NewGroupManager(host, node.handleGroupMessage)
+		node.groupManager.Dispatcher = node.dispatcher
 	}
 
 	// Create test keys.  Genesis will later need this.
@@ -340,16 +376,9 @@ func New(host p2p.Host, consensusObj *consensus.Consensus, chainDBFactory shardc
 		node.State = NodeInit
 	}
 
-	// start the goroutine to receive client message
-	// client messages are sent by clients, like txgen, wallet
-	go node.ReceiveClientGroupMessage()
-
-	// start the goroutine to receive group message
-	go node.ReceiveGroupMessage()
-
-	// start the goroutine to receive global message, used for cross-shard TX
-	// FIXME (leo): we use beacon client topic as the global topic for now
-	go node.ReceiveGlobalMessage()
+	// start the goroutine to receive the messages sent to this node only
+	node.receiveCtx, node.stopReceiving = context.WithCancel(context.Background())
+	go node.ReceiveDirectMessage()
 
 	// Setup initial state of syncing.
 	node.peerRegistrationRecord = make(map[string]*syncConfig)
@@ -464,22 +493,22 @@ func (node *Node) initNodeConfiguration() (service.NodeConfig, chan p2p.Peer) {
 		nodeConfig.Actions[node.NodeConfig.GetShardGroupID()] = p2p.ActionStart
 	}
 
-	var err error
-	node.shardGroupReceiver, err = node.host.GroupReceiver(node.NodeConfig.GetShardGroupID())
-	if err != nil {
-		utils.GetLogInstance().Error("Failed to create shard receiver", "msg", err)
+	if err := node.groupManager.Join(node.NodeConfig.GetShardGroupID()); err != nil {
+		utils.GetLogInstance().Error("Failed to join shard group", "msg", err)
 	}
 
-	node.globalGroupReceiver, err = node.host.GroupReceiver(p2p.GroupIDBeaconClient)
-	if err != nil {
-		utils.GetLogInstance().Error("Failed to create global receiver", "msg", err)
+	// client messages are sent by clients, like txgen, wallet
+	if err := node.groupManager.Join(node.NodeConfig.GetClientGroupID()); err != nil {
+		utils.GetLogInstance().Error("Failed to join client group", "msg", err)
 	}
 
-	node.clientReceiver, err = node.host.GroupReceiver(node.NodeConfig.GetClientGroupID())
-	if err != nil {
-		utils.GetLogInstance().Error("Failed to create client receiver", "msg", err)
+	// FIXME (leo): we use beacon client topic as the global topic for now
+	if err := node.groupManager.Join(p2p.GroupIDBeaconClient); err != nil {
+		utils.GetLogInstance().Error("Failed to join global group", "msg", err)
 	}
 
+	node.directReceiver = node.host.DirectReceiver()
+
 	return nodeConfig, chanPeer
 }
 
@@ -487,3 +516,97 @@ func (node *Node) initNodeConfiguration() (service.NodeConfig, chan p2p.Peer) {
 func (node *Node) AccountManager() *accounts.Manager {
 	return node.accountManager
 }
+
+// PeerScores returns the reputation of the peers which misbehaved.
+func (node *Node) PeerScores() map[libp2p_peer.ID]p2p.PeerScore {
+	return node.Reputation.Scores()
+}
+
+// StartConnManager keeps the connections of the node within the limits of config, the
+// peers of the worst reputation being pruned first.
+func (node *Node) StartConnManager(config p2p.ConnManagerConfig) {
+	manager := p2p.NewConnManager(node.host.GetP2PHost(), config)
+	manager.Roles = node.peerRoles
+	manager.Score = node.Reputation.Score
+	manager.Start()
+}
+
+// peerRoles returns the roles of the peers of the shard, the beacon chain and the sync.
+func (node *Node) peerRoles() map[libp2p_peer.ID]p2p.PeerRole {
+	roles := make(map[libp2p_peer.ID]p2p.PeerRole)
+	for _, stateSync := range []*syncing.StateSync{node.stateSync, node.beaconSync} {
+		if stateSync == nil {
+			continue
+		}
+		for _, id := range stateSync.PeerIDs() {
+			roles[id] = p2p.RoleSync
+		}
+	}
+	if host, ok := node.host.(interface {
+		GroupPeers(group p2p.GroupID) []libp2p_peer.ID
+	}); ok {
+		for _, id := range host.GroupPeers(p2p.GroupIDBeacon) {
+			roles[id] = p2p.RoleBeacon
+		}
+		// The shard role goes first, the beacon shard being the shard of the beacon nodes.
+		for _, id := range host.GroupPeers(node.NodeConfig.GetShardGroupID()) {
+			roles[id] = p2p.RoleShard
+		}
+	}
+	return roles
+}
+
+// BandwidthStats returns the traffic of the host of the node.
+func (node *Node) BandwidthStats() p2p.BandwidthStats {
+	if host, ok := node.host.(interface{ BandwidthStats() p2p.BandwidthStats }); ok {
+		return host.BandwidthStats()
+	}
+	return p2p.BandwidthStats{}
+}
+
+// Reachability tells how the peers can reach the host of the node.
+func (node *Node) Reachability() p2p.Reachability {
+	if host, ok := node.host.(interface{ Reachability() p2p.Reachability }); ok {
+		return host.Reachability()
+	}
+	return p2p.Reachability{Status: p2p.ReachabilityPrivate}
+}
+
+// accessControlledHost is a host restricting the peers it connects with.
+type accessControlledHost interface {
+	AccessRules() p2p.AccessRules
+	SetAccessRules(rules p2p.AccessRules) error
+}
+
+// AccessRules returns the rules restricting the peers of the host of the node.
+func (node *Node) AccessRules() p2p.AccessRules {
+	if host, ok := node.host.(accessControlledHost); ok {
+		return host.AccessRules()
+	}
+	return p2p.AccessRules{}
+}
+
+// SetAccessRules replaces the rules restricting the peers of the host of the node.
+func (node *Node) SetAccessRules(rules p2p.AccessRules) error {
+	host, ok := node.host.(accessControlledHost)
+	if !ok {
+		return ctxerror.New("host does not support access rules")
+	}
+	return host.SetAccessRules(rules)
+}
+
+// Status returns a snapshot of the state of the node.
+func (node *Node) Status() nodeconfig.Status {
+	block := node.Blockchain().CurrentBlock()
+	return nodeconfig.Status{
+		Version:     nodeconfig.Version,
+		NetworkID:   node.NodeConfig.NetworkID,
+		ShardID:     node.NodeConfig.ShardID,
+		Role:        node.NodeConfig.Role().String(),
+		IsLeader:    node.NodeConfig.IsLeader(),
+		State:       node.State.String(),
+		BlockNumber: block.NumberU64(),
+		BlockHash:   block.Hash().Hex(),
+		PeerCount:   node.host.GetPeerCount(),
+	}
+}
@@ -2,7 +2,6 @@ package node
 
 import (
 	"bytes"
-	"context"
 	"encoding/hex"
 	"errors"
 	"math"
@@ -34,68 +33,155 @@ import (
 	"github.com/harmony-one/harmony/internal/utils"
 	"github.com/harmony-one/harmony/p2p"
 	"github.com/harmony-one/harmony/p2p/host"
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
 )
 
 const (
 	// MaxNumberOfTransactionsPerBlock is the max number of transaction per a block.
 	MaxNumberOfTransactionsPerBlock = 8000
 	consensusTimeout                = 30 * time.Second
+
+	// BlockOverheadBytes is the room left in a new block for its header, signatures and shard state.
+	BlockOverheadBytes = 64 * 1024
 )
 
-// ReceiveGlobalMessage use libp2p pubsub mechanism to receive global broadcast messages
-func (node *Node) ReceiveGlobalMessage() {
-	ctx := context.Background()
-	for {
-		if node.globalGroupReceiver == nil {
+// handleGroupMessage handles a message received from a group the node is a member of
+func (node *Node) handleGroupMessage(group p2p.GroupID, msg []byte, sender libp2p_peer.ID) {
+	if sender == node.host.GetID() {
+		return
+	}
+	if content, ok := node.acceptMessage(msg, sender); ok {
+		node.messageHandler(content, string(sender))
+	}
+}
+
+// ReceiveDirectMessage receives the messages sent to this node only, over direct streams,
+// until the node is stopped.
+func (node *Node) ReceiveDirectMessage() {
+	ctx := node.receiveCtx
+	for ctx.Err() == nil {
+		if node.directReceiver == nil {
 			time.Sleep(100 * time.Millisecond)
 			continue
 		}
-		msg, sender, err := node.globalGroupReceiver.Receive(ctx)
-		if sender != node.host.GetID() {
-			//utils.GetLogInstance().Info("[PUBSUB]", "received global msg", len(msg), "sender", sender)
-			if err == nil {
-				// skip the first 5 bytes, 1 byte is p2p type, 4 bytes are message size
-				go node.messageHandler(msg[5:], string(sender))
+		msg, sender, err := node.directReceiver.Receive(ctx)
+		if err != nil {
+			continue
+		}
+		if content, ok := node.acceptMessage(msg, sender); ok {
+			handle := func() { node.messageHandler(content, string(sender)) }
+			if node.dispatcher == nil || !node.dispatcher.Dispatch(msg, handle) {
+				go handle()
 			}
 		}
 	}
 }
 
-// ReceiveGroupMessage use libp2p pubsub mechanism to receive broadcast messages
-func (node *Node) ReceiveGroupMessage() {
-	ctx := context.Background()
-	for {
-		if node.shardGroupReceiver == nil {
-			time.Sleep(100 * time.Millisecond)
-			continue
+// acceptMessage returns the content of msg from sender to handle, if any, dropping the
+// messages of banned peers, the messages over the rate of the sender and the malformed ones.
+func (node *Node) acceptMessage(msg []byte, sender libp2p_peer.ID) ([]byte, bool) {
+	if !node.Reputation.AllowMessage(sender) {
+		return nil, false
+	}
+	content, err := host.P2pMessageContent(msg)
+	if err != nil {
+		node.Reputation.Penalize(sender, p2p.OffenseMalformedMessage)
+		return nil, false
+	}
+	return content, true
+}
+
+// names of the message categories in the message kinds
+var messageCategoryNames = map[proto.MessageCategory]string{
+	proto.Consensus: "consensus",
+	proto.Node:      "node",
+	proto.Client:    "client",
+	proto.DRand:     "drand",
+	proto.Staking:   "staking",
+}
+
+// MessageKind returns the kind of a message received from a group for rate limiting, as the
+// name of its category and its type number, e.g. "node/0" for transactions.
+func MessageKind(msg []byte) p2p.MessageKind {
+	content, err := host.P2pMessageRawContent(msg)
+	if err != nil {
+		return "malformed"
+	}
+	category, err := proto.GetMessageCategory(content)
+	if err != nil {
+		return "malformed"
+	}
+	name, ok := messageCategoryNames[category]
+	if !ok {
+		name = "unknown"
+	}
+	msgType, err := proto.GetMessageType(content)
+	if err != nil {
+		return p2p.MessageKind(name)
+	}
+	return p2p.MessageKind(name + "/" + strconv.Itoa(int(msgType)))
+}
+
+// MessagePriority returns the lane a message received from a group waits in before it is
+// handled: the consensus rounds first, then the other messages, then the bulk traffic of
+// transactions, blocks and block responses.
+func MessagePriority(msg []byte) p2p.Priority {
+	content, err := host.P2pMessageContentHead(msg)
+	if err != nil {
+		return p2p.PriorityLow
+	}
+	category, err := proto.GetMessageCategory(content)
+	if err != nil {
+		return p2p.PriorityLow
+	}
+	switch category {
+	case proto.Consensus:
+		payload, _ := proto.GetConsensusMessagePayload(content)
+		switch consensusMessageType(payload) {
+		case message.MessageType_BLOCK_REQUEST, message.MessageType_BLOCK_RESPONSE:
+			return p2p.PriorityLow
 		}
-		msg, sender, err := node.shardGroupReceiver.Receive(ctx)
-		if sender != node.host.GetID() {
-			//utils.GetLogInstance().Info("[PUBSUB]", "received group msg", len(msg), "sender", sender)
-			if err == nil {
-				// skip the first 5 bytes, 1 byte is p2p type, 4 bytes are message size
-				go node.messageHandler(msg[5:], string(sender))
-			}
+		return p2p.PriorityHigh
+	case proto.Node:
+		msgType, err := proto.GetMessageType(content)
+		if err != nil {
+			return p2p.PriorityLow
+		}
+		switch proto_node.MessageType(msgType) {
+		case proto_node.Transaction, proto_node.Block:
+			return p2p.PriorityLow
 		}
 	}
+	return p2p.PriorityNormal
 }
 
-// ReceiveClientGroupMessage use libp2p pubsub mechanism to receive broadcast messages for client
-func (node *Node) ReceiveClientGroupMessage() {
-	ctx := context.Background()
-	for {
-		if node.clientReceiver == nil {
-			// check less frequent on client messages
-			time.Sleep(100 * time.Millisecond)
-			continue
+// consensusMessageType reads the type of a marshaled consensus message without unmarshaling
+// it, as the type is marshaled ahead of the payload.
+func consensusMessageType(payload []byte) message.MessageType {
+	for len(payload) > 0 {
+		key, n := pb.DecodeVarint(payload)
+		if n == 0 || key&7 != 0 || key>>3 > 2 {
+			// not a varint field, so past the type, which is then the default
+			break
 		}
-		msg, sender, err := node.clientReceiver.Receive(ctx)
-		if sender != node.host.GetID() {
-			// utils.GetLogInstance().Info("[CLIENT]", "received group msg", len(msg), "sender", sender, "error", err)
-			if err == nil {
-				// skip the first 5 bytes, 1 byte is p2p type, 4 bytes are message size
-				go node.messageHandler(msg[5:], string(sender))
-			}
+		value, m := pb.DecodeVarint(payload[n:])
+		if m == 0 {
+			break
+		}
+		if key>>3 == 2 {
+			return message.MessageType(value)
+		}
+		payload = payload[n+m:]
+	}
+	return message.MessageType(0)
+}
+
+// disconnectPeer closes the connections to a banned peer.
+func (node *Node) disconnectPeer(id libp2p_peer.ID) {
+	utils.GetLogInstance().Warn("Banning peer", "peer", id.Pretty())
+	if p2pHost := node.host.GetP2PHost(); p2pHost != nil {
+		if err := p2pHost.Network().ClosePeer(id); err != nil {
+			utils.GetLogInstance().Warn("Cannot disconnect banned peer", "peer", id.Pretty(), "error", err)
 		}
 	}
 }
@@ -105,24 +191,32 @@ func (node *Node) messageHandler(content []byte, sender string) {
 	msgCategory, err := proto.GetMessageCategory(content)
 	if err != nil {
 		utils.GetLogInstance().Error("Read node type failed", "err", err, "node", node)
+		node.Reputation.Penalize(libp2p_peer.ID(sender), p2p.OffenseMalformedMessage)
 		return
 	}
 
 	msgType, err := proto.GetMessageType(content)
 	if err != nil {
 		utils.GetLogInstance().Error("Read action type failed", "err", err, "node", node)
+		node.Reputation.Penalize(libp2p_peer.ID(sender), p2p.OffenseMalformedMessage)
 		return
 	}
 
 	msgPayload, err := proto.GetMessagePayload(content)
 	if err != nil {
 		utils.GetLogInstance().Error("Read message payload failed", "err", err, "node", node)
+		node.Reputation.Penalize(libp2p_peer.ID(sender), p2p.OffenseMalformedMessage)
 		return
 	}
 
 	switch msgCategory {
 	case proto.Consensus:
 		msgPayload, _ := proto.GetConsensusMessagePayload(content)
+		if _, err := node.Consensus.ValidateMessage(msgPayload); err != nil {
+			node.Reputation.Penalize(libp2p_peer.ID(sender), p2p.OffenseInvalidConsensusMessage)
+		} else {
+			node.Consensus.LearnLeaderPeer(msgPayload, libp2p_peer.ID(sender))
+		}
 		node.ConsensusMessageHandler(msgPayload)
 	case proto.DRand:
 		msgPayload, _ := proto.GetDRandMessagePayload(content)
@@ -157,6 +251,7 @@ func (node *Node) messageHandler(content []byte, sender string) {
 				err := rlp.DecodeBytes(msgPayload[1:], &blocks)
 				if err != nil {
 					utils.GetLogInstance().Error("block sync", "error", err)
+					node.Reputation.Penalize(libp2p_peer.ID(sender), p2p.OffenseMalformedMessage)
 				} else {
 					// for non-beaconchain node, subscribe to beacon block broadcast
 					role := node.NodeConfig.Role()
@@ -245,7 +340,7 @@ func (node *Node) transactionMessageHandler(msgPayload []byte) {
 func (node *Node) BroadcastNewBlock(newBlock *types.Block) {
 	if node.ClientPeer != nil {
 		utils.GetLogInstance().Debug("Sending new block to client", "client", node.ClientPeer)
-		node.host.SendMessageToGroups([]p2p.GroupID{node.NodeConfig.GetClientGroupID()}, host.ConstructP2pMessage(byte(0), proto_node.ConstructBlocksSyncMessage([]*types.Block{newBlock})))
+		node.host.SendMessageToGroups([]p2p.GroupID{node.NodeConfig.GetClientGroupID()}, host.ConstructP2pMessage(proto_node.ConstructBlocksSyncMessage([]*types.Block{newBlock})))
 	}
 }
 
@@ -448,7 +543,7 @@ func (node *Node) broadcastEpochShardState(newBlock *types.Block) error {
 	)
 	return node.host.SendMessageToGroups(
 		[]p2p.GroupID{node.NodeConfig.GetClientGroupID()},
-		host.ConstructP2pMessage(byte(0), epochShardStateMessage))
+		host.ConstructP2pMessage(epochShardStateMessage))
 }
 
 // AddNewBlock is usedd to add new block into the blockchain.
@@ -538,7 +633,7 @@ func (node *Node) SendPongMessage() {
 				if !sentMessage && numPeersNow >= node.Consensus.MinPeers {
 					pong := proto_discovery.NewPongMessage(peers, node.Consensus.PublicKeys, node.Consensus.GetLeaderPubKey(), node.Consensus.ShardID)
 					buffer := pong.ConstructPongMessage()
-					err := node.host.SendMessageToGroups([]p2p.GroupID{node.NodeConfig.GetShardGroupID()}, host.ConstructP2pMessage(byte(0), buffer))
+					err := node.host.SendMessageToGroups([]p2p.GroupID{node.NodeConfig.GetShardGroupID()}, host.ConstructP2pMessage(buffer))
 					if err != nil {
 						utils.GetLogInstance().Error("[PONG] failed to send pong message", "group", node.NodeConfig.GetShardGroupID())
 						continue
@@ -566,7 +661,7 @@ func (node *Node) SendPongMessage() {
 			peers := node.Consensus.GetValidatorPeers()
 			pong := proto_discovery.NewPongMessage(peers, node.Consensus.PublicKeys, node.Consensus.GetLeaderPubKey(), node.Consensus.ShardID)
 			buffer := pong.ConstructPongMessage()
-			err := node.host.SendMessageToGroups([]p2p.GroupID{node.NodeConfig.GetShardGroupID()}, host.ConstructP2pMessage(byte(0), buffer))
+			err := node.host.SendMessageToGroups([]p2p.GroupID{node.NodeConfig.GetShardGroupID()}, host.ConstructP2pMessage(buffer))
 			if err != nil {
 				utils.GetLogInstance().Error("[PONG] failed to send regular pong message", "group", node.NodeConfig.GetShardGroupID())
 				continue
@@ -740,6 +835,20 @@ func (node *Node) transitionIntoNextEpoch(shardState types.ShardState) {
 		getLogger().Info("staying in the same shard")
 	} else {
 		getLogger().Info("moving to another shard")
+		// Stop handling the messages of the former shard, and let those being handled
+		// finish, before its chain is closed.
+		curShardID := p2p.ShardID(node.Blockchain().ShardID())
+		nextShardID := p2p.ShardID(myShardID)
+		leave := []p2p.GroupID{p2p.NewGroupIDByShardID(curShardID)}
+		if clientGroup := p2p.NewClientGroupIDByShardID(curShardID); clientGroup != p2p.GroupIDBeaconClient {
+			// the beacon client group is also the global group
+			leave = append(leave, clientGroup)
+		}
+		join := []p2p.GroupID{p2p.NewGroupIDByShardID(nextShardID), p2p.NewClientGroupIDByShardID(nextShardID)}
+		if err := node.groupManager.Switch(leave, join); err != nil {
+			getLogger().Error("cannot switch shard groups", "error", err)
+			node.groupManager.Switch(leave, nil)
+		}
 		if err := node.shardChains.Close(); err != nil {
 			getLogger().Error("cannot close shard chains", "error", err)
 		}
@@ -814,6 +923,9 @@ func getBinaryPath() (argv0 string, err error) {
 
 // ConsensusMessageHandler passes received message in node_handler to consensus
 func (node *Node) ConsensusMessageHandler(msgPayload []byte) {
+	if node.Consensus.IsStopped() {
+		return
+	}
 	if node.Consensus.ConsensusVersion == "v1" {
 		if nodeconfig.GetDefaultConfig().IsLeader() {
 			node.Consensus.ProcessMessageLeader(msgPayload)
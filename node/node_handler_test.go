@@ -1,13 +1,20 @@
 package node
 
 import (
+	"bytes"
 	"testing"
 
-	"github.com/harmony-one/harmony/crypto/bls"
+	protobuf "github.com/golang/protobuf/proto"
 
+	"github.com/harmony-one/harmony/api/proto"
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	proto_node "github.com/harmony-one/harmony/api/proto/node"
 	"github.com/harmony-one/harmony/consensus"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/crypto/bls"
 	"github.com/harmony-one/harmony/internal/utils"
 	"github.com/harmony-one/harmony/p2p"
+	"github.com/harmony-one/harmony/p2p/host"
 	"github.com/harmony-one/harmony/p2p/p2pimpl"
 )
 
@@ -58,3 +65,46 @@ func TestVerifyNewBlock(t *testing.T) {
 		t.Error("New block is not verified successfully:", err)
 	}
 }
+
+func TestMessagePriority(t *testing.T) {
+	consensusMessage := func(msgType msg_pb.MessageType, block []byte) []byte {
+		payload, err := protobuf.Marshal(&msg_pb.Message{
+			ServiceType: msg_pb.ServiceType_CONSENSUS,
+			Type:        msgType,
+			Request:     &msg_pb.Message_Consensus{Consensus: &msg_pb.ConsensusRequest{ViewId: 1, Payload: block}},
+		})
+		if err != nil {
+			t.Fatalf("cannot marshal message: %v", err)
+		}
+		return host.ConstructP2pMessage(proto.ConstructConsensusMessage(payload))
+	}
+	// The blocks make large messages, which are compressed.
+	host.SetCompression(true)
+	block := bytes.Repeat([]byte("block"), 1000)
+	compressedAnnounce := consensusMessage(msg_pb.MessageType_ANNOUNCE, block)
+	compressedBlockResponse := consensusMessage(msg_pb.MessageType_BLOCK_RESPONSE, block)
+	host.SetCompression(false)
+	if !host.IsCompressedMessage(compressedAnnounce) || !host.IsCompressedMessage(compressedBlockResponse) {
+		t.Fatalf("expected the messages with blocks compressed")
+	}
+	tests := []struct {
+		name string
+		msg  []byte
+		want p2p.Priority
+	}{
+		{"announce", consensusMessage(msg_pb.MessageType_ANNOUNCE, nil), p2p.PriorityHigh},
+		{"prepare", consensusMessage(msg_pb.MessageType_PREPARE, nil), p2p.PriorityHigh},
+		{"committed", consensusMessage(msg_pb.MessageType_COMMITTED, nil), p2p.PriorityHigh},
+		{"block response", consensusMessage(msg_pb.MessageType_BLOCK_RESPONSE, nil), p2p.PriorityLow},
+		{"compressed announce", compressedAnnounce, p2p.PriorityHigh},
+		{"compressed block response", compressedBlockResponse, p2p.PriorityLow},
+		{"transactions", host.ConstructP2pMessage(proto_node.ConstructTransactionListMessageAccount(nil)), p2p.PriorityLow},
+		{"shard state", host.ConstructP2pMessage(proto_node.ConstructEpochShardStateMessage(types.EpochShardState{})), p2p.PriorityNormal},
+		{"truncated", []byte{17, 0}, p2p.PriorityLow},
+	}
+	for _, test := range tests {
+		if got := MessagePriority(test.msg); got != test.want {
+			t.Errorf("%s: expected priority %s, got %s", test.name, test.want, got)
+		}
+	}
+}
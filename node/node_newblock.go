@@ -1,6 +1,7 @@
 package node
 
 import (
+	"context"
 	"math/big"
 	"time"
 
@@ -103,69 +104,60 @@ func (node *Node) WaitForConsensusReady(readySignal chan struct{}, stopChan chan
 // TODO: clean pending transactions for validators; or validators not prepare pending transactions
 func (node *Node) WaitForConsensusReadyv2(readySignal chan struct{}, stopChan chan struct{}, stoppedChan chan struct{}) {
 	go func() {
-		// Setup stoppedChan
-		defer close(stoppedChan)
-
 		utils.GetLogInstance().Debug("Waiting for Consensus ready")
 		time.Sleep(30 * time.Second) // Wait for other nodes to be ready (test-only)
 
-		firstTime := true
-		for {
-			// keep waiting for Consensus ready
-			select {
-			case <-stopChan:
-				utils.GetLogInstance().Debug("Consensus propose new block: STOPPED!")
-				return
-
-			case <-readySignal:
-				firstTry := true
-				deadline := time.Now().Add(BlockPeriod)
-				for {
-					if !firstTry {
-						time.Sleep(PeriodicBlock)
-					}
-					firstTry = false
-					// threshold and firstTime are for the test-only built-in smart contract tx.
-					// TODO: remove in production
-					threshold := DefaultThreshold
-					if firstTime {
-						threshold = FirstTimeThreshold
-						firstTime = false
-					}
-					if len(node.pendingTransactions) < threshold && time.Now().Before(deadline) {
-						continue
-					}
-					deadline = time.Now().Add(BlockPeriod)
-					// Normal tx block consensus
-					selectedTxs := node.getTransactionsForNewBlock(MaxNumberOfTransactionsPerBlock)
-					utils.GetLogInstance().Debug("PROPOSING NEW BLOCK ------------------------------------------------", "blockNum", node.Blockchain().CurrentBlock().NumberU64()+1, "threshold", threshold, "selectedTxs", len(selectedTxs))
-					if err := node.Worker.CommitTransactions(selectedTxs); err != nil {
-						ctxerror.Log15(utils.GetLogger().Error,
-							ctxerror.New("cannot commit transactions").
-								WithCause(err))
-					}
-					block, err := node.Worker.Commit()
-					if err != nil {
-						ctxerror.Log15(utils.GetLogger().Error,
-							ctxerror.New("cannot commit new block").
-								WithCause(err))
-						continue
-					} else if err := node.proposeShardState(block); err != nil {
-						ctxerror.Log15(utils.GetLogger().Error,
-							ctxerror.New("cannot add shard state").
-								WithCause(err))
-					} else {
-						newBlock := block
-						utils.GetLogInstance().Debug("Successfully proposed new block", "blockNum", block.NumberU64(), "numTxs", block.Transactions().Len())
+		node.Consensus.RunProposer(readySignal, node.BlockChannel, stopChan, stoppedChan)
+	}()
+}
 
-						// Send the new block to Consensus so it can be confirmed.
-						node.BlockChannel <- newBlock
-						break
-					}
-				}
-			}
+// ProposeBlock implements consensus.Proposer. It waits until there are enough pending
+// transactions or EmptyBlockPeriod has passed since the last block, then builds a block of them.
+func (node *Node) ProposeBlock(ctx context.Context) (*types.Block, error) {
+	deadline := time.Now().Add(node.EmptyBlockPeriod)
+	if !node.lastProposedTime.IsZero() {
+		deadline = node.lastProposedTime.Add(node.EmptyBlockPeriod)
+	}
+	for {
+		// threshold and proposedFirstBlock are for the test-only built-in smart contract tx.
+		// TODO: remove in production
+		threshold := DefaultThreshold
+		if !node.proposedFirstBlock {
+			threshold = FirstTimeThreshold
 		}
-	}()
+		if len(node.pendingTransactions) >= threshold {
+			break
+		}
+		if node.EmptyBlockPeriod > 0 && !time.Now().Before(deadline) {
+			utils.GetLogInstance().Debug("Proposing a heartbeat block", "pendingTransactions", len(node.pendingTransactions))
+			break
+		}
+		select {
+		case <-time.After(PeriodicBlock):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	node.proposedFirstBlock = true
+
+	// Normal tx block consensus
+	selectedTxs := node.getTransactionsForNewBlock(MaxNumberOfTransactionsPerBlock)
+	utils.GetLogInstance().Debug("PROPOSING NEW BLOCK ------------------------------------------------", "blockNum", node.Blockchain().CurrentBlock().NumberU64()+1, "selectedTxs", len(selectedTxs))
+	if err := node.Worker.CommitTransactions(selectedTxs); err != nil {
+		ctxerror.Log15(utils.GetLogger().Error,
+			ctxerror.New("cannot commit transactions").
+				WithCause(err))
+	}
+	block, err := node.Worker.Commit()
+	if err != nil {
+		return nil, ctxerror.New("cannot commit new block").WithCause(err)
+	}
+	if err := node.proposeShardState(block); err != nil {
+		return nil, ctxerror.New("cannot add shard state").WithCause(err)
+	}
+	node.lastProposedTime = time.Now()
+	utils.GetLogInstance().Debug("Successfully proposed new block", "blockNum", block.NumberU64(), "numTxs", block.Transactions().Len())
+	return block, nil
 }
 
 func (node *Node) proposeShardState(block *types.Block) error {
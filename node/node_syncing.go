@@ -3,6 +3,7 @@ package node
 import (
 	"bytes"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -25,6 +26,9 @@ const (
 	lastMileThreshold = 4
 	inSyncThreshold   = 1  // unit in number of block
 	SyncFrequency     = 10 // unit in second
+
+	// how long a peer which requested blocks or headers counts as syncing from the node
+	syncingPeerTimeout = 2 * time.Minute
 )
 
 // getNeighborPeers is a helper function to return list of peers
@@ -49,11 +53,23 @@ func (node *Node) DoSyncWithoutConsensus() {
 // IsSameHeight tells whether node is at same bc height as a peer
 func (node *Node) IsSameHeight() (uint64, bool) {
 	if node.stateSync == nil {
-		node.stateSync = syncing.CreateStateSync(node.SelfPeer.IP, node.SelfPeer.Port, node.GetSyncID())
+		node.stateSync = node.createShardStateSync()
 	}
 	return node.stateSync.IsSameBlockchainHeight(node.Blockchain())
 }
 
+// createShardStateSync returns the state sync of the shard chain, verifying the headers
+// with the quorum policy of the shard consensus.
+func (node *Node) createShardStateSync() *syncing.StateSync {
+	stateSync := syncing.CreateStateSync(node.SelfPeer.IP, node.SelfPeer.Port, node.GetSyncID())
+	stateSync.Mode = node.SyncMode
+	stateSync.Reputation = node.Reputation
+	if node.Consensus != nil {
+		stateSync.QuorumPolicy = node.Consensus.QuorumPolicy
+	}
+	return stateSync
+}
+
 // GetBeaconSyncingPeers returns a list of peers for beaconchain syncing
 func (node *Node) GetBeaconSyncingPeers() []p2p.Peer {
 	return node.getNeighborPeers(&node.BeaconNeighbors)
@@ -64,6 +80,24 @@ func (node *Node) GetSyncingPeers() []p2p.Peer {
 	return node.getNeighborPeers(&node.Neighbors)
 }
 
+// SetBlockRetention makes the node keep the bodies and receipts of the last retention
+// blocks only, or of all blocks if retention is 0. Pruning waits while peers sync from
+// the node.
+func (node *Node) SetBlockRetention(retention uint64) {
+	node.Blockchain().SetBlockRetention(retention, node.hasSyncingPeers)
+}
+
+// hasSyncingPeers tells whether some peers sync from the node: either they are registered
+// for the new blocks to be pushed to them, or they pulled blocks or headers recently.
+func (node *Node) hasSyncingPeers() bool {
+	if time.Since(time.Unix(0, atomic.LoadInt64(&node.lastSyncServed))) < syncingPeerTimeout {
+		return true
+	}
+	node.stateMutex.Lock()
+	defer node.stateMutex.Unlock()
+	return len(node.peerRegistrationRecord) > 0
+}
+
 // DoBeaconSyncing update received beaconchain blocks and downloads missing beacon chain blocks
 func (node *Node) DoBeaconSyncing() {
 	for {
@@ -71,6 +105,7 @@ func (node *Node) DoBeaconSyncing() {
 		case beaconBlock := <-node.BeaconBlockChannel:
 			if node.beaconSync == nil {
 				node.beaconSync = syncing.CreateStateSync(node.SelfPeer.IP, node.SelfPeer.Port, node.GetSyncID())
+				node.beaconSync.Reputation = node.Reputation
 			}
 			if node.beaconSync.GetActivePeerNumber() == 0 {
 				peers := node.GetBeaconSyncingPeers()
@@ -96,7 +131,7 @@ SyncingLoop:
 		select {
 		case <-ticker.C:
 			if node.stateSync == nil {
-				node.stateSync = syncing.CreateStateSync(node.SelfPeer.IP, node.SelfPeer.Port, node.GetSyncID())
+				node.stateSync = node.createShardStateSync()
 				logger = logger.New("syncID", node.GetSyncID())
 				getLogger().Debug("initialized state sync")
 			}
@@ -210,8 +245,10 @@ func (node *Node) CalculateResponse(request *downloader_pb.DownloaderRequest) (*
 		} else {
 			startHeaderHash = request.BlockHash
 		}
-		for block := node.Blockchain().CurrentBlock(); block != nil; block = node.Blockchain().GetBlockByHash(block.Header().ParentHash) {
-			blockHash := block.Hash()
+		atomic.StoreInt64(&node.lastSyncServed, time.Now().UnixNano())
+		// The headers are kept when the block bodies are pruned
+		for header := node.Blockchain().CurrentHeader(); header != nil; header = node.Blockchain().GetHeaderByHash(header.ParentHash) {
+			blockHash := header.Hash()
 			if bytes.Compare(blockHash[:], startHeaderHash) == 0 {
 				break
 			}
@@ -219,11 +256,16 @@ func (node *Node) CalculateResponse(request *downloader_pb.DownloaderRequest) (*
 		}
 
 	case downloader_pb.DownloaderRequest_BLOCK:
+		atomic.StoreInt64(&node.lastSyncServed, time.Now().UnixNano())
 		for _, bytes := range request.Hashes {
 			var hash common.Hash
 			hash.SetBytes(bytes)
 			block := node.Blockchain().GetBlockByHash(hash)
 			if block == nil {
+				if header := node.Blockchain().GetHeaderByHash(hash); header != nil {
+					return nil, ctxerror.New("[SYNC] block body pruned",
+						"blockHash", hash, "blockNum", header.Number)
+				}
 				continue
 			}
 			encodedBlock, err := rlp.EncodeToBytes(block)
@@ -232,6 +274,20 @@ func (node *Node) CalculateResponse(request *downloader_pb.DownloaderRequest) (*
 			}
 		}
 
+	case downloader_pb.DownloaderRequest_BLOCKHEADER:
+		for _, bytes := range request.Hashes {
+			var hash common.Hash
+			hash.SetBytes(bytes)
+			header := node.Blockchain().GetHeaderByHash(hash)
+			if header == nil {
+				continue
+			}
+			encodedHeader, err := rlp.EncodeToBytes(header)
+			if err == nil {
+				response.Payload = append(response.Payload, encodedHeader)
+			}
+		}
+
 	case downloader_pb.DownloaderRequest_BLOCKHEIGHT:
 		response.BlockHeight = node.Blockchain().CurrentBlock().NumberU64()
 
@@ -1,6 +1,7 @@
 package node
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"testing"
@@ -14,8 +15,10 @@ import (
 	"github.com/harmony-one/harmony/drand"
 
 	proto_discovery "github.com/harmony-one/harmony/api/proto/discovery"
+	downloader_pb "github.com/harmony-one/harmony/api/service/syncing/downloader/proto"
 	"github.com/harmony-one/harmony/consensus"
 	"github.com/harmony-one/harmony/crypto/pki"
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
 	"github.com/harmony-one/harmony/internal/utils"
 	"github.com/harmony-one/harmony/p2p"
 	"github.com/harmony-one/harmony/p2p/p2pimpl"
@@ -49,6 +52,66 @@ func TestNewNode(t *testing.T) {
 	}
 }
 
+func TestNodeStop(t *testing.T) {
+	pubKey := bls2.RandPrivateKey().GetPublicKey()
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "8882", ConsensusPubKey: pubKey}
+	priKey, _, _ := utils.GenKeyP2P("127.0.0.1", "9902")
+	host, err := p2pimpl.NewHost(&leader, priKey)
+	if err != nil {
+		t.Fatalf("newhost failure: %v", err)
+	}
+	consensus, err := consensus.New(host, 0, leader, nil)
+	if err != nil {
+		t.Fatalf("Cannot craeate consensus: %v", err)
+	}
+	node := New(host, consensus, testDBFactory, false)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := node.Stop(ctx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if !node.Consensus.IsStopped() {
+		t.Error("Consensus is not stopped with the node")
+	}
+	if groups := node.groupManager.Groups(); len(groups) != 0 {
+		t.Errorf("node is still a member of %v", groups)
+	}
+	if node.receiveCtx.Err() == nil {
+		t.Error("node still receives direct messages")
+	}
+}
+
+func TestNodeStatus(t *testing.T) {
+	pubKey := bls2.RandPrivateKey().GetPublicKey()
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "8882", ConsensusPubKey: pubKey}
+	priKey, _, _ := utils.GenKeyP2P("127.0.0.1", "9902")
+	host, err := p2pimpl.NewHost(&leader, priKey)
+	if err != nil {
+		t.Fatalf("newhost failure: %v", err)
+	}
+	consensus, err := consensus.New(host, 0, leader, nil)
+	if err != nil {
+		t.Fatalf("Cannot craeate consensus: %v", err)
+	}
+	node := New(host, consensus, testDBFactory, false)
+	node.NodeConfig.NetworkID = nodeconfig.NetworkIDs["localnet"]
+
+	status := node.Status()
+	if status.NetworkID != nodeconfig.NetworkIDs["localnet"] {
+		t.Errorf("NetworkID = %d, want %d", status.NetworkID, nodeconfig.NetworkIDs["localnet"])
+	}
+	if status.ShardID != 0 {
+		t.Errorf("ShardID = %d, want 0", status.ShardID)
+	}
+	genesis := node.Blockchain().CurrentBlock()
+	if status.BlockNumber != genesis.NumberU64() || status.BlockHash != genesis.Hash().Hex() {
+		t.Errorf("head = %d %s, want the genesis block %d %s", status.BlockNumber, status.BlockHash, genesis.NumberU64(), genesis.Hash().Hex())
+	}
+	if status.Version != nodeconfig.Version {
+		t.Errorf("Version = %s, want %s", status.Version, nodeconfig.Version)
+	}
+}
+
 func TestGetSyncingPeers(t *testing.T) {
 	pubKey := bls2.RandPrivateKey().GetPublicKey()
 	leader := p2p.Peer{IP: "127.0.0.1", Port: "8882", ConsensusPubKey: pubKey}
@@ -76,6 +139,36 @@ func TestGetSyncingPeers(t *testing.T) {
 	}
 }
 
+func TestPullSyncHoldsPruning(t *testing.T) {
+	pubKey := bls2.RandPrivateKey().GetPublicKey()
+	leader := p2p.Peer{IP: "127.0.0.1", Port: "8882", ConsensusPubKey: pubKey}
+	priKey, _, _ := utils.GenKeyP2P("127.0.0.1", "9902")
+	host, err := p2pimpl.NewHost(&leader, priKey)
+	if err != nil {
+		t.Fatalf("newhost failure: %v", err)
+	}
+	consensus, err := consensus.New(host, 0, leader, nil)
+	if err != nil {
+		t.Fatalf("Cannot craeate consensus: %v", err)
+	}
+	node := New(host, consensus, testDBFactory, false)
+	if node.hasSyncingPeers() {
+		t.Error("node has syncing peers before serving any")
+	}
+	genesisHash := node.Blockchain().Genesis().Hash()
+	request := &downloader_pb.DownloaderRequest{Type: downloader_pb.DownloaderRequest_BLOCK, Hashes: [][]byte{genesisHash[:]}}
+	response, err := node.CalculateResponse(request)
+	if err != nil {
+		t.Fatalf("CalculateResponse failed: %v", err)
+	}
+	if len(response.Payload) != 1 {
+		t.Errorf("expected the genesis block, got %d blocks", len(response.Payload))
+	}
+	if !node.hasSyncingPeers() {
+		t.Error("a peer pulling blocks does not hold pruning")
+	}
+}
+
 func TestAddPeers(t *testing.T) {
 	pubKey1 := pki.GetBLSPrivateKeyFromInt(333).GetPublicKey()
 	pubKey2 := pki.GetBLSPrivateKeyFromInt(444).GetPublicKey()
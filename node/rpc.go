@@ -15,8 +15,9 @@ import (
 )
 
 const (
-	rpcHTTPPortOffset = 500
-	rpcWSPortOffset   = 800
+	rpcHTTPPortOffset  = 500
+	rpcWSPortOffset    = 800
+	rpcAdminPortOffset = 900
 )
 
 var (
@@ -28,16 +29,24 @@ var (
 	wsListener net.Listener
 	wsHandler  *rpc.Server
 
-	httpEndpoint = ""
-	wsEndpoint   = ""
+	// The admin APIs change the node, so they are only served on localhost.
+	adminListener net.Listener
+	adminHandler  *rpc.Server
 
-	httpModules      = []string{"hmy"}
+	httpEndpoint  = ""
+	wsEndpoint    = ""
+	adminEndpoint = ""
+
+	httpModules      = []string{"hmy", "eth", "net", "web3"}
 	httpVirtualHosts = []string{"*"}
 	httpTimeouts     = rpc.DefaultHTTPTimeouts
 
-	wsModules = []string{"net", "web3"}
+	wsModules = []string{"hmy", "eth", "net", "web3"}
 	wsOrigins = []string{"*"}
 
+	adminModules      = []string{"admin"}
+	adminVirtualHosts = []string{"localhost"}
+
 	harmony *hmy.Harmony
 )
 
@@ -60,15 +69,28 @@ func (node *Node) StartRPC(nodePort string) error {
 	}
 
 	wsEndpoint = fmt.Sprintf(":%v", port+rpcWSPortOffset)
-	if err := node.startWS(wsEndpoint, apis, wsModules, wsOrigins, true); err != nil {
+	if err := node.startWS(wsEndpoint, apis, wsModules, wsOrigins, false); err != nil {
 		node.stopHTTP()
 		return err
 	}
 
+	adminEndpoint = fmt.Sprintf("127.0.0.1:%v", port+rpcAdminPortOffset)
+	if err := node.startAdmin(adminEndpoint, apis); err != nil {
+		node.stopRPC()
+		return err
+	}
+
 	rpcAPIs = apis
 	return nil
 }
 
+// stopRPC terminates all the RPC endpoints.
+func (node *Node) stopRPC() {
+	node.stopAdmin()
+	node.stopWS()
+	node.stopHTTP()
+}
+
 // startHTTP initializes and starts the HTTP RPC endpoint.
 func (node *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors []string, vhosts []string, timeouts rpc.HTTPTimeouts) error {
 	// Short circuit if the HTTP endpoint isn't being exposed
@@ -135,6 +157,34 @@ func (node *Node) stopWS() {
 	}
 }
 
+// startAdmin initializes and starts the HTTP RPC endpoint of the admin APIs, which must
+// listen on localhost only.
+func (node *Node) startAdmin(endpoint string, apis []rpc.API) error {
+	listener, handler, err := rpc.StartHTTPEndpoint(endpoint, apis, adminModules, nil, adminVirtualHosts, httpTimeouts)
+	if err != nil {
+		return err
+	}
+	log.Info("Admin HTTP endpoint opened", "url", fmt.Sprintf("http://%s", endpoint))
+	adminListener = listener
+	adminHandler = handler
+
+	return nil
+}
+
+// stopAdmin terminates the admin HTTP RPC endpoint.
+func (node *Node) stopAdmin() {
+	if adminListener != nil {
+		adminListener.Close()
+		adminListener = nil
+
+		log.Info("Admin HTTP endpoint closed", "url", fmt.Sprintf("http://%s", adminEndpoint))
+	}
+	if adminHandler != nil {
+		adminHandler.Stop()
+		adminHandler = nil
+	}
+}
+
 // APIs return the collection of RPC services the ethereum package offers.
 // NOTE, some of these services probably need to be moved to somewhere else.
 func (node *Node) APIs() []rpc.API {
@@ -142,11 +192,27 @@ func (node *Node) APIs() []rpc.API {
 	apis := hmyapi.GetAPIs(harmony.APIBackend)
 
 	// Append all the local APIs and return
+	filterAPI := filters.NewPublicFilterAPI(harmony.APIBackend, false)
 	return append(apis, []rpc.API{
 		{
 			Namespace: "hmy",
 			Version:   "1.0",
-			Service:   filters.NewPublicFilterAPI(harmony.APIBackend, false),
+			Service:   filterAPI,
+			Public:    true,
+		}, {
+			Namespace: "eth",
+			Version:   "1.0",
+			Service:   filterAPI,
+			Public:    true,
+		}, {
+			Namespace: "net",
+			Version:   "1.0",
+			Service:   hmyapi.NewPublicNetAPI(node.host, node.NodeConfig.NetworkID),
+			Public:    true,
+		}, {
+			Namespace: "web3",
+			Version:   "1.0",
+			Service:   hmyapi.NewPublicWeb3API(),
 			Public:    true,
 		},
 	}...)
@@ -0,0 +1,26 @@
+package node
+
+import (
+	"context"
+
+	"github.com/harmony-one/harmony/internal/utils"
+)
+
+// Stop shuts the node down: it closes the RPC endpoints, stops receiving direct messages,
+// leaves all its groups, waiting for the handlers of their messages to return, stops the
+// services and then the consensus, until ctx is done.
+func (node *Node) Stop(ctx context.Context) error {
+	utils.GetLogInstance().Info("Stopping node")
+	node.stopRPC()
+	if node.stopReceiving != nil {
+		node.stopReceiving()
+	}
+	if node.groupManager != nil {
+		node.groupManager.Switch(node.groupManager.Groups(), nil)
+	}
+	node.StopServices()
+	if node.Consensus == nil {
+		return nil
+	}
+	return node.Consensus.Stop(ctx)
+}
@@ -44,29 +44,39 @@ type Worker struct {
 }
 
 // SelectTransactionsForNewBlock selects transactions for new block.
-func (w *Worker) SelectTransactionsForNewBlock(txs types.Transactions, maxNumTxs int) (types.Transactions, types.Transactions, types.Transactions) {
+// At most maxNumTxs transactions of at most maxTotalTxBytes encoded bytes in total are
+// selected, and transactions larger than maxTxBytes are invalid; a zero byte limit means no limit.
+func (w *Worker) SelectTransactionsForNewBlock(txs types.Transactions, maxNumTxs, maxTxBytes, maxTotalTxBytes int) (types.Transactions, types.Transactions, types.Transactions) {
 	if w.current.gasPool == nil {
 		w.current.gasPool = new(core.GasPool).AddGas(w.current.header.GasLimit)
 	}
 	selected := types.Transactions{}
 	unselected := types.Transactions{}
 	invalid := types.Transactions{}
+	totalTxBytes := 0
 	for _, tx := range txs {
 		if tx.ShardID() != w.shardID {
 			invalid = append(invalid, tx)
 		}
+		txBytes := int(tx.Size())
+		if maxTxBytes > 0 && txBytes > maxTxBytes {
+			invalid = append(invalid, tx)
+			log.Debug("Transaction too large", "size", txBytes, "max", maxTxBytes)
+			continue
+		}
+		if len(selected) >= maxNumTxs || (maxTotalTxBytes > 0 && totalTxBytes+txBytes > maxTotalTxBytes) {
+			unselected = append(unselected, tx)
+			continue
+		}
 		snap := w.current.state.Snapshot()
 		_, err := w.commitTransaction(tx, w.coinbase)
-		if len(selected) > maxNumTxs {
-			unselected = append(unselected, tx)
+		if err != nil {
+			w.current.state.RevertToSnapshot(snap)
+			invalid = append(invalid, tx)
+			log.Debug("Invalid transaction", "Error", err)
 		} else {
-			if err != nil {
-				w.current.state.RevertToSnapshot(snap)
-				invalid = append(invalid, tx)
-				log.Debug("Invalid transaction", "Error", err)
-			} else {
-				selected = append(selected, tx)
-			}
+			selected = append(selected, tx)
+			totalTxBytes += txBytes
 		}
 	}
 	err := w.UpdateCurrent()
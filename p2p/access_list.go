@@ -0,0 +1,175 @@
+package p2p
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync"
+
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// AccessRules restrict the peers the node connects with, by peer ID and by the IP address
+// of the connection, written as a CIDR or as a single IP. A peer matching a deny rule is
+// rejected. If there are allow rules, a peer must also match one of them to be accepted.
+type AccessRules struct {
+	AllowPeers []string `json:"allowPeers,omitempty"`
+	AllowNets  []string `json:"allowNets,omitempty"`
+	DenyPeers  []string `json:"denyPeers,omitempty"`
+	DenyNets   []string `json:"denyNets,omitempty"`
+}
+
+// AccessList checks the peers against access rules which can change at runtime.
+type AccessList struct {
+	mutex      sync.RWMutex
+	rules      AccessRules
+	allowPeers map[libp2p_peer.ID]bool
+	allowNets  []*net.IPNet
+	denyPeers  map[libp2p_peer.ID]bool
+	denyNets   []*net.IPNet
+}
+
+// NewAccessList creates an access list of rules.
+func NewAccessList(rules AccessRules) (*AccessList, error) {
+	list := &AccessList{}
+	if err := list.SetRules(rules); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// LoadAccessRules reads the access rules from the JSON file at path, e.g.
+// {"allowNets": ["10.0.0.0/8"], "denyPeers": ["QmPeer..."]}.
+func LoadAccessRules(path string) (AccessRules, error) {
+	rules := AccessRules{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return rules, err
+	}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return rules, fmt.Errorf("invalid access rules in %s: %v", path, err)
+	}
+	if _, err := NewAccessList(rules); err != nil {
+		return rules, fmt.Errorf("invalid access rules in %s: %v", path, err)
+	}
+	return rules, nil
+}
+
+// SetRules replaces the rules of the list. The rules are left unchanged if any is invalid.
+func (list *AccessList) SetRules(rules AccessRules) error {
+	allowPeers, err := parsePeerIDs(rules.AllowPeers)
+	if err != nil {
+		return err
+	}
+	denyPeers, err := parsePeerIDs(rules.DenyPeers)
+	if err != nil {
+		return err
+	}
+	allowNets, err := parseIPNets(rules.AllowNets)
+	if err != nil {
+		return err
+	}
+	denyNets, err := parseIPNets(rules.DenyNets)
+	if err != nil {
+		return err
+	}
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+	list.rules = rules
+	list.allowPeers = allowPeers
+	list.allowNets = allowNets
+	list.denyPeers = denyPeers
+	list.denyNets = denyNets
+	return nil
+}
+
+// Rules returns the rules of the list.
+func (list *AccessList) Rules() AccessRules {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+	return list.rules
+}
+
+// Permits tells whether the rules accept the peer id connected from addr. Only the peer
+// rules apply to an address without an IP, or to a nil address.
+func (list *AccessList) Permits(id libp2p_peer.ID, addr ma.Multiaddr) bool {
+	ip := addrIP(addr)
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+	if list.denyPeers[id] || containsIP(list.denyNets, ip) {
+		return false
+	}
+	if len(list.allowPeers) == 0 && len(list.allowNets) == 0 {
+		return true
+	}
+	return list.allowPeers[id] || containsIP(list.allowNets, ip)
+}
+
+// parsePeerIDs decodes the base58 peer IDs of ids.
+func parsePeerIDs(ids []string) (map[libp2p_peer.ID]bool, error) {
+	peers := make(map[libp2p_peer.ID]bool)
+	for _, value := range ids {
+		id, err := libp2p_peer.IDB58Decode(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid peer ID %q: %v", value, err)
+		}
+		peers[id] = true
+	}
+	return peers, nil
+}
+
+// parseIPNets parses the CIDRs of nets, taking a single IP as the network of that IP only.
+func parseIPNets(nets []string) ([]*net.IPNet, error) {
+	var ipNets []*net.IPNet
+	for _, value := range nets {
+		value = strings.TrimSpace(value)
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP %q", value)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			ipNets = append(ipNets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %v", value, err)
+		}
+		ipNets = append(ipNets, ipNet)
+	}
+	return ipNets, nil
+}
+
+// addrIP returns the IP of addr, or nil if it has none.
+func addrIP(addr ma.Multiaddr) net.IP {
+	if addr == nil {
+		return nil
+	}
+	for _, code := range []int{ma.P_IP4, ma.P_IP6} {
+		if value, err := addr.ValueForProtocol(code); err == nil {
+			return net.ParseIP(value)
+		}
+	}
+	return nil
+}
+
+// containsIP tells whether one of nets contains ip.
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
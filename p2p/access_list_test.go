@@ -0,0 +1,51 @@
+package p2p
+
+import (
+	"testing"
+
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testPeerA = "QmaCpDMGvV2BGHeYERUEnRQAwe3N8SzbUtfsmvsqQLuvuJ"
+	testPeerB = "QmNnooDu7bfjPFoTZYxMNLWUQJyrVwtbZg5gBMjTezGAJN"
+)
+
+func TestAccessList(t *testing.T) {
+	peerA, _ := libp2p_peer.IDB58Decode(testPeerA)
+	peerB, _ := libp2p_peer.IDB58Decode(testPeerB)
+	inside, _ := ma.NewMultiaddr("/ip4/10.1.2.3/tcp/9000")
+	outside, _ := ma.NewMultiaddr("/ip4/192.168.1.1/tcp/9000")
+	denied, _ := ma.NewMultiaddr("/ip4/10.0.0.7/tcp/9000")
+
+	list, err := NewAccessList(AccessRules{})
+	assert.Nil(t, err)
+	assert.True(t, list.Permits(peerA, outside))
+	assert.True(t, list.Permits(peerA, nil))
+
+	assert.Nil(t, list.SetRules(AccessRules{
+		AllowNets: []string{"10.0.0.0/8"},
+		DenyNets:  []string{"10.0.0.7"},
+		DenyPeers: []string{testPeerB},
+	}))
+	assert.True(t, list.Permits(peerA, inside))
+	assert.False(t, list.Permits(peerA, outside), "not in the allowlist")
+	assert.False(t, list.Permits(peerA, denied), "denied IP")
+	assert.False(t, list.Permits(peerB, inside), "denied peer")
+	assert.False(t, list.Permits(peerA, nil), "no IP to allow")
+
+	assert.Nil(t, list.SetRules(AccessRules{AllowPeers: []string{testPeerA}}))
+	assert.True(t, list.Permits(peerA, outside))
+	assert.False(t, list.Permits(peerB, outside))
+}
+
+func TestAccessListInvalidRules(t *testing.T) {
+	list, err := NewAccessList(AccessRules{DenyPeers: []string{testPeerB}})
+	assert.Nil(t, err)
+	assert.NotNil(t, list.SetRules(AccessRules{AllowNets: []string{"10.0.0.0/33"}}))
+	assert.NotNil(t, list.SetRules(AccessRules{DenyNets: []string{"not an ip"}}))
+	assert.NotNil(t, list.SetRules(AccessRules{DenyPeers: []string{"peer"}}))
+	assert.Equal(t, []string{testPeerB}, list.Rules().DenyPeers, "rules unchanged")
+}
@@ -0,0 +1,239 @@
+package p2p
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+)
+
+// DirectTopic is the topic the messages sent to a single peer are counted under.
+const DirectTopic = "direct"
+
+const (
+	// the rates are averaged over about this window
+	bandwidthRateWindow = time.Minute
+	// the counters of the peers which exchanged nothing for this long are dropped
+	bandwidthPeerTimeout = 10 * time.Minute
+	// how often the counting drops the counters of the idle peers
+	bandwidthPeerCleanupPeriod = time.Minute
+)
+
+// TrafficStats counts the messages and bytes sent and received, with the rates in bytes per
+// second averaged over about the last minute.
+type TrafficStats struct {
+	MessagesIn  uint64  `json:"messagesIn"`
+	MessagesOut uint64  `json:"messagesOut"`
+	BytesIn     uint64  `json:"bytesIn"`
+	BytesOut    uint64  `json:"bytesOut"`
+	RateIn      float64 `json:"rateIn"`
+	RateOut     float64 `json:"rateOut"`
+}
+
+// BandwidthStats is the traffic of a host in total, per topic, per kind of message and per
+// peer. The messages a host gossips to a group are not counted per peer.
+type BandwidthStats struct {
+	Total  TrafficStats            `json:"total"`
+	Topics map[string]TrafficStats `json:"topics"`
+	Kinds  map[string]TrafficStats `json:"kinds"`
+	Peers  map[string]TrafficStats `json:"peers"`
+}
+
+// traffic is the traffic counted under a topic, a kind or a peer.
+type traffic struct {
+	stats   TrafficStats
+	updated time.Time
+}
+
+// add counts a message of size bytes, sent if out, and decays the rates to now.
+func (t *traffic) add(size int, out bool, now time.Time) {
+	t.decay(now)
+	rate := float64(size) / bandwidthRateWindow.Seconds()
+	if out {
+		t.stats.MessagesOut++
+		t.stats.BytesOut += uint64(size)
+		t.stats.RateOut += rate
+	} else {
+		t.stats.MessagesIn++
+		t.stats.BytesIn += uint64(size)
+		t.stats.RateIn += rate
+	}
+}
+
+// decay lowers the rates as time passes without traffic.
+func (t *traffic) decay(now time.Time) {
+	if !t.updated.IsZero() {
+		factor := math.Exp(-now.Sub(t.updated).Seconds() / bandwidthRateWindow.Seconds())
+		t.stats.RateIn *= factor
+		t.stats.RateOut *= factor
+	}
+	t.updated = now
+}
+
+// BandwidthCounter counts the traffic of a host.
+type BandwidthCounter struct {
+	mutex  sync.Mutex
+	total  traffic
+	topics map[string]*traffic
+	kinds  map[string]*traffic
+	peers  map[libp2p_peer.ID]*traffic
+	// when the idle peers were last dropped
+	cleaned time.Time
+
+	// Classify returns the kind of a message, if set
+	Classify func(msg []byte) MessageKind
+
+	now func() time.Time
+}
+
+// NewBandwidthCounter creates a bandwidth counter classifying the messages with classify.
+func NewBandwidthCounter(classify func(msg []byte) MessageKind) *BandwidthCounter {
+	return &BandwidthCounter{
+		topics:   make(map[string]*traffic),
+		kinds:    make(map[string]*traffic),
+		peers:    make(map[libp2p_peer.ID]*traffic),
+		Classify: classify,
+		now:      time.Now,
+	}
+}
+
+// Sent counts msg sent to topic, to peer if not empty.
+func (counter *BandwidthCounter) Sent(topic string, peer libp2p_peer.ID, msg []byte) {
+	counter.count(topic, peer, msg, true)
+}
+
+// Received counts msg received from peer on topic.
+func (counter *BandwidthCounter) Received(topic string, peer libp2p_peer.ID, msg []byte) {
+	counter.count(topic, peer, msg, false)
+}
+
+func (counter *BandwidthCounter) count(topic string, peer libp2p_peer.ID, msg []byte, out bool) {
+	var kind MessageKind
+	if counter.Classify != nil {
+		kind = counter.Classify(msg)
+	}
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	now := counter.now()
+	counter.total.add(len(msg), out, now)
+	trafficOf(counter.topics, topic).add(len(msg), out, now)
+	if kind != "" {
+		trafficOf(counter.kinds, string(kind)).add(len(msg), out, now)
+	}
+	if now.Sub(counter.cleaned) >= bandwidthPeerCleanupPeriod {
+		counter.dropIdlePeers(now)
+	}
+	if peer != "" {
+		t, ok := counter.peers[peer]
+		if !ok {
+			t = &traffic{}
+			counter.peers[peer] = t
+		}
+		t.add(len(msg), out, now)
+	}
+}
+
+func trafficOf(traffics map[string]*traffic, key string) *traffic {
+	t, ok := traffics[key]
+	if !ok {
+		t = &traffic{}
+		traffics[key] = t
+	}
+	return t
+}
+
+// Stats returns the traffic counted so far, dropping the peers idle for long.
+func (counter *BandwidthCounter) Stats() BandwidthStats {
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	now := counter.now()
+	counter.total.decay(now)
+	stats := BandwidthStats{
+		Total:  counter.total.stats,
+		Topics: make(map[string]TrafficStats),
+		Kinds:  make(map[string]TrafficStats),
+		Peers:  make(map[string]TrafficStats),
+	}
+	for topic, t := range counter.topics {
+		t.decay(now)
+		stats.Topics[topic] = t.stats
+	}
+	for kind, t := range counter.kinds {
+		t.decay(now)
+		stats.Kinds[kind] = t.stats
+	}
+	counter.dropIdlePeers(now)
+	for peer, t := range counter.peers {
+		t.decay(now)
+		stats.Peers[peer.Pretty()] = t.stats
+	}
+	return stats
+}
+
+// dropIdlePeers drops the counters of the peers idle for bandwidthPeerTimeout, as the
+// authors of the gossiped messages keep changing. The caller must hold counter.mutex.
+func (counter *BandwidthCounter) dropIdlePeers(now time.Time) {
+	for peer, t := range counter.peers {
+		if now.Sub(t.updated) >= bandwidthPeerTimeout {
+			delete(counter.peers, peer)
+		}
+	}
+	counter.cleaned = now
+}
+
+// WritePrometheus writes the traffic counted so far in the Prometheus text format. The
+// peers are left out, as there can be too many of them for a time series each.
+func (counter *BandwidthCounter) WritePrometheus(w io.Writer) error {
+	stats := counter.Stats()
+	metrics := []struct {
+		name, help, kind string
+		value            func(TrafficStats) float64
+	}{
+		{"harmony_p2p_messages_received_total", "Messages received.", "counter",
+			func(t TrafficStats) float64 { return float64(t.MessagesIn) }},
+		{"harmony_p2p_messages_sent_total", "Messages sent.", "counter",
+			func(t TrafficStats) float64 { return float64(t.MessagesOut) }},
+		{"harmony_p2p_bytes_received_total", "Bytes received.", "counter",
+			func(t TrafficStats) float64 { return float64(t.BytesIn) }},
+		{"harmony_p2p_bytes_sent_total", "Bytes sent.", "counter",
+			func(t TrafficStats) float64 { return float64(t.BytesOut) }},
+		{"harmony_p2p_receive_rate_bytes", "Bytes received per second over the last minute.", "gauge",
+			func(t TrafficStats) float64 { return t.RateIn }},
+		{"harmony_p2p_send_rate_bytes", "Bytes sent per second over the last minute.", "gauge",
+			func(t TrafficStats) float64 { return t.RateOut }},
+	}
+	for _, metric := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %g\n",
+			metric.name, metric.help, metric.name, metric.kind, metric.name, metric.value(stats.Total)); err != nil {
+			return err
+		}
+		for _, label := range []struct {
+			name   string
+			series map[string]TrafficStats
+		}{{"topic", stats.Topics}, {"kind", stats.Kinds}} {
+			keys := make([]string, 0, len(label.series))
+			for key := range label.series {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				if _, err := fmt.Fprintf(w, "%s{%s=%q} %g\n",
+					metric.name, label.name, key, metric.value(label.series[key])); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ServeHTTP serves the traffic counted so far to Prometheus.
+func (counter *BandwidthCounter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	counter.WritePrometheus(w)
+}
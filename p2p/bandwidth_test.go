@@ -0,0 +1,63 @@
+package p2p
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBandwidthCounter(test *testing.T) {
+	now := time.Now()
+	counter := NewBandwidthCounter(func(msg []byte) MessageKind { return MessageKind(msg[:1]) })
+	counter.now = func() time.Time { return now }
+	peer := libp2p_peer.ID("peer")
+
+	counter.Received("shard1", peer, []byte("c-vote"))
+	counter.Received("shard1", peer, []byte("t-tx"))
+	counter.Sent("shard1", "", []byte("c-announce"))
+	counter.Sent(DirectTopic, peer, []byte("c-v"))
+
+	stats := counter.Stats()
+	assert.Equal(test, uint64(2), stats.Total.MessagesIn)
+	assert.Equal(test, uint64(2), stats.Total.MessagesOut)
+	assert.Equal(test, uint64(10), stats.Total.BytesIn)
+	assert.Equal(test, uint64(13), stats.Total.BytesOut)
+	assert.InDelta(test, 10/bandwidthRateWindow.Seconds(), stats.Total.RateIn, 1e-9)
+	assert.InDelta(test, 13/bandwidthRateWindow.Seconds(), stats.Total.RateOut, 1e-9)
+	assert.Equal(test, uint64(10), stats.Topics["shard1"].BytesOut)
+	assert.Equal(test, uint64(3), stats.Topics[DirectTopic].BytesOut)
+	assert.Equal(test, uint64(2), stats.Kinds["c"].MessagesOut)
+	assert.Equal(test, uint64(4), stats.Kinds["t"].BytesIn)
+	assert.Equal(test, uint64(3), stats.Peers[peer.Pretty()].MessagesIn+stats.Peers[peer.Pretty()].MessagesOut)
+
+	// The rates decay without traffic, and the idle peers are forgotten.
+	now = now.Add(bandwidthPeerTimeout)
+	stats = counter.Stats()
+	assert.True(test, stats.Total.RateIn < 0.001)
+	assert.Equal(test, uint64(10), stats.Total.BytesIn)
+	assert.Empty(test, stats.Peers)
+
+	var buffer bytes.Buffer
+	assert.Nil(test, counter.WritePrometheus(&buffer))
+	assert.True(test, strings.Contains(buffer.String(), "harmony_p2p_bytes_received_total 10\n"))
+	assert.True(test, strings.Contains(buffer.String(), `harmony_p2p_bytes_sent_total{topic="shard1"} 10`))
+}
+
+func TestBandwidthCounterDropsIdlePeers(test *testing.T) {
+	now := time.Now()
+	counter := NewBandwidthCounter(nil)
+	counter.now = func() time.Time { return now }
+
+	// The peers are dropped while counting, without waiting for Stats.
+	counter.Received("shard1", libp2p_peer.ID("old"), []byte("tx"))
+	now = now.Add(bandwidthPeerTimeout)
+	counter.Received("shard1", libp2p_peer.ID("new"), []byte("tx"))
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	assert.Len(test, counter.peers, 1)
+	assert.Contains(test, counter.peers, libp2p_peer.ID("new"))
+}
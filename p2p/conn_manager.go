@@ -0,0 +1,249 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	libp2p_host "github.com/libp2p/go-libp2p-host"
+	libp2p_net "github.com/libp2p/go-libp2p-net"
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+	libp2p_peerstore "github.com/libp2p/go-libp2p-peerstore"
+)
+
+// PeerRole is what a node connects to a peer for.
+type PeerRole uint8
+
+// The peer roles, a peer having the first role it qualifies for
+const (
+	// RoleShard is a peer of the shard of the node.
+	RoleShard PeerRole = iota
+	// RoleBeacon is a peer of the beacon chain.
+	RoleBeacon
+	// RoleSync is a peer the node syncs blocks from.
+	RoleSync
+	// RoleOther is any other peer, such as a bootnode or a client.
+	RoleOther
+	numRoles
+)
+
+func (role PeerRole) String() string {
+	switch role {
+	case RoleShard:
+		return "shard"
+	case RoleBeacon:
+		return "beacon"
+	case RoleSync:
+		return "sync"
+	case RoleOther:
+		return "other"
+	}
+	return "unknown"
+}
+
+// RoleLimits are the fewest and most connected peers of a role. The peers of a role are not
+// pruned below Min, and its known peers are redialed while fewer are connected. 0 is no maximum.
+type RoleLimits struct {
+	Min int
+	Max int
+}
+
+// ConnManagerConfig are the limits of the connections of a node.
+type ConnManagerConfig struct {
+	Roles [numRoles]RoleLimits
+	// MaxPeers is the most connected peers in total; 0 is no limit.
+	MaxPeers int
+}
+
+// ConnManagerProfiles are the limits for the sizes of deployment.
+var ConnManagerProfiles = map[string]ConnManagerConfig{
+	"small": {
+		Roles:    [numRoles]RoleLimits{{Min: 8, Max: 16}, {Min: 4, Max: 8}, {Min: 2, Max: 4}, {Max: 8}},
+		MaxPeers: 32,
+	},
+	"medium": {
+		Roles:    [numRoles]RoleLimits{{Min: 16, Max: 32}, {Min: 8, Max: 16}, {Min: 4, Max: 8}, {Max: 16}},
+		MaxPeers: 64,
+	},
+	"large": {
+		Roles:    [numRoles]RoleLimits{{Min: 32, Max: 64}, {Min: 16, Max: 32}, {Min: 8, Max: 16}, {Max: 32}},
+		MaxPeers: 128,
+	},
+}
+
+// the connections are checked every period
+const connManagerPeriod = 30 * time.Second
+
+// ConnManager keeps the number of connected peers of each role within its limits: it prunes
+// the peers of the lowest score beyond the maximum of their role or the total maximum, and
+// redials the peers it has seen in a role while the role is short of its minimum.
+type ConnManager struct {
+	host   libp2p_host.Host
+	config ConnManagerConfig
+
+	// Roles returns the role of the peers which have one other than RoleOther
+	Roles func() map[libp2p_peer.ID]PeerRole
+	// Score returns the score of a peer, if set; the peers of lower score are pruned first
+	Score func(id libp2p_peer.ID) int
+
+	mutex sync.Mutex
+	known map[libp2p_peer.ID]PeerRole // the peers seen in a role other than RoleOther
+}
+
+// NewConnManager creates a connection manager of the connections of host.
+func NewConnManager(host libp2p_host.Host, config ConnManagerConfig) *ConnManager {
+	return &ConnManager{
+		host:   host,
+		config: config,
+		known:  make(map[libp2p_peer.ID]PeerRole),
+	}
+}
+
+// Start checks the connections periodically.
+func (manager *ConnManager) Start() {
+	go func() {
+		for {
+			time.Sleep(connManagerPeriod)
+			manager.Check()
+		}
+	}()
+}
+
+// Counts returns the number of connected peers of each role.
+func (manager *ConnManager) Counts() map[string]int {
+	counts := make(map[string]int)
+	for role, peers := range manager.connected() {
+		counts[PeerRole(role).String()] = len(peers)
+	}
+	return counts
+}
+
+// connected returns the connected peers by role.
+func (manager *ConnManager) connected() [numRoles][]libp2p_peer.ID {
+	var roles map[libp2p_peer.ID]PeerRole
+	if manager.Roles != nil {
+		roles = manager.Roles()
+	}
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	for id, role := range roles {
+		manager.known[id] = role
+	}
+	var peers [numRoles][]libp2p_peer.ID
+	for _, id := range manager.host.Network().Peers() {
+		role, ok := roles[id]
+		if !ok {
+			role = RoleOther
+		}
+		peers[role] = append(peers[role], id)
+	}
+	return peers
+}
+
+// Check prunes the connections beyond the limits and redials the roles short of peers.
+func (manager *ConnManager) Check() {
+	peers := manager.connected()
+	total := 0
+	for _, ids := range peers {
+		total += len(ids)
+	}
+
+	// Prune each role down to its maximum, then the roles above their minimum, the lowest
+	// scores first, down to the total maximum.
+	for role, ids := range peers {
+		limits := manager.config.Roles[role]
+		if limits.Max > 0 && len(ids) > limits.Max {
+			manager.sortByScore(ids)
+			total -= manager.prune(ids[:len(ids)-limits.Max], PeerRole(role).String())
+			peers[role] = ids[len(ids)-limits.Max:]
+		}
+	}
+	if manager.config.MaxPeers > 0 && total > manager.config.MaxPeers {
+		var candidates []libp2p_peer.ID
+		for role, ids := range peers {
+			if spare := len(ids) - manager.config.Roles[role].Min; spare > 0 {
+				manager.sortByScore(ids)
+				candidates = append(candidates, ids[:spare]...)
+			}
+		}
+		manager.sortByScore(candidates)
+		if excess := total - manager.config.MaxPeers; excess < len(candidates) {
+			candidates = candidates[:excess]
+		}
+		manager.prune(candidates, "any")
+	}
+
+	for role, ids := range peers {
+		if missing := manager.config.Roles[role].Min - len(ids); missing > 0 {
+			manager.redial(PeerRole(role), missing)
+		}
+	}
+}
+
+// sortByScore sorts ids by increasing score.
+func (manager *ConnManager) sortByScore(ids []libp2p_peer.ID) {
+	if manager.Score == nil {
+		return
+	}
+	scores := make(map[libp2p_peer.ID]int)
+	for _, id := range ids {
+		scores[id] = manager.Score(id)
+	}
+	sort.SliceStable(ids, func(i, j int) bool { return scores[ids[i]] < scores[ids[j]] })
+}
+
+// prune closes the connections to ids, returning their number.
+func (manager *ConnManager) prune(ids []libp2p_peer.ID, role string) int {
+	for _, id := range ids {
+		if err := manager.host.Network().ClosePeer(id); err != nil {
+			log.Debug("Cannot prune peer", "peer", id.Pretty(), "error", err)
+		}
+	}
+	if len(ids) > 0 {
+		log.Info("Pruned peers", "role", role, "count", len(ids))
+	}
+	return len(ids)
+}
+
+// redial connects to up to missing known peers of role which are not connected.
+func (manager *ConnManager) redial(role PeerRole, missing int) {
+	var candidates []libp2p_peerstore.PeerInfo
+	manager.mutex.Lock()
+	for id, knownRole := range manager.known {
+		if knownRole != role || manager.host.Network().Connectedness(id) == libp2p_net.Connected {
+			continue
+		}
+		peer := manager.host.Peerstore().PeerInfo(id)
+		if len(peer.Addrs) == 0 {
+			// the addresses expired, the peer cannot be redialed anymore
+			delete(manager.known, id)
+			continue
+		}
+		candidates = append(candidates, peer)
+	}
+	manager.mutex.Unlock()
+	log.Debug("Too few peers", "role", role, "missing", missing, "candidates", len(candidates))
+	for _, peer := range candidates {
+		if missing == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := manager.host.Connect(ctx, peer)
+		cancel()
+		if err == nil {
+			missing--
+		}
+	}
+}
+
+// ParseConnManagerProfile returns the limits of a size of deployment.
+func ParseConnManagerProfile(name string) (ConnManagerConfig, error) {
+	config, ok := ConnManagerProfiles[name]
+	if !ok {
+		return ConnManagerConfig{}, fmt.Errorf("unknown connection profile %q: expected small, medium or large", name)
+	}
+	return config, nil
+}
@@ -94,6 +94,6 @@ type GroupReceiver interface {
 	// Close closes this receiver.
 	io.Closer
 
-	// Receive a message.
+	// Receive a message, along with the peer which authored and signed it.
 	Receive(ctx context.Context) (msg []byte, sender libp2p_peer.ID, err error)
 }
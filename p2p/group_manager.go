@@ -0,0 +1,182 @@
+package p2p
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+)
+
+// GroupDrainTimeout is how long leaving a group waits for the handlers of its messages to return.
+var GroupDrainTimeout = 10 * time.Second
+
+// a receive loop waits this long after failing to receive a message
+const groupRetryDelay = 100 * time.Millisecond
+
+// GroupHandler handles a message received from a group.
+type GroupHandler func(group GroupID, msg []byte, sender libp2p_peer.ID)
+
+// membership is the subscription of a group joined by a GroupManager.
+type membership struct {
+	receiver GroupReceiver
+	cancel   context.CancelFunc
+	done     chan struct{}  // closed when the receive loop returns
+	handlers sync.WaitGroup // handlers in flight
+}
+
+// GroupManager owns the group subscriptions of a node. Every message received from a group
+// the node is a member of is passed to the handler, in a goroutine of its own or through
+// the dispatcher. Once the node
+// leaves a group, no more messages of the group are handled, so that a node reassigned to
+// another shard does not process the messages of its former shard.
+type GroupManager struct {
+	host    Host
+	handler GroupHandler
+
+	// Dispatcher, if set, runs the handlers by priority in its workers instead of each in a
+	// goroutine of its own. It must be set before joining groups.
+	Dispatcher *PriorityDispatcher
+
+	mutex  sync.RWMutex
+	groups map[GroupID]*membership
+}
+
+// NewGroupManager creates a group manager passing the messages received by host to handler.
+func NewGroupManager(host Host, handler GroupHandler) *GroupManager {
+	return &GroupManager{
+		host:    host,
+		handler: handler,
+		groups:  make(map[GroupID]*membership),
+	}
+}
+
+// Join subscribes to group. Joining a group twice is the same as joining it once.
+func (manager *GroupManager) Join(group GroupID) error {
+	return manager.Switch(nil, []GroupID{group})
+}
+
+// Leave unsubscribes from group, waiting for the handlers of its messages to return.
+func (manager *GroupManager) Leave(group GroupID) {
+	manager.Switch([]GroupID{group}, nil)
+}
+
+// Switch atomically leaves the groups in leave and joins those in join, e.g. when the node
+// is reassigned to another shard: if a group cannot be joined, no group is left or joined.
+// The groups in both lists are kept. Switch returns once the handlers of the messages of the
+// left groups returned, or GroupDrainTimeout passed.
+func (manager *GroupManager) Switch(leave, join []GroupID) error {
+	manager.mutex.Lock()
+	joined := make(map[GroupID]*membership)
+	for _, group := range join {
+		if _, ok := manager.groups[group]; ok {
+			continue
+		}
+		if _, ok := joined[group]; ok {
+			continue
+		}
+		receiver, err := manager.host.GroupReceiver(group)
+		if err != nil {
+			manager.mutex.Unlock()
+			for _, member := range joined {
+				member.receiver.Close()
+			}
+			return err
+		}
+		joined[group] = &membership{receiver: receiver, done: make(chan struct{})}
+	}
+	keep := make(map[GroupID]bool)
+	for _, group := range join {
+		keep[group] = true
+	}
+	var left []*membership
+	for _, group := range leave {
+		if member, ok := manager.groups[group]; ok && !keep[group] {
+			delete(manager.groups, group)
+			left = append(left, member)
+		}
+	}
+	for group, member := range joined {
+		ctx, cancel := context.WithCancel(context.Background())
+		member.cancel = cancel
+		manager.groups[group] = member
+		go manager.receive(ctx, group, member)
+	}
+	manager.mutex.Unlock()
+
+	for _, member := range left {
+		manager.drain(member)
+	}
+	return nil
+}
+
+// IsMember tells whether the node is a member of group.
+func (manager *GroupManager) IsMember(group GroupID) bool {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+	_, ok := manager.groups[group]
+	return ok
+}
+
+// Groups returns the groups the node is a member of.
+func (manager *GroupManager) Groups() []GroupID {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+	groups := make([]GroupID, 0, len(manager.groups))
+	for group := range manager.groups {
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// receive passes the messages of group to the handler until the node leaves the group.
+func (manager *GroupManager) receive(ctx context.Context, group GroupID, member *membership) {
+	defer close(member.done)
+	for {
+		msg, sender, err := member.receiver.Receive(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			time.Sleep(groupRetryDelay)
+			continue
+		}
+		// The membership is checked under the lock, so that no handler starts once the
+		// group is left and its handlers are being drained.
+		manager.mutex.RLock()
+		if manager.groups[group] != member {
+			manager.mutex.RUnlock()
+			return
+		}
+		member.handlers.Add(1)
+		manager.mutex.RUnlock()
+		handle := func() {
+			defer member.handlers.Done()
+			manager.handler(group, msg, sender)
+		}
+		if manager.Dispatcher == nil {
+			go handle()
+		} else if !manager.Dispatcher.Dispatch(msg, handle) {
+			member.handlers.Done()
+		}
+	}
+}
+
+// drain stops receiving the messages of a left group and waits for its handlers.
+func (manager *GroupManager) drain(member *membership) {
+	member.cancel()
+	<-member.done
+	member.receiver.Close()
+
+	drained := make(chan struct{})
+	go func() {
+		member.handlers.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(GroupDrainTimeout):
+		log.Warn("Handlers of a left group still running", "timeout", GroupDrainTimeout)
+	}
+}
@@ -0,0 +1,97 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+	"github.com/stretchr/testify/assert"
+)
+
+// testGroupHost is a host whose groups deliver the messages sent to their channel.
+type testGroupHost struct {
+	Host
+	groups map[GroupID]chan []byte
+}
+
+func (host *testGroupHost) GroupReceiver(group GroupID) (GroupReceiver, error) {
+	messages, ok := host.groups[group]
+	if !ok {
+		return nil, errors.New("unknown group")
+	}
+	return &testGroupReceiver{messages: messages}, nil
+}
+
+type testGroupReceiver struct {
+	messages chan []byte
+}
+
+func (receiver *testGroupReceiver) Close() error {
+	return nil
+}
+
+func (receiver *testGroupReceiver) Receive(ctx context.Context) ([]byte, libp2p_peer.ID, error) {
+	select {
+	case msg := <-receiver.messages:
+		return msg, libp2p_peer.ID("peer"), nil
+	case <-ctx.Done():
+		return nil, "", ctx.Err()
+	}
+}
+
+func TestGroupManagerSwitch(test *testing.T) {
+	host := &testGroupHost{groups: map[GroupID]chan []byte{
+		"shard1": make(chan []byte),
+		"shard2": make(chan []byte),
+	}}
+	handled := make(chan GroupID, 1)
+	manager := NewGroupManager(host, func(group GroupID, msg []byte, sender libp2p_peer.ID) {
+		handled <- group
+	})
+
+	assert.Nil(test, manager.Join("shard1"))
+	assert.Nil(test, manager.Join("shard1"))
+	assert.Equal(test, []GroupID{"shard1"}, manager.Groups())
+	host.groups["shard1"] <- []byte("msg")
+	assert.Equal(test, GroupID("shard1"), <-handled)
+
+	// A failed switch leaves the groups as they were.
+	assert.NotNil(test, manager.Switch([]GroupID{"shard1"}, []GroupID{"shard2", "shard3"}))
+	assert.True(test, manager.IsMember("shard1"))
+	assert.False(test, manager.IsMember("shard2"))
+
+	assert.Nil(test, manager.Switch([]GroupID{"shard1"}, []GroupID{"shard2"}))
+	assert.False(test, manager.IsMember("shard1"))
+	assert.True(test, manager.IsMember("shard2"))
+	select {
+	case host.groups["shard1"] <- []byte("msg"):
+		test.Error("message of a left group received")
+	case <-time.After(10 * time.Millisecond):
+	}
+	host.groups["shard2"] <- []byte("msg")
+	assert.Equal(test, GroupID("shard2"), <-handled)
+}
+
+func TestGroupManagerDrain(test *testing.T) {
+	host := &testGroupHost{groups: map[GroupID]chan []byte{"shard1": make(chan []byte)}}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	finished := false
+	manager := NewGroupManager(host, func(group GroupID, msg []byte, sender libp2p_peer.ID) {
+		close(started)
+		<-release
+		finished = true
+	})
+	assert.Nil(test, manager.Join("shard1"))
+	host.groups["shard1"] <- []byte("msg")
+	<-started
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+	}()
+	manager.Leave("shard1")
+	assert.True(test, finished)
+}
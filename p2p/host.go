@@ -28,4 +28,11 @@ type Host interface {
 	// If multiple receivers are created for the same group,
 	// a message sent to the group will be delivered to all of the receivers.
 	GroupReceiver(GroupID) (receiver GroupReceiver, err error)
+
+	// SendMessageToPeer sends a message to a single peer over a direct stream.
+	SendMessageToPeer(peer libp2p_peer.ID, msg []byte) error
+
+	// DirectReceiver returns the receiver of the messages sent to this host with
+	// SendMessageToPeer. All the calls return the same receiver.
+	DirectReceiver() GroupReceiver
 }
@@ -0,0 +1,38 @@
+package hostv2
+
+import (
+	libp2p_net "github.com/libp2p/go-libp2p-net"
+
+	"github.com/harmony-one/harmony/p2p"
+)
+
+// checkAccess closes conn if the access rules reject its peer. The peer ID is known once
+// the handshake authenticated it, which is as early as the connection can be rejected.
+func (host *HostV2) checkAccess(network libp2p_net.Network, conn libp2p_net.Conn) {
+	if host.access.Permits(conn.RemotePeer(), conn.RemoteMultiaddr()) {
+		return
+	}
+	host.logger.Info("Rejecting connection denied by the access rules",
+		"peer", conn.RemotePeer().Pretty(), "addr", conn.RemoteMultiaddr())
+	// Closing from within the notification would block the swarm.
+	go conn.Close()
+}
+
+// AccessRules returns the rules restricting the peers of the host.
+func (host *HostV2) AccessRules() p2p.AccessRules {
+	return host.access.Rules()
+}
+
+// SetAccessRules replaces the rules restricting the peers of the host, and closes the
+// connections the new rules reject.
+func (host *HostV2) SetAccessRules(rules p2p.AccessRules) error {
+	if err := host.access.SetRules(rules); err != nil {
+		return err
+	}
+	network := host.h.Network()
+	for _, conn := range network.Conns() {
+		host.checkAccess(network, conn)
+	}
+	host.logger.Info("Access rules changed", "rules", rules)
+	return nil
+}
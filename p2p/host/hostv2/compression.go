@@ -0,0 +1,30 @@
+package hostv2
+
+import (
+	"time"
+
+	p2p_host "github.com/harmony-one/harmony/p2p/host"
+)
+
+// CompressionFlagDays are when the known networks turn on the compression of their large
+// p2p messages. A message is gossiped over several hops, to peers the sender is not
+// connected with, so the compression is turned on at once for the whole network, once
+// all its nodes run a version which decompresses the messages. It stays off on the
+// networks without a flag day.
+// FIXME: set the flag days of mainnet and testnet once their nodes are upgraded.
+var CompressionFlagDays = map[string]time.Time{
+	// The nodes of a local network all run the same version.
+	"localnet": time.Unix(0, 0),
+}
+
+// enableCompressionAt turns on the compression of the large p2p messages at day, or right
+// away if day passed. A zero day leaves the compression off.
+func (host *HostV2) enableCompressionAt(day time.Time) {
+	if day.IsZero() {
+		return
+	}
+	time.AfterFunc(time.Until(day), func() {
+		host.logger.Info("Message compression enabled", "flagDay", day)
+		p2p_host.SetCompression(true)
+	})
+}
@@ -0,0 +1,124 @@
+package hostv2
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	libp2p_net "github.com/libp2p/go-libp2p-net"
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+
+	"github.com/harmony-one/harmony/p2p"
+)
+
+const (
+	// DirectProtocolID is the ID of the protocol of the direct messages between two peers.
+	DirectProtocolID = "/harmony/direct/0.0.1"
+
+	// a direct message is a p2p message, [messageType, contentSize, content]
+	directHeaderSize = 5
+	// direct messages are small ones, such as consensus votes
+	maxDirectMessageSize = 1 << 20
+	directTimeout        = 5 * time.Second
+	// received direct messages waiting for the receiver, the next ones are dropped
+	directQueueSize = 1024
+)
+
+// directMessage is a message received over a direct stream.
+type directMessage struct {
+	msg    []byte
+	sender libp2p_peer.ID
+}
+
+// SendMessageToPeer sends a message to a single peer over a new stream.
+func (host *HostV2) SendMessageToPeer(peer libp2p_peer.ID, msg []byte) error {
+	if len(msg) < directHeaderSize || len(msg) > maxDirectMessageSize {
+		return fmt.Errorf("invalid direct message size %d", len(msg))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), directTimeout)
+	defer cancel()
+	stream, err := host.h.NewStream(ctx, peer, DirectProtocolID)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	if err := stream.SetWriteDeadline(time.Now().Add(directTimeout)); err != nil {
+		return err
+	}
+	if _, err = stream.Write(msg); err != nil {
+		return err
+	}
+	if bandwidth := host.bandwidthCounter(); bandwidth != nil {
+		bandwidth.Sent(p2p.DirectTopic, peer, msg)
+	}
+	return nil
+}
+
+// handleDirectStream reads the message of a direct stream and queues it for the receiver.
+// The sender is the peer authenticated by the secure transport of the stream.
+func (host *HostV2) handleDirectStream(stream libp2p_net.Stream) {
+	defer stream.Close()
+	sender := stream.Conn().RemotePeer()
+	if err := stream.SetReadDeadline(time.Now().Add(directTimeout)); err != nil {
+		return
+	}
+	header := make([]byte, directHeaderSize)
+	if _, err := io.ReadFull(stream, header); err != nil {
+		host.logger.Debug("Cannot read direct message", "peer", sender, "error", err)
+		return
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxDirectMessageSize-directHeaderSize {
+		host.logger.Warn("Direct message too large", "peer", sender, "size", size)
+		return
+	}
+	msg := make([]byte, directHeaderSize+int(size))
+	copy(msg, header)
+	if _, err := io.ReadFull(stream, msg[directHeaderSize:]); err != nil {
+		host.logger.Debug("Cannot read direct message", "peer", sender, "error", err)
+		return
+	}
+
+	host.lock.Lock()
+	rateLimiter := host.rateLimiter
+	bandwidth := host.bandwidth
+	host.lock.Unlock()
+	if bandwidth != nil {
+		bandwidth.Received(p2p.DirectTopic, sender, msg)
+	}
+	if rateLimiter != nil && !rateLimiter.Allow(sender, msg) {
+		return
+	}
+	select {
+	case host.directChan <- directMessage{msg: msg, sender: sender}:
+	default:
+		host.logger.Warn("Direct message queue full, dropping message", "peer", sender)
+	}
+}
+
+// directReceiver receives the direct messages of a host.
+type directReceiver struct {
+	messages <-chan directMessage
+}
+
+// DirectReceiver returns the receiver of the messages sent to this host with SendMessageToPeer.
+func (host *HostV2) DirectReceiver() p2p.GroupReceiver {
+	return &directReceiver{messages: host.directChan}
+}
+
+// Close does nothing, as the direct messages keep coming for the other receivers.
+func (r *directReceiver) Close() error {
+	return nil
+}
+
+// Receive receives a direct message.
+func (r *directReceiver) Receive(ctx context.Context) (msg []byte, sender libp2p_peer.ID, err error) {
+	select {
+	case message := <-r.messages:
+		return message.msg, message.sender, nil
+	case <-ctx.Done():
+		return nil, libp2p_peer.ID(""), ctx.Err()
+	}
+}
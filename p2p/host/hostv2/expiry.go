@@ -0,0 +1,29 @@
+package hostv2
+
+import (
+	"time"
+
+	p2p_host "github.com/harmony-one/harmony/p2p/host"
+)
+
+// ExpiryFlagDays are when the known networks turn on the expiry time of their expiring
+// p2p messages. Like the compression, it is turned on at once for the whole network, once
+// all its nodes run a version which reads the expiry time, as a message is gossiped to
+// peers the sender is not connected with. It stays off on the networks without a flag day.
+// FIXME: set the flag days of mainnet and testnet once their nodes are upgraded.
+var ExpiryFlagDays = map[string]time.Time{
+	// The nodes of a local network all run the same version.
+	"localnet": time.Unix(0, 0),
+}
+
+// enableExpiryAt turns on the expiry time of the expiring p2p messages at day, or right
+// away if day passed. A zero day leaves the expiry time off.
+func (host *HostV2) enableExpiryAt(day time.Time) {
+	if day.IsZero() {
+		return
+	}
+	time.AfterFunc(time.Until(day), func() {
+		host.logger.Info("Message expiry enabled", "flagDay", day)
+		p2p_host.SetExpiry(true)
+	})
+}
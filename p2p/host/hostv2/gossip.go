@@ -0,0 +1,120 @@
+package hostv2
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+	libp2p_pubsub "github.com/libp2p/go-libp2p-pubsub"
+
+	"github.com/harmony-one/harmony/p2p"
+	p2p_host "github.com/harmony-one/harmony/p2p/host"
+)
+
+const (
+	// an expiring message is remembered until it expires, but no longer than this, so
+	// that a far expiry time cannot pin it in the cache
+	maxSeenDuration = 10 * time.Minute
+	// the expired messages are dropped from the cache every period
+	seenCleanupPeriod = time.Minute
+)
+
+// gossipFilter stops the gossip of the expired messages, and of the copies of an expiring
+// message seen before, e.g. published again by another peer. The messages without an
+// expiry time are left to the deduplication of pubsub, which recognizes the messages by
+// their author and sequence number only.
+type gossipFilter struct {
+	mutex   sync.Mutex
+	seen    map[[sha256.Size]byte]time.Time
+	cleaned time.Time
+	// topics the filter validates the messages of
+	topics map[string]bool
+	// returns the reputation manager scoring the authors of the messages, if any
+	reputation func() *p2p.Reputation
+
+	now func() time.Time
+}
+
+func newGossipFilter() *gossipFilter {
+	return &gossipFilter{
+		seen:   make(map[[sha256.Size]byte]time.Time),
+		topics: make(map[string]bool),
+		now:    time.Now,
+	}
+}
+
+// register makes pubsub validate the messages of topic with the filter, before they are
+// delivered or forwarded.
+func (filter *gossipFilter) register(pubsub pubsub, topic string) error {
+	filter.mutex.Lock()
+	defer filter.mutex.Unlock()
+	if filter.topics[topic] {
+		return nil
+	}
+	if err := pubsub.RegisterTopicValidator(topic, filter.validate); err != nil {
+		return err
+	}
+	filter.topics[topic] = true
+	return nil
+}
+
+// validate tells whether to deliver and forward msg. pubsub only validates the messages
+// whose signature it verified, so their author is known: the messages of the banned peers
+// are dropped, and the author of a malformed message is penalized.
+func (filter *gossipFilter) validate(ctx context.Context, msg *libp2p_pubsub.Message) bool {
+	var reputation *p2p.Reputation
+	if filter.reputation != nil {
+		reputation = filter.reputation()
+	}
+	if reputation != nil {
+		author, err := libp2p_peer.IDFromBytes(msg.GetFrom())
+		if err != nil {
+			return false
+		}
+		if reputation.IsBanned(author) {
+			return false
+		}
+		if _, err := p2p_host.P2pMessageRawContent(msg.Data); err != nil {
+			reputation.Penalize(author, p2p.OffenseMalformedMessage)
+			return false
+		}
+	}
+	return filter.accept(msg.Data)
+}
+
+// accept tells whether data is neither expired nor a copy of an expiring message seen before.
+func (filter *gossipFilter) accept(data []byte) bool {
+	expiry, ok := p2p_host.P2pMessageExpiry(data)
+	if !ok {
+		return true
+	}
+	now := filter.now()
+	if now.After(expiry) {
+		metrics.GetOrRegisterCounter("p2p/gossip/expired", nil).Inc(1)
+		return false
+	}
+	if limit := now.Add(maxSeenDuration); expiry.After(limit) {
+		expiry = limit
+	}
+	key := sha256.Sum256(data)
+
+	filter.mutex.Lock()
+	defer filter.mutex.Unlock()
+	if now.Sub(filter.cleaned) >= seenCleanupPeriod {
+		for key, until := range filter.seen {
+			if now.After(until) {
+				delete(filter.seen, key)
+			}
+		}
+		filter.cleaned = now
+	}
+	if until, seen := filter.seen[key]; seen && !now.After(until) {
+		metrics.GetOrRegisterCounter("p2p/gossip/duplicate", nil).Inc(1)
+		return false
+	}
+	filter.seen[key] = expiry
+	return true
+}
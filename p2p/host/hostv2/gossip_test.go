@@ -0,0 +1,73 @@
+package hostv2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+
+	"github.com/harmony-one/harmony/p2p"
+	p2p_host "github.com/harmony-one/harmony/p2p/host"
+)
+
+func TestGossipFilter(t *testing.T) {
+	p2p_host.SetExpiry(true)
+	defer p2p_host.SetExpiry(false)
+	now := time.Now()
+	filter := newGossipFilter()
+	filter.now = func() time.Time { return now }
+
+	message := p2p_host.ConstructExpiringP2pMessage([]byte{0, 1, 2}, time.Minute)
+	if !filter.accept(message) {
+		t.Errorf("expected a fresh message accepted")
+	}
+	if filter.accept(message) {
+		t.Errorf("expected a copy of the message dropped")
+	}
+	other := p2p_host.ConstructExpiringP2pMessage([]byte{0, 1, 3}, time.Minute)
+	if !filter.accept(other) {
+		t.Errorf("expected another message accepted")
+	}
+
+	plain := p2p_host.ConstructP2pMessage([]byte{0, 1, 2})
+	if !filter.accept(plain) || !filter.accept(plain) {
+		t.Errorf("expected the messages without expiry time left to pubsub")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if filter.accept(message) {
+		t.Errorf("expected an expired message dropped")
+	}
+	filter.accept(p2p_host.ConstructExpiringP2pMessage([]byte{4}, time.Minute))
+	if len(filter.seen) != 1 {
+		t.Errorf("expected the expired messages forgotten, %d remembered", len(filter.seen))
+	}
+}
+
+func TestGossipFilterReputation(t *testing.T) {
+	author, err := libp2p_peer.IDB58Decode("QmYyQSo1c1Ym7orWxLYvCrM2EmxFTANf8wXmmE7DWjhx5N")
+	if err != nil {
+		t.Fatalf("cannot decode peer ID: %v", err)
+	}
+	reputation := p2p.NewReputation()
+	filter := newGossipFilter()
+	filter.reputation = func() *p2p.Reputation { return reputation }
+
+	valid := pubsubMessage(author, p2p_host.ConstructP2pMessage([]byte{0, 1, 2}))
+	if !filter.validate(context.Background(), valid) {
+		t.Errorf("expected a valid message accepted")
+	}
+	if filter.validate(context.Background(), pubsubMessage(author, []byte{17, 0})) {
+		t.Errorf("expected a malformed message dropped")
+	}
+	if reputation.Score(author) >= 0 {
+		t.Errorf("expected the author of a malformed message penalized")
+	}
+	for !reputation.IsBanned(author) {
+		reputation.Penalize(author, p2p.OffenseMalformedMessage)
+	}
+	if filter.validate(context.Background(), valid) {
+		t.Errorf("expected the message of a banned author dropped")
+	}
+}
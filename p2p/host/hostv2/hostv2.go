@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/log"
 
@@ -15,9 +16,11 @@ import (
 	libp2p "github.com/libp2p/go-libp2p"
 	libp2p_crypto "github.com/libp2p/go-libp2p-crypto"
 	libp2p_host "github.com/libp2p/go-libp2p-host"
+	libp2p_net "github.com/libp2p/go-libp2p-net"
 	libp2p_peer "github.com/libp2p/go-libp2p-peer"
 	libp2p_peerstore "github.com/libp2p/go-libp2p-peerstore"
 	libp2p_pubsub "github.com/libp2p/go-libp2p-pubsub"
+	secio "github.com/libp2p/go-libp2p-secio"
 	ma "github.com/multiformats/go-multiaddr"
 )
 
@@ -36,6 +39,8 @@ const (
 type pubsub interface {
 	Publish(topic string, data []byte) error
 	Subscribe(topic string, opts ...libp2p_pubsub.SubOpt) (*libp2p_pubsub.Subscription, error)
+	ListPeers(topic string) []libp2p_peer.ID
+	RegisterTopicValidator(topic string, val libp2p_pubsub.Validator, opts ...libp2p_pubsub.ValidatorOpt) error
 }
 
 // HostV2 is the version 2 p2p host
@@ -46,6 +51,19 @@ type HostV2 struct {
 	priKey libp2p_crypto.PrivKey
 	lock   sync.Mutex
 
+	// drops the messages over the budgets of their senders, if set
+	rateLimiter *p2p.RateLimiter
+	// counts the traffic, if set
+	bandwidth *p2p.BandwidthCounter
+	// scores the authors of the gossiped messages, if set
+	reputation *p2p.Reputation
+	// stops the gossip of the expired and duplicate messages, if set
+	gossip *gossipFilter
+	// messages received over direct streams
+	directChan chan directMessage
+	// the peers the host accepts connections with
+	access *p2p.AccessList
+
 	//incomingPeers []p2p.Peer // list of incoming Peers. TODO: fixed number incoming
 	//outgoingPeers []p2p.Peer // list of outgoing Peers. TODO: fixed number of outgoing
 
@@ -56,10 +74,13 @@ type HostV2 struct {
 // SendMessageToGroups sends a message to one or more multicast groups.
 func (host *HostV2) SendMessageToGroups(groups []p2p.GroupID, msg []byte) error {
 	var error error
+	bandwidth := host.bandwidthCounter()
 	for _, group := range groups {
 		err := host.pubsub.Publish(string(group), msg)
 		if err != nil {
 			error = err
+		} else if bandwidth != nil {
+			bandwidth.Sent(string(group), "", msg)
 		}
 	}
 	return error
@@ -73,7 +94,10 @@ type subscription interface {
 
 // GroupReceiverImpl is a multicast group receiver implementation.
 type GroupReceiverImpl struct {
-	sub subscription
+	sub         subscription
+	group       p2p.GroupID
+	rateLimiter *p2p.RateLimiter
+	bandwidth   *p2p.BandwidthCounter
 }
 
 // Close closes this receiver.
@@ -90,12 +114,20 @@ func (r *GroupReceiverImpl) Receive(ctx context.Context) (
 	if r.sub == nil {
 		return nil, libp2p_peer.ID(""), fmt.Errorf("GroupReceiver has been closed")
 	}
-	m, err := r.sub.Next(ctx)
-	if err == nil {
-		msg = m.Data
+	for {
+		m, err := r.sub.Next(ctx)
+		if err != nil {
+			return nil, libp2p_peer.ID(""), err
+		}
 		sender = libp2p_peer.ID(m.From)
+		if r.bandwidth != nil {
+			r.bandwidth.Received(string(r.group), sender, m.Data)
+		}
+		if r.rateLimiter != nil && !r.rateLimiter.Allow(sender, m.Data) {
+			continue
+		}
+		return m.Data, sender, nil
 	}
-	return msg, sender, err
 }
 
 // GroupReceiver returns a receiver of messages sent to a multicast group.
@@ -103,11 +135,71 @@ func (r *GroupReceiverImpl) Receive(ctx context.Context) (
 func (host *HostV2) GroupReceiver(group p2p.GroupID) (
 	receiver p2p.GroupReceiver, err error,
 ) {
+	if host.gossip != nil {
+		if err := host.gossip.register(host.pubsub, string(group)); err != nil {
+			return nil, err
+		}
+	}
 	sub, err := host.pubsub.Subscribe(string(group))
 	if err != nil {
 		return nil, err
 	}
-	return &GroupReceiverImpl{sub: sub}, nil
+	host.lock.Lock()
+	rateLimiter := host.rateLimiter
+	bandwidth := host.bandwidth
+	host.lock.Unlock()
+	return &GroupReceiverImpl{sub: sub, group: group, rateLimiter: rateLimiter, bandwidth: bandwidth}, nil
+}
+
+// GroupPeers returns the connected peers subscribed to group.
+func (host *HostV2) GroupPeers(group p2p.GroupID) []libp2p_peer.ID {
+	return host.pubsub.ListPeers(string(group))
+}
+
+// SetRateLimiter makes the group receivers created from now on drop the messages which
+// exceed the budgets of their senders.
+func (host *HostV2) SetRateLimiter(rateLimiter *p2p.RateLimiter) {
+	host.lock.Lock()
+	defer host.lock.Unlock()
+	host.rateLimiter = rateLimiter
+}
+
+// SetReputation makes the host score the authors of the gossiped messages with reputation,
+// and stop gossiping the messages of the banned peers.
+func (host *HostV2) SetReputation(reputation *p2p.Reputation) {
+	host.lock.Lock()
+	defer host.lock.Unlock()
+	host.reputation = reputation
+}
+
+// peerReputation returns the reputation manager of the host, if any.
+func (host *HostV2) peerReputation() *p2p.Reputation {
+	host.lock.Lock()
+	defer host.lock.Unlock()
+	return host.reputation
+}
+
+// SetBandwidthCounter makes the host count its traffic with counter, including that of
+// the group receivers created from now on.
+func (host *HostV2) SetBandwidthCounter(counter *p2p.BandwidthCounter) {
+	host.lock.Lock()
+	defer host.lock.Unlock()
+	host.bandwidth = counter
+}
+
+// bandwidthCounter returns the bandwidth counter of the host, if any.
+func (host *HostV2) bandwidthCounter() *p2p.BandwidthCounter {
+	host.lock.Lock()
+	defer host.lock.Unlock()
+	return host.bandwidth
+}
+
+// BandwidthStats returns the traffic counted by the host, if counting.
+func (host *HostV2) BandwidthStats() p2p.BandwidthStats {
+	if counter := host.bandwidthCounter(); counter != nil {
+		return counter.Stats()
+	}
+	return p2p.BandwidthStats{}
 }
 
 // AddPeer add p2p.Peer into Peerstore
@@ -156,19 +248,61 @@ func (host *HostV2) Peerstore() libp2p_peerstore.Peerstore {
 
 // New creates a host for p2p communication
 func New(self *p2p.Peer, priKey libp2p_crypto.PrivKey) *HostV2 {
+	return NewWithConfig(self, priKey, Config{})
+}
+
+// Config is the optional configuration of a host.
+type Config struct {
+	NAT NATConfig
+	// Insecure turns off the encryption and authentication of the connections, for local
+	// test networks only. An insecure host cannot connect to the secure ones.
+	Insecure bool
+	// Access restricts the peers the host connects with.
+	Access p2p.AccessRules
+	// CompressionFlagDay is when the network turns on the compression of the large
+	// messages, see CompressionFlagDays. The compression stays off if it is zero.
+	CompressionFlagDay time.Time
+	// ExpiryFlagDay is when the network turns on the expiry time of the expiring
+	// messages, see ExpiryFlagDays. The expiry time stays off if it is zero.
+	ExpiryFlagDay time.Time
+}
+
+// NewWithConfig creates a host for p2p communication configured by config.
+func NewWithConfig(self *p2p.Peer, priKey libp2p_crypto.PrivKey, config Config) *HostV2 {
 	listenAddr, err := ma.NewMultiaddr(fmt.Sprintf("/ip4/0.0.0.0/tcp/%s", self.Port))
 	logger := utils.GetLogInstance()
 	if err != nil {
 		logger.Error("New MA Error", "IP", self.IP, "Port", self.Port)
 		return nil
 	}
+	access, err := p2p.NewAccessList(config.Access)
+	if err != nil {
+		logger.Error("Invalid access rules", "error", err)
+		return nil
+	}
 	// TODO – use WithCancel for orderly host teardown (which we don't have yet)
 	ctx := context.Background()
-	p2pHost, err := libp2p.New(ctx,
-		libp2p.ListenAddrs(listenAddr), libp2p.Identity(priKey),
-	)
+	opts := []libp2p.Option{libp2p.ListenAddrs(listenAddr), libp2p.Identity(priKey)}
+	if config.Insecure {
+		logger.Warn("Connections are NOT encrypted nor authenticated, use on local test networks only")
+		opts = append(opts, libp2p.NoSecurity)
+	} else {
+		// Every connection is encrypted, and authenticated with the identity keys of
+		// both ends, which are also the keys the peer IDs derive from.
+		opts = append(opts, libp2p.Security(secio.ID, secio.New))
+	}
+	opts = append(opts, config.NAT.options()...)
+	p2pHost, err := libp2p.New(ctx, opts...)
 	catchError(err)
-	pubsub, err := libp2p_pubsub.NewGossipSub(ctx, p2pHost)
+	// Every message is signed with the key of the host, and a message without a valid
+	// signature of its author is dropped before it is delivered or forwarded, so that
+	// the sender reported by GroupReceiver is authenticated. This version of pubsub drops
+	// such a message without telling the peer it came from, so no peer is scored for it:
+	// the peers are scored on the messages of their own, see gossipFilter.validate.
+	pubsub, err := libp2p_pubsub.NewGossipSub(ctx, p2pHost,
+		libp2p_pubsub.WithMessageSigning(true),
+		libp2p_pubsub.WithStrictSignatureVerification(true),
+	)
 	// pubsub, err := libp2p_pubsub.NewFloodSub(ctx, p2pHost)
 	catchError(err)
 
@@ -181,7 +315,17 @@ func New(self *p2p.Peer, priKey libp2p_crypto.PrivKey) *HostV2 {
 		self:   *self,
 		priKey: priKey,
 		logger: logger.New("hostID", p2pHost.ID().Pretty()),
+
+		directChan: make(chan directMessage, directQueueSize),
+		access:     access,
+		gossip:     newGossipFilter(),
 	}
+	h.gossip.reputation = h.peerReputation
+	p2pHost.Network().Notify(&libp2p_net.NotifyBundle{ConnectedF: h.checkAccess})
+	p2pHost.SetStreamHandler(DirectProtocolID, h.handleDirectStream)
+	go h.maintainRelays(config.NAT.Relays)
+	h.enableCompressionAt(config.CompressionFlagDay)
+	h.enableExpiryAt(config.ExpiryFlagDay)
 
 	h.logger.Debug("HostV2 is up!",
 		"port", self.Port, "id", p2pHost.ID().Pretty(), "addr", listenAddr)
@@ -5,13 +5,16 @@ import (
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/golang/mock/gomock"
 	libp2p_peer "github.com/libp2p/go-libp2p-peer"
 	libp2p_pubsub "github.com/libp2p/go-libp2p-pubsub"
 	libp2p_pubsub_pb "github.com/libp2p/go-libp2p-pubsub/pb"
 
 	"github.com/harmony-one/harmony/p2p"
+	p2p_host "github.com/harmony-one/harmony/p2p/host"
 	mock "github.com/harmony-one/harmony/p2p/host/hostv2/mock"
 )
 
@@ -136,3 +139,31 @@ func TestHostV2_GroupReceiver(t *testing.T) {
 		}
 	})
 }
+
+func TestFlagDays(t *testing.T) {
+	tests := []struct {
+		name    string
+		enable  func(host *HostV2, day time.Time)
+		enabled func() bool
+		reset   func(enabled bool)
+	}{
+		{"compression", (*HostV2).enableCompressionAt, p2p_host.CompressionEnabled, p2p_host.SetCompression},
+		{"expiry", (*HostV2).enableExpiryAt, p2p_host.ExpiryEnabled, p2p_host.SetExpiry},
+	}
+	for _, test := range tests {
+		host := &HostV2{logger: log.New()}
+		test.enable(host, time.Time{})
+		time.Sleep(10 * time.Millisecond)
+		if test.enabled() {
+			t.Errorf("%s: expected off without a flag day", test.name)
+		}
+		test.enable(host, time.Now().Add(-time.Hour))
+		for deadline := time.Now().Add(time.Second); !test.enabled(); time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Errorf("%s: expected on past the flag day", test.name)
+				break
+			}
+		}
+		test.reset(false)
+	}
+}
@@ -7,6 +7,7 @@ package mock_hostv2
 import (
 	context "context"
 	gomock "github.com/golang/mock/gomock"
+	go_libp2p_peer "github.com/libp2p/go-libp2p-peer"
 	go_libp2p_pubsub "github.com/libp2p/go-libp2p-pubsub"
 	reflect "reflect"
 )
@@ -68,6 +69,39 @@ func (mr *MockpubsubMockRecorder) Subscribe(topic interface{}, opts ...interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*Mockpubsub)(nil).Subscribe), varargs...)
 }
 
+// ListPeers mocks base method
+func (m *Mockpubsub) ListPeers(topic string) []go_libp2p_peer.ID {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPeers", topic)
+	ret0, _ := ret[0].([]go_libp2p_peer.ID)
+	return ret0
+}
+
+// ListPeers indicates an expected call of ListPeers
+func (mr *MockpubsubMockRecorder) ListPeers(topic interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPeers", reflect.TypeOf((*Mockpubsub)(nil).ListPeers), topic)
+}
+
+// RegisterTopicValidator mocks base method
+func (m *Mockpubsub) RegisterTopicValidator(topic string, val go_libp2p_pubsub.Validator, opts ...go_libp2p_pubsub.ValidatorOpt) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{topic, val}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RegisterTopicValidator", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterTopicValidator indicates an expected call of RegisterTopicValidator
+func (mr *MockpubsubMockRecorder) RegisterTopicValidator(topic, val interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{topic, val}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterTopicValidator", reflect.TypeOf((*Mockpubsub)(nil).RegisterTopicValidator), varargs...)
+}
+
 // Mocksubscription is a mock of subscription interface
 type Mocksubscription struct {
 	ctrl     *gomock.Controller
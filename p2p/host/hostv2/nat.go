@@ -0,0 +1,112 @@
+package hostv2
+
+import (
+	"context"
+	"time"
+
+	libp2p "github.com/libp2p/go-libp2p"
+	circuit "github.com/libp2p/go-libp2p-circuit"
+	libp2p_net "github.com/libp2p/go-libp2p-net"
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+	libp2p_peerstore "github.com/libp2p/go-libp2p-peerstore"
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr-net"
+
+	"github.com/harmony-one/harmony/p2p"
+)
+
+// NATConfig tells how a host behind a NAT makes itself reachable by the peers.
+type NATConfig struct {
+	// PortMap asks the gateway to forward the listen port to the host, over UPnP or NAT-PMP.
+	PortMap bool
+	// RelayHop makes the host relay the connections of the peers which cannot accept
+	// connections themselves. It needs a public address.
+	RelayHop bool
+	// Relays are relay peers the host stays connected to and advertises addresses through,
+	// so that the peers can connect to it when it cannot accept connections.
+	Relays []libp2p_peerstore.PeerInfo
+}
+
+// the connections to the relays are checked, and the reachability reported, every period
+const relayCheckPeriod = time.Minute
+
+// options returns the libp2p options setting up config.
+func (config NATConfig) options() []libp2p.Option {
+	var opts []libp2p.Option
+	if config.PortMap {
+		opts = append(opts, libp2p.NATPortMap())
+	}
+	if config.RelayHop {
+		opts = append(opts, libp2p.EnableRelay(circuit.OptHop))
+	} else if len(config.Relays) > 0 {
+		opts = append(opts, libp2p.EnableRelay())
+	}
+	if len(config.Relays) > 0 {
+		var relayAddrs []ma.Multiaddr
+		for _, relay := range config.Relays {
+			for _, addr := range relay.Addrs {
+				relayAddr, err := ma.NewMultiaddr("/ipfs/" + relay.ID.Pretty() + "/p2p-circuit")
+				if err != nil {
+					continue
+				}
+				relayAddrs = append(relayAddrs, addr.Encapsulate(relayAddr))
+			}
+		}
+		opts = append(opts, libp2p.AddrsFactory(func(addrs []ma.Multiaddr) []ma.Multiaddr {
+			return append(addrs, relayAddrs...)
+		}))
+	}
+	return opts
+}
+
+// maintainRelays reconnects the relays the host lost the connection to, and logs the
+// reachability of the host whenever it changes.
+func (host *HostV2) maintainRelays(relays []libp2p_peerstore.PeerInfo) {
+	var status p2p.ReachabilityStatus
+	for {
+		for _, relay := range relays {
+			if host.h.Network().Connectedness(relay.ID) == libp2p_net.Connected {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), directTimeout)
+			if err := host.h.Connect(ctx, relay); err != nil {
+				host.logger.Warn("Cannot connect to relay", "relay", relay.ID.Pretty(), "error", err)
+			}
+			cancel()
+		}
+		reachability := host.Reachability()
+		if reachability.Status != status {
+			status = reachability.Status
+			host.logger.Info("Reachability changed", "status", status,
+				"publicAddrs", reachability.PublicAddrs, "relayAddrs", reachability.RelayAddrs)
+		}
+		time.Sleep(relayCheckPeriod)
+	}
+}
+
+// Reachability tells how the peers can reach the host: through its public addresses,
+// including those mapped on the gateway, or else through the relays it is connected to.
+func (host *HostV2) Reachability() p2p.Reachability {
+	reachability := p2p.Reachability{Status: p2p.ReachabilityPrivate}
+	for _, addr := range host.h.Addrs() {
+		if _, err := addr.ValueForProtocol(circuit.P_CIRCUIT); err == nil {
+			relay, err := addr.ValueForProtocol(ma.P_IPFS)
+			if err != nil {
+				continue
+			}
+			id, err := libp2p_peer.IDB58Decode(relay)
+			if err != nil || host.h.Network().Connectedness(id) != libp2p_net.Connected {
+				continue
+			}
+			reachability.RelayAddrs = append(reachability.RelayAddrs, addr.String())
+		} else if manet.IsPublicAddr(addr) {
+			reachability.PublicAddrs = append(reachability.PublicAddrs, addr.String())
+		}
+	}
+	if len(reachability.PublicAddrs) > 0 {
+		reachability.Status = p2p.ReachabilityPublic
+	} else if len(reachability.RelayAddrs) > 0 {
+		reachability.Status = p2p.ReachabilityRelayed
+	}
+	return reachability
+}
@@ -0,0 +1,350 @@
+// Package memory is an in-process p2p network, so that consensus and sync can be tested
+// without libp2p, with controllable latency, message loss and network partitions.
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	libp2p_host "github.com/libp2p/go-libp2p-host"
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+
+	"github.com/harmony-one/harmony/p2p"
+)
+
+// Errors of the in-memory network
+var (
+	ErrUnknownPeer = errors.New("unknown peer")
+	ErrPartitioned = errors.New("peer in another partition")
+	ErrClosed      = errors.New("host closed")
+)
+
+// Network connects the in-memory hosts. A message is delivered after the latency, plus up
+// to the jitter, unless it is lost, which happens with the drop rate, or the sender and the
+// receiver are in different partitions. The losses and the jitter are drawn from a source
+// seeded at creation, so that a test sending the same messages loses the same ones.
+type Network struct {
+	mutex      sync.Mutex
+	hosts      map[libp2p_peer.ID]*Host
+	partitions map[libp2p_peer.ID]int
+	random     *rand.Rand
+	latency    time.Duration
+	jitter     time.Duration
+	dropRate   float64
+}
+
+// NewNetwork creates an in-memory network drawing its losses from seed.
+func NewNetwork(seed int64) *Network {
+	return &Network{
+		hosts:      make(map[libp2p_peer.ID]*Host),
+		partitions: make(map[libp2p_peer.ID]int),
+		random:     rand.New(rand.NewSource(seed)),
+	}
+}
+
+// SetLatency delays the delivery of every message by latency, plus up to jitter.
+func (network *Network) SetLatency(latency, jitter time.Duration) {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+	network.latency, network.jitter = latency, jitter
+}
+
+// SetDropRate makes the network lose the given fraction of the messages, from 0 to 1.
+func (network *Network) SetDropRate(dropRate float64) {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+	network.dropRate = dropRate
+}
+
+// Partition splits the network: the hosts of each group only reach the hosts of the same
+// group, and the hosts of no group only reach each other.
+func (network *Network) Partition(groups ...[]libp2p_peer.ID) {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+	network.partitions = make(map[libp2p_peer.ID]int)
+	for i, group := range groups {
+		for _, id := range group {
+			network.partitions[id] = i + 1
+		}
+	}
+}
+
+// Heal joins the partitions back.
+func (network *Network) Heal() {
+	network.Partition()
+}
+
+// NewHost adds a host for self to the network. self is given a peer ID if it has none.
+func (network *Network) NewHost(self *p2p.Peer) *Host {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+	if self.PeerID == "" {
+		self.PeerID = libp2p_peer.ID(fmt.Sprintf("memory-%d", len(network.hosts)))
+	}
+	host := &Host{
+		network:   network,
+		self:      *self,
+		groups:    make(map[p2p.GroupID][]*receiver),
+		direct:    newReceiver(),
+		connected: make(map[libp2p_peer.ID]bool),
+	}
+	network.hosts[self.PeerID] = host
+	return host
+}
+
+// route tells whether a message from one host reaches another, and after how long.
+func (network *Network) route(from, to libp2p_peer.ID) (delay time.Duration, ok bool, err error) {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+	if _, ok := network.hosts[to]; !ok {
+		return 0, false, ErrUnknownPeer
+	}
+	if network.partitions[from] != network.partitions[to] {
+		return 0, false, ErrPartitioned
+	}
+	if from == to {
+		return 0, true, nil
+	}
+	if network.dropRate > 0 && network.random.Float64() < network.dropRate {
+		return 0, false, nil
+	}
+	delay = network.latency
+	if network.jitter > 0 {
+		delay += time.Duration(network.random.Int63n(int64(network.jitter)))
+	}
+	return delay, true, nil
+}
+
+// send delivers msg from a host to the receivers of another host.
+func (network *Network) send(from, to libp2p_peer.ID, receivers []*receiver, msg []byte) error {
+	delay, ok, err := network.route(from, to)
+	if !ok {
+		return err
+	}
+	deliver := func() {
+		for _, r := range receivers {
+			r.push(msg, from)
+		}
+	}
+	if delay == 0 {
+		deliver()
+	} else {
+		time.AfterFunc(delay, deliver)
+	}
+	return nil
+}
+
+// peers returns the hosts of the network.
+func (network *Network) peers() []*Host {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+	hosts := make([]*Host, 0, len(network.hosts))
+	for _, host := range network.hosts {
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// Host is a p2p.Host of an in-memory network. Like the libp2p hosts, it receives its own
+// messages to the groups it is subscribed to.
+type Host struct {
+	network *Network
+	self    p2p.Peer
+
+	mutex     sync.Mutex
+	groups    map[p2p.GroupID][]*receiver
+	direct    *receiver
+	connected map[libp2p_peer.ID]bool
+	closed    bool
+}
+
+// GetSelfPeer gets self peer
+func (host *Host) GetSelfPeer() p2p.Peer {
+	return host.self
+}
+
+// Close removes the host from the network.
+func (host *Host) Close() error {
+	host.network.mutex.Lock()
+	delete(host.network.hosts, host.self.PeerID)
+	host.network.mutex.Unlock()
+	host.mutex.Lock()
+	defer host.mutex.Unlock()
+	host.closed = true
+	for _, receivers := range host.groups {
+		for _, r := range receivers {
+			r.Close()
+		}
+	}
+	host.direct.Close()
+	return nil
+}
+
+// AddPeer records p as a peer of the host.
+func (host *Host) AddPeer(p *p2p.Peer) error {
+	if p.PeerID == "" {
+		return fmt.Errorf("AddPeer error: peerID is empty")
+	}
+	host.mutex.Lock()
+	defer host.mutex.Unlock()
+	host.connected[p.PeerID] = true
+	return nil
+}
+
+// GetID returns the peer ID of the host.
+func (host *Host) GetID() libp2p_peer.ID {
+	return host.self.PeerID
+}
+
+// GetP2PHost returns nil, as there is no libp2p host behind.
+func (host *Host) GetP2PHost() libp2p_host.Host {
+	return nil
+}
+
+// GetPeerCount returns the number of the other hosts of the network.
+func (host *Host) GetPeerCount() int {
+	return len(host.network.peers()) - 1
+}
+
+// ConnectHostPeer records peer as a peer of the host.
+func (host *Host) ConnectHostPeer(peer p2p.Peer) {
+	host.AddPeer(&peer)
+}
+
+// SendMessageToGroups sends a message to the hosts subscribed to the groups.
+func (host *Host) SendMessageToGroups(groups []p2p.GroupID, msg []byte) error {
+	if host.isClosed() {
+		return ErrClosed
+	}
+	for _, peer := range host.network.peers() {
+		var receivers []*receiver
+		peer.mutex.Lock()
+		for _, group := range groups {
+			receivers = append(receivers, peer.groups[group]...)
+		}
+		peer.mutex.Unlock()
+		if len(receivers) > 0 {
+			// A lost or partitioned message to a group is not an error, as with gossip.
+			host.network.send(host.self.PeerID, peer.self.PeerID, receivers, msg)
+		}
+	}
+	return nil
+}
+
+// GroupReceiver returns a new receiver of the messages sent to group.
+func (host *Host) GroupReceiver(group p2p.GroupID) (p2p.GroupReceiver, error) {
+	host.mutex.Lock()
+	defer host.mutex.Unlock()
+	if host.closed {
+		return nil, ErrClosed
+	}
+	r := newReceiver()
+	r.onClose = func() { host.unsubscribe(group, r) }
+	host.groups[group] = append(host.groups[group], r)
+	return r, nil
+}
+
+func (host *Host) unsubscribe(group p2p.GroupID, r *receiver) {
+	host.mutex.Lock()
+	defer host.mutex.Unlock()
+	receivers := host.groups[group]
+	for i := range receivers {
+		if receivers[i] == r {
+			host.groups[group] = append(receivers[:i:i], receivers[i+1:]...)
+			return
+		}
+	}
+}
+
+// SendMessageToPeer sends a message to a single host, failing if it is unknown or in
+// another partition.
+func (host *Host) SendMessageToPeer(peer libp2p_peer.ID, msg []byte) error {
+	if host.isClosed() {
+		return ErrClosed
+	}
+	host.network.mutex.Lock()
+	target, ok := host.network.hosts[peer]
+	host.network.mutex.Unlock()
+	if !ok {
+		return ErrUnknownPeer
+	}
+	return host.network.send(host.self.PeerID, peer, []*receiver{target.direct}, msg)
+}
+
+// DirectReceiver returns the receiver of the messages sent to this host with SendMessageToPeer.
+func (host *Host) DirectReceiver() p2p.GroupReceiver {
+	return host.direct
+}
+
+func (host *Host) isClosed() bool {
+	host.mutex.Lock()
+	defer host.mutex.Unlock()
+	return host.closed
+}
+
+// message is a message waiting for its receiver.
+type message struct {
+	msg    []byte
+	sender libp2p_peer.ID
+}
+
+// receiver queues the messages delivered to it, without limit, so that the senders never
+// wait for it.
+type receiver struct {
+	mutex   sync.Mutex
+	queue   []message
+	notify  chan struct{}
+	closed  chan struct{}
+	once    sync.Once
+	onClose func()
+}
+
+func newReceiver() *receiver {
+	return &receiver{notify: make(chan struct{}, 1), closed: make(chan struct{})}
+}
+
+func (r *receiver) push(msg []byte, sender libp2p_peer.ID) {
+	r.mutex.Lock()
+	r.queue = append(r.queue, message{msg: msg, sender: sender})
+	r.mutex.Unlock()
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Close closes the receiver.
+func (r *receiver) Close() error {
+	r.once.Do(func() {
+		close(r.closed)
+		if r.onClose != nil {
+			go r.onClose()
+		}
+	})
+	return nil
+}
+
+// Receive receives a message.
+func (r *receiver) Receive(ctx context.Context) ([]byte, libp2p_peer.ID, error) {
+	for {
+		r.mutex.Lock()
+		if len(r.queue) > 0 {
+			m := r.queue[0]
+			r.queue = r.queue[1:]
+			r.mutex.Unlock()
+			return m.msg, m.sender, nil
+		}
+		r.mutex.Unlock()
+		select {
+		case <-r.notify:
+		case <-r.closed:
+			return nil, "", ErrClosed
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		}
+	}
+}
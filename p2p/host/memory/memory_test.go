@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/harmony-one/harmony/p2p"
+)
+
+const testGroup = p2p.GroupID("harmony/test")
+
+// receive returns the next message of r, or nil if none comes shortly.
+func receive(r p2p.GroupReceiver) []byte {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	msg, _, err := r.Receive(ctx)
+	if err != nil {
+		return nil
+	}
+	return msg
+}
+
+func TestGroupMessages(t *testing.T) {
+	network := NewNetwork(1)
+	a := network.NewHost(&p2p.Peer{})
+	b := network.NewHost(&p2p.Peer{})
+	c := network.NewHost(&p2p.Peer{})
+	receiverA, _ := a.GroupReceiver(testGroup)
+	receiverB, _ := b.GroupReceiver(testGroup)
+	receiverC, _ := c.GroupReceiver(testGroup)
+
+	assert.Nil(t, a.SendMessageToGroups([]p2p.GroupID{testGroup}, []byte("hello")))
+	assert.Equal(t, []byte("hello"), receive(receiverA))
+	assert.Equal(t, []byte("hello"), receive(receiverB))
+	msg, sender, err := receiverC.Receive(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("hello"), msg)
+	assert.Equal(t, a.GetID(), sender)
+
+	// A partitioned host misses the messages of the other partition.
+	network.Partition([]libp2p_peer.ID{c.GetID()})
+	assert.Nil(t, a.SendMessageToGroups([]p2p.GroupID{testGroup}, []byte("split")))
+	assert.Equal(t, []byte("split"), receive(receiverB))
+	assert.Nil(t, receive(receiverC))
+	network.Heal()
+	assert.Nil(t, a.SendMessageToGroups([]p2p.GroupID{testGroup}, []byte("healed")))
+	assert.Equal(t, []byte("healed"), receive(receiverC))
+}
+
+func TestDirectMessages(t *testing.T) {
+	network := NewNetwork(1)
+	a := network.NewHost(&p2p.Peer{})
+	b := network.NewHost(&p2p.Peer{})
+
+	assert.Nil(t, a.SendMessageToPeer(b.GetID(), []byte("vote")))
+	assert.Equal(t, []byte("vote"), receive(b.DirectReceiver()))
+	assert.Equal(t, ErrUnknownPeer, a.SendMessageToPeer(libp2p_peer.ID("nobody"), []byte("vote")))
+
+	network.Partition([]libp2p_peer.ID{a.GetID()}, []libp2p_peer.ID{b.GetID()})
+	assert.Equal(t, ErrPartitioned, a.SendMessageToPeer(b.GetID(), []byte("vote")))
+}
+
+func TestLatencyAndDrops(t *testing.T) {
+	network := NewNetwork(1)
+	a := network.NewHost(&p2p.Peer{})
+	b := network.NewHost(&p2p.Peer{})
+	receiver, _ := b.GroupReceiver(testGroup)
+
+	network.SetLatency(20*time.Millisecond, 0)
+	start := time.Now()
+	a.SendMessageToGroups([]p2p.GroupID{testGroup}, []byte("late"))
+	msg, _, err := receiver.Receive(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("late"), msg)
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+
+	network.SetLatency(0, 0)
+	network.SetDropRate(1)
+	a.SendMessageToGroups([]p2p.GroupID{testGroup}, []byte("lost"))
+	assert.Nil(t, receive(receiver))
+
+	// The same seed loses the same messages.
+	delivered := func(seed int64) []bool {
+		network := NewNetwork(seed)
+		a := network.NewHost(&p2p.Peer{})
+		b := network.NewHost(&p2p.Peer{})
+		network.SetDropRate(0.5)
+		delivered := make([]bool, 20)
+		for i := range delivered {
+			a.SendMessageToPeer(b.GetID(), []byte{byte(i)})
+		}
+		for msg := receive(b.DirectReceiver()); msg != nil; msg = receive(b.DirectReceiver()) {
+			delivered[msg[0]] = true
+		}
+		return delivered
+	}
+	assert.Equal(t, delivered(7), delivered(7))
+}
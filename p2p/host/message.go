@@ -2,13 +2,225 @@ package host
 
 import (
 	"encoding/binary"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/snappy"
+)
+
+const (
+	// messageType of the messages carrying their content as is
+	plainMessageType = 17 // 0x11
+	// messageType of the messages whose content is compressed with snappy past its first two
+	// bytes, the category and type of the message, so that it can be classified as is
+	compressedMessageType = 18 // 0x12
+	// messageTypes of the plain and compressed messages whose header carries an expiry time,
+	// in Unix nanoseconds, past which they must no longer be gossiped
+	expiringPlainMessageType      = 19 // 0x13
+	expiringCompressedMessageType = 20 // 0x14
+	// sizes of the headers without and with an expiry time
+	headerSize         = 5
+	expiringHeaderSize = headerSize + 8
+	// contents at least this large are compressed
+	compressionThreshold = 1024
+	// the largest content a compressed message may decompress to
+	maxDecompressedSize = 32 << 20
 )
 
-// ConstructP2pMessage constructs the p2p message as [messageType, contentSize, content]
-func ConstructP2pMessage(msgType byte, content []byte) []byte {
-	message := make([]byte, 5+len(content))
-	message[0] = 17 // messageType 0x11
+// 1 if the large messages are compressed
+var compressionEnabled int32
+
+// 1 if the expiring messages carry their expiry time
+var expiryEnabled int32
+
+// SetCompression turns on or off the compression of the large messages. It must only be
+// on while every node of the network can decompress them.
+func SetCompression(enabled bool) {
+	var value int32
+	if enabled {
+		value = 1
+	}
+	atomic.StoreInt32(&compressionEnabled, value)
+}
+
+// CompressionEnabled tells whether the large messages are compressed.
+func CompressionEnabled() bool {
+	return atomic.LoadInt32(&compressionEnabled) == 1
+}
+
+// SetExpiry turns on or off the expiry time of the expiring messages. It must only be on
+// while every node of the network can read it.
+func SetExpiry(enabled bool) {
+	var value int32
+	if enabled {
+		value = 1
+	}
+	atomic.StoreInt32(&expiryEnabled, value)
+}
+
+// ExpiryEnabled tells whether the expiring messages carry their expiry time.
+func ExpiryEnabled() bool {
+	return atomic.LoadInt32(&expiryEnabled) == 1
+}
+
+// ConstructP2pMessage constructs the p2p message as [messageType, contentSize, content],
+// the messageType telling whether the content is compressed, as it is for a large message
+// if compression is enabled.
+func ConstructP2pMessage(content []byte) []byte {
+	return constructP2pMessage(content, time.Time{})
+}
+
+// ConstructExpiringP2pMessage constructs the p2p message as [messageType, contentSize,
+// expiry, content], the peers no longer gossiping it once ttl passed. The message carries
+// no expiry time unless expiry is enabled.
+func ConstructExpiringP2pMessage(content []byte, ttl time.Duration) []byte {
+	if !ExpiryEnabled() {
+		return constructP2pMessage(content, time.Time{})
+	}
+	return constructP2pMessage(content, time.Now().Add(ttl))
+}
+
+func constructP2pMessage(content []byte, expiry time.Time) []byte {
+	if CompressionEnabled() && len(content) >= compressionThreshold {
+		compressed := append(content[:2:2], snappy.Encode(nil, content[2:])...)
+		if len(compressed) < len(content) {
+			return constructMessage(compressedMessageType, compressed, expiry)
+		}
+	}
+	return constructMessage(plainMessageType, content, expiry)
+}
+
+func constructMessage(messageType byte, content []byte, expiry time.Time) []byte {
+	size := headerSize
+	if !expiry.IsZero() {
+		// plain => expiring plain, compressed => expiring compressed
+		messageType += expiringPlainMessageType - plainMessageType
+		size = expiringHeaderSize
+	}
+	message := make([]byte, size+len(content))
+	message[0] = messageType
 	binary.BigEndian.PutUint32(message[1:5], uint32(len(content)))
-	copy(message[5:], content)
+	if !expiry.IsZero() {
+		binary.BigEndian.PutUint64(message[5:13], uint64(expiry.UnixNano()))
+	}
+	copy(message[size:], content)
 	return message
 }
+
+// IsCompressedMessage tells whether the content of a p2p message is compressed.
+func IsCompressedMessage(message []byte) bool {
+	return len(message) > 0 &&
+		(message[0] == compressedMessageType || message[0] == expiringCompressedMessageType)
+}
+
+// isExpiringMessage tells whether the header of a p2p message carries an expiry time.
+func isExpiringMessage(message []byte) bool {
+	return len(message) > 0 &&
+		(message[0] == expiringPlainMessageType || message[0] == expiringCompressedMessageType)
+}
+
+// P2pMessageExpiry returns the expiry time of a p2p message, if it has one.
+func P2pMessageExpiry(message []byte) (time.Time, bool) {
+	if !isExpiringMessage(message) || len(message) < expiringHeaderSize {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(message[5:13]))), true
+}
+
+// P2pMessageRawContent returns the content of a p2p message as sent, compressed past its
+// category and type if the message is compressed.
+func P2pMessageRawContent(message []byte) ([]byte, error) {
+	size := headerSize
+	if isExpiringMessage(message) {
+		size = expiringHeaderSize
+	}
+	if len(message) < size {
+		return nil, errors.New("p2p message too short")
+	}
+	return message[size:], nil
+}
+
+// P2pMessageContent returns the content of a p2p message, decompressed if needed.
+func P2pMessageContent(message []byte) ([]byte, error) {
+	content, err := P2pMessageRawContent(message)
+	if err != nil {
+		return nil, err
+	}
+	if !IsCompressedMessage(message) {
+		return content, nil
+	}
+	if len(content) < 2 {
+		return nil, errors.New("compressed p2p message too short")
+	}
+	size, err := snappy.DecodedLen(content[2:])
+	if err != nil {
+		return nil, err
+	}
+	if size > maxDecompressedSize {
+		return nil, errors.New("compressed p2p message too large")
+	}
+	body, err := snappy.Decode(nil, content[2:])
+	if err != nil {
+		return nil, err
+	}
+	return append(content[:2:2], body...), nil
+}
+
+// P2pMessageContentHead returns the beginning of the content of a p2p message, which holds
+// the category and type of the message, without decompressing the whole content: the
+// head of a compressed content is the first literal of its snappy block, which can only
+// be stored as is.
+func P2pMessageContentHead(message []byte) ([]byte, error) {
+	content, err := P2pMessageRawContent(message)
+	if err != nil {
+		return nil, err
+	}
+	if !IsCompressedMessage(message) {
+		return content, nil
+	}
+	if len(content) < 2 {
+		return nil, errors.New("compressed p2p message too short")
+	}
+	literal, err := snappyFirstLiteral(content[2:])
+	if err != nil {
+		return nil, err
+	}
+	return append(content[:2:2], literal...), nil
+}
+
+// snappyFirstLiteral returns the first literal of a snappy block, i.e. the beginning of the
+// decoded data, or nothing if the decoded data is empty.
+func snappyFirstLiteral(block []byte) ([]byte, error) {
+	_, n := binary.Uvarint(block)
+	if n <= 0 {
+		return nil, errors.New("invalid snappy block length")
+	}
+	block = block[n:]
+	if len(block) == 0 {
+		return nil, nil
+	}
+	tag := block[0]
+	if tag&3 != 0 {
+		return nil, errors.New("snappy block not starting with a literal")
+	}
+	block = block[1:]
+	// The length minus one is in the tag, or in the 1 to 4 little endian bytes after it.
+	length := uint64(tag >> 2)
+	if length >= 60 {
+		numBytes := int(length) - 59
+		if len(block) < numBytes {
+			return nil, errors.New("truncated snappy literal")
+		}
+		length = 0
+		for i := 0; i < numBytes; i++ {
+			length |= uint64(block[i]) << (8 * uint(i))
+		}
+		block = block[numBytes:]
+	}
+	length++
+	if uint64(len(block)) < length {
+		return nil, errors.New("truncated snappy literal")
+	}
+	return block[:length], nil
+}
@@ -0,0 +1,113 @@
+package host
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestConstructP2pMessageCompression(t *testing.T) {
+	content := append([]byte{1, 2}, bytes.Repeat([]byte("block"), 1000)...)
+	small := []byte{1, 2, 3}
+
+	SetCompression(false)
+	message := ConstructP2pMessage(content)
+	if message[0] != plainMessageType || !bytes.Equal(message[5:], content) {
+		t.Errorf("expected a plain message")
+	}
+
+	SetCompression(true)
+	defer SetCompression(false)
+	message = ConstructP2pMessage(content)
+	if message[0] != compressedMessageType || len(message) >= len(content) {
+		t.Errorf("expected a compressed message, got type %d of %d bytes", message[0], len(message))
+	}
+	if !bytes.Equal(message[5:7], content[:2]) {
+		t.Errorf("expected the category and type uncompressed")
+	}
+	got, err := P2pMessageContent(message)
+	if err != nil || !bytes.Equal(got, content) {
+		t.Errorf("cannot decompress message: %v", err)
+	}
+
+	message = ConstructP2pMessage(small)
+	if message[0] != plainMessageType {
+		t.Errorf("expected a small message left plain")
+	}
+	got, err = P2pMessageContent(message)
+	if err != nil || !bytes.Equal(got, small) {
+		t.Errorf("cannot read plain message: %v", err)
+	}
+
+	if _, err := P2pMessageContent([]byte{compressedMessageType, 0, 0, 0, 4, 1, 2, 0xff, 0xff}); err == nil {
+		t.Errorf("expected an error for corrupt compressed content")
+	}
+}
+
+func TestConstructExpiringP2pMessage(t *testing.T) {
+	content := []byte{1, 2, 3}
+
+	SetExpiry(false)
+	message := ConstructExpiringP2pMessage(content, time.Minute)
+	if _, ok := P2pMessageExpiry(message); ok || message[0] != plainMessageType {
+		t.Errorf("expected no expiry time while expiry is disabled")
+	}
+
+	SetExpiry(true)
+	defer SetExpiry(false)
+	before := time.Now()
+	message = ConstructExpiringP2pMessage(content, time.Minute)
+	if message[0] != expiringPlainMessageType {
+		t.Errorf("expected an expiring message, got type %d", message[0])
+	}
+	expiry, ok := P2pMessageExpiry(message)
+	if !ok || expiry.Before(before.Add(time.Minute)) || expiry.After(time.Now().Add(time.Minute)) {
+		t.Errorf("unexpected expiry time %v", expiry)
+	}
+	got, err := P2pMessageContent(message)
+	if err != nil || !bytes.Equal(got, content) {
+		t.Errorf("cannot read expiring message: %v", err)
+	}
+	if _, ok := P2pMessageExpiry(ConstructP2pMessage(content)); ok {
+		t.Errorf("expected no expiry time for a message without TTL")
+	}
+
+	SetCompression(true)
+	defer SetCompression(false)
+	large := append([]byte{1, 2}, bytes.Repeat([]byte("block"), 1000)...)
+	message = ConstructExpiringP2pMessage(large, time.Minute)
+	if message[0] != expiringCompressedMessageType || !IsCompressedMessage(message) {
+		t.Errorf("expected an expiring compressed message, got type %d", message[0])
+	}
+	got, err = P2pMessageContent(message)
+	if err != nil || !bytes.Equal(got, large) {
+		t.Errorf("cannot decompress expiring message: %v", err)
+	}
+}
+
+func TestP2pMessageContentHead(t *testing.T) {
+	content := append([]byte{1, 2, 3, 4, 5, 6}, bytes.Repeat([]byte("block"), 1000)...)
+
+	head, err := P2pMessageContentHead(ConstructP2pMessage(content))
+	if err != nil || !bytes.Equal(head, content) {
+		t.Errorf("expected the whole content of a plain message: %v", err)
+	}
+
+	SetCompression(true)
+	defer SetCompression(false)
+	message := ConstructP2pMessage(content)
+	if !IsCompressedMessage(message) {
+		t.Fatalf("expected a compressed message")
+	}
+	head, err = P2pMessageContentHead(message)
+	if err != nil {
+		t.Fatalf("cannot read the head of the message: %v", err)
+	}
+	if len(head) < 6 || len(head) >= len(content) || !bytes.Equal(head, content[:len(head)]) {
+		t.Errorf("expected a prefix of the content, got %d bytes", len(head))
+	}
+
+	if _, err := P2pMessageContentHead([]byte{compressedMessageType, 0, 0, 0, 5, 1, 2, 10, 0x0c}); err == nil {
+		t.Errorf("expected an error for a truncated literal")
+	}
+}
@@ -159,3 +159,31 @@ func (mr *MockHostMockRecorder) GroupReceiver(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GroupReceiver", reflect.TypeOf((*MockHost)(nil).GroupReceiver), arg0)
 }
+
+// SendMessageToPeer mocks base method
+func (m *MockHost) SendMessageToPeer(peer go_libp2p_peer.ID, msg []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendMessageToPeer", peer, msg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMessageToPeer indicates an expected call of SendMessageToPeer
+func (mr *MockHostMockRecorder) SendMessageToPeer(peer, msg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMessageToPeer", reflect.TypeOf((*MockHost)(nil).SendMessageToPeer), peer, msg)
+}
+
+// DirectReceiver mocks base method
+func (m *MockHost) DirectReceiver() p2p.GroupReceiver {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DirectReceiver")
+	ret0, _ := ret[0].(p2p.GroupReceiver)
+	return ret0
+}
+
+// DirectReceiver indicates an expected call of DirectReceiver
+func (mr *MockHostMockRecorder) DirectReceiver() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DirectReceiver", reflect.TypeOf((*MockHost)(nil).DirectReceiver))
+}
@@ -14,7 +14,12 @@ import (
 // for hostv2, it generates multiaddress, keypair and add PeerID to peer, add priKey to host
 // TODO (leo) The peerstore has to be persisted on disk.
 func NewHost(self *p2p.Peer, key libp2p_crypto.PrivKey) (p2p.Host, error) {
-	h := hostv2.New(self, key)
+	return NewHostWithConfig(self, key, hostv2.Config{})
+}
+
+// NewHostWithConfig starts the host for p2p configured by config.
+func NewHostWithConfig(self *p2p.Peer, key libp2p_crypto.PrivKey, config hostv2.Config) (p2p.Host, error) {
+	h := hostv2.NewWithConfig(self, key, config)
 
 	utils.GetLogInstance().Info("NewHost", "self", net.JoinHostPort(self.IP, self.Port), "PeerID", self.PeerID)
 
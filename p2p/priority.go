@@ -0,0 +1,132 @@
+package p2p
+
+import (
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// Priority is the lane a received message waits in before it is handled.
+type Priority uint8
+
+// The priorities, from the highest
+const (
+	// PriorityHigh is for the messages of consensus rounds, such as announce, prepared and committed.
+	PriorityHigh Priority = iota
+	// PriorityNormal is for the messages without a lane of their own.
+	PriorityNormal
+	// PriorityLow is for the bulk traffic, such as transaction gossip and sync responses.
+	PriorityLow
+	numPriorities
+)
+
+func (priority Priority) String() string {
+	switch priority {
+	case PriorityHigh:
+		return "high"
+	case PriorityNormal:
+		return "normal"
+	case PriorityLow:
+		return "low"
+	}
+	return "unknown"
+}
+
+// Defaults of the priority dispatcher
+var (
+	// DefaultLaneWeights are how many messages of each priority are handled, at most, for
+	// each round of the lanes while the lanes of higher priority are not empty.
+	DefaultLaneWeights = [numPriorities]int{8, 4, 1}
+	// DefaultLaneSize is how many messages wait in a lane, at most; the next ones are dropped.
+	DefaultLaneSize = 1024
+	// DefaultDispatchWorkers is how many messages are handled at the same time.
+	DefaultDispatchWorkers = 32
+)
+
+// PriorityDispatcher queues the received messages in lanes by priority and hands them to a
+// pool of workers, so that under load the consensus messages are handled ahead of the bulk
+// traffic. The lanes are served by weighted round robin, so that no lane starves.
+type PriorityDispatcher struct {
+	// Classify returns the priority of a message as received from a group
+	Classify func(msg []byte) Priority
+
+	lanes   [numPriorities]chan func()
+	weights [numPriorities]int
+	credits [numPriorities]int
+	notify  chan struct{}
+	tasks   chan func()
+}
+
+// NewPriorityDispatcher creates a dispatcher with the default settings and starts its workers.
+func NewPriorityDispatcher(classify func(msg []byte) Priority) *PriorityDispatcher {
+	dispatcher := &PriorityDispatcher{
+		Classify: classify,
+		weights:  DefaultLaneWeights,
+		credits:  DefaultLaneWeights,
+		notify:   make(chan struct{}, 1),
+		tasks:    make(chan func()),
+	}
+	for i := range dispatcher.lanes {
+		dispatcher.lanes[i] = make(chan func(), DefaultLaneSize)
+	}
+	go dispatcher.schedule()
+	for i := 0; i < DefaultDispatchWorkers; i++ {
+		go func() {
+			for handle := range dispatcher.tasks {
+				handle()
+			}
+		}()
+	}
+	return dispatcher
+}
+
+// Dispatch queues handle, handling msg, in the lane of msg. It returns false if the lane
+// is full, in which case handle is not called.
+func (dispatcher *PriorityDispatcher) Dispatch(msg []byte, handle func()) bool {
+	priority := PriorityNormal
+	if dispatcher.Classify != nil {
+		priority = dispatcher.Classify(msg)
+	}
+	if priority >= numPriorities {
+		priority = PriorityNormal
+	}
+	select {
+	case dispatcher.lanes[priority] <- handle:
+	default:
+		metrics.GetOrRegisterCounter("p2p/dispatch/dropped/"+priority.String(), nil).Inc(1)
+		return false
+	}
+	select {
+	case dispatcher.notify <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// schedule hands the queued messages to the workers, one at a time.
+func (dispatcher *PriorityDispatcher) schedule() {
+	for {
+		dispatcher.tasks <- dispatcher.next()
+	}
+}
+
+// next takes the next message to handle: from the lane of highest priority with messages
+// and credits left in the round, waiting for a message if all the lanes are empty.
+func (dispatcher *PriorityDispatcher) next() func() {
+	for {
+		for priority, lane := range dispatcher.lanes {
+			if dispatcher.credits[priority] == 0 {
+				continue
+			}
+			select {
+			case handle := <-lane:
+				dispatcher.credits[priority]--
+				return handle
+			default:
+			}
+		}
+		if dispatcher.credits == dispatcher.weights {
+			<-dispatcher.notify
+		} else {
+			dispatcher.credits = dispatcher.weights
+		}
+	}
+}
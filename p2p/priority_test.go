@@ -0,0 +1,49 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriorityDispatcher(test *testing.T) {
+	defer func(workers int) { DefaultDispatchWorkers = workers }(DefaultDispatchWorkers)
+	DefaultDispatchWorkers = 1
+	dispatcher := NewPriorityDispatcher(func(msg []byte) Priority { return Priority(msg[0]) })
+
+	release := make(chan struct{})
+	handled := make(chan string, 8)
+	dispatch := func(priority Priority, name string) {
+		assert.True(test, dispatcher.Dispatch([]byte{byte(priority)}, func() { handled <- name }))
+	}
+	// Keep the worker busy, and the scheduler waiting for it with the first low message.
+	assert.True(test, dispatcher.Dispatch([]byte{byte(PriorityNormal)}, func() { <-release }))
+	dispatch(PriorityLow, "low0")
+	for len(dispatcher.lanes[PriorityLow]) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	dispatch(PriorityLow, "low1")
+	dispatch(PriorityLow, "low2")
+	dispatch(PriorityHigh, "high0")
+	dispatch(PriorityHigh, "high1")
+	close(release)
+
+	var order []string
+	for i := 0; i < 5; i++ {
+		order = append(order, <-handled)
+	}
+	assert.Equal(test, []string{"low0", "high0", "high1", "low1", "low2"}, order)
+}
+
+func TestPriorityDispatcherLaneFull(test *testing.T) {
+	defer func(size int) { DefaultLaneSize = size }(DefaultLaneSize)
+	DefaultLaneSize = 1
+	dispatcher := &PriorityDispatcher{}
+	for i := range dispatcher.lanes {
+		dispatcher.lanes[i] = make(chan func(), DefaultLaneSize)
+	}
+	dispatcher.notify = make(chan struct{}, 1)
+	assert.True(test, dispatcher.Dispatch(nil, func() {}))
+	assert.False(test, dispatcher.Dispatch(nil, func() {}))
+}
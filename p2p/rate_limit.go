@@ -0,0 +1,172 @@
+package p2p
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+)
+
+// MessageKind identifies the kind of a message for rate limiting, as "category/type".
+type MessageKind string
+
+// RateBudget is a token bucket: a peer may send Rate messages of a kind per second on
+// average, and up to Burst at once. A zero Rate is no limit.
+type RateBudget struct {
+	Rate  float64
+	Burst float64
+}
+
+// DefaultRateBudget is the budget of the message kinds without a budget of their own.
+var DefaultRateBudget = RateBudget{Rate: 50, Burst: 100}
+
+// the buckets which refilled entirely are dropped every period
+const rateLimitCleanupPeriod = time.Minute
+
+// rateLimitKey is the bucket of a peer for a kind of message.
+type rateLimitKey struct {
+	sender libp2p_peer.ID
+	kind   MessageKind
+}
+
+// tokenBucket is the remaining budget of a peer for a kind of message.
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// RateLimiter drops the messages a peer sends over its budget for their kind, so that a
+// single peer cannot flood the handlers of the node. Dropped messages are counted in the
+// p2p/ratelimit/dropped metrics, in total and per kind.
+type RateLimiter struct {
+	mutex   sync.Mutex
+	buckets map[rateLimitKey]*tokenBucket
+	budgets map[MessageKind]RateBudget
+	cleaned time.Time
+
+	// DefaultBudget applies to the kinds without a budget of their own
+	DefaultBudget RateBudget
+	// Classify returns the kind of a message as received from a group
+	Classify func(msg []byte) MessageKind
+
+	now func() time.Time
+}
+
+// NewRateLimiter creates a rate limiter with DefaultRateBudget for every kind of message.
+func NewRateLimiter(classify func(msg []byte) MessageKind) *RateLimiter {
+	return &RateLimiter{
+		buckets:       make(map[rateLimitKey]*tokenBucket),
+		budgets:       make(map[MessageKind]RateBudget),
+		DefaultBudget: DefaultRateBudget,
+		Classify:      classify,
+		now:           time.Now,
+	}
+}
+
+// SetBudget sets the budget of a kind of message. The budget of a category, e.g. "node",
+// applies to the kinds of that category without a budget of their own, e.g. "node/0".
+func (limiter *RateLimiter) SetBudget(kind MessageKind, budget RateBudget) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	limiter.budgets[kind] = budget
+}
+
+// budget returns the budget of kind. Caller holds the mutex.
+func (limiter *RateLimiter) budget(kind MessageKind) RateBudget {
+	if budget, ok := limiter.budgets[kind]; ok {
+		return budget
+	}
+	if i := strings.Index(string(kind), "/"); i >= 0 {
+		if budget, ok := limiter.budgets[kind[:i]]; ok {
+			return budget
+		}
+	}
+	return limiter.DefaultBudget
+}
+
+// Allow takes a token from the bucket of sender for the kind of msg, telling whether the
+// message is within the budget.
+func (limiter *RateLimiter) Allow(sender libp2p_peer.ID, msg []byte) bool {
+	var kind MessageKind
+	if limiter.Classify != nil {
+		kind = limiter.Classify(msg)
+	}
+
+	limiter.mutex.Lock()
+	now := limiter.now()
+	if now.Sub(limiter.cleaned) >= rateLimitCleanupPeriod {
+		limiter.cleanup(now)
+	}
+	budget := limiter.budget(kind)
+	if budget.Rate == 0 {
+		limiter.mutex.Unlock()
+		return true
+	}
+	key := rateLimitKey{sender: sender, kind: kind}
+	bucket, ok := limiter.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: budget.Burst, updated: now}
+		limiter.buckets[key] = bucket
+	}
+	bucket.tokens += now.Sub(bucket.updated).Seconds() * budget.Rate
+	if bucket.tokens > budget.Burst {
+		bucket.tokens = budget.Burst
+	}
+	bucket.updated = now
+	allowed := bucket.tokens >= 1
+	if allowed {
+		bucket.tokens--
+	}
+	limiter.mutex.Unlock()
+
+	if !allowed {
+		metrics.GetOrRegisterCounter("p2p/ratelimit/dropped", nil).Inc(1)
+		metrics.GetOrRegisterCounter("p2p/ratelimit/dropped/"+string(kind), nil).Inc(1)
+	}
+	return allowed
+}
+
+// cleanup drops the buckets which refilled entirely. Caller holds the mutex.
+func (limiter *RateLimiter) cleanup(now time.Time) {
+	for key, bucket := range limiter.buckets {
+		budget := limiter.budget(key.kind)
+		if bucket.tokens+now.Sub(bucket.updated).Seconds()*budget.Rate >= budget.Burst {
+			delete(limiter.buckets, key)
+		}
+	}
+	limiter.cleaned = now
+}
+
+// ParseRateBudgets parses budgets written as "kind=rate:burst,...", e.g.
+// "consensus=100:200,node/0=20:40". The kind "default" sets the default budget.
+func ParseRateBudgets(value string) (map[MessageKind]RateBudget, error) {
+	budgets := make(map[MessageKind]RateBudget)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid rate budget %q: expected kind=rate:burst", item)
+		}
+		limits := strings.SplitN(parts[1], ":", 2)
+		if len(limits) != 2 {
+			return nil, fmt.Errorf("invalid rate budget %q: expected kind=rate:burst", item)
+		}
+		rate, err := strconv.ParseFloat(limits[0], 64)
+		if err != nil || rate < 0 {
+			return nil, fmt.Errorf("invalid rate in %q", item)
+		}
+		burst, err := strconv.ParseFloat(limits[1], 64)
+		if err != nil || burst < 1 {
+			return nil, fmt.Errorf("invalid burst in %q", item)
+		}
+		budgets[MessageKind(parts[0])] = RateBudget{Rate: rate, Burst: burst}
+	}
+	return budgets, nil
+}
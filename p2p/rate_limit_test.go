@@ -0,0 +1,51 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterAllow(test *testing.T) {
+	now := time.Now()
+	limiter := NewRateLimiter(func(msg []byte) MessageKind { return MessageKind(msg) })
+	limiter.now = func() time.Time { return now }
+	limiter.SetBudget("consensus", RateBudget{Rate: 2, Burst: 3})
+	limiter.SetBudget("node/0", RateBudget{})
+	sender := libp2p_peer.ID("peer")
+
+	for i := 0; i < 3; i++ {
+		assert.True(test, limiter.Allow(sender, []byte("consensus/0")))
+	}
+	assert.False(test, limiter.Allow(sender, []byte("consensus/0")))
+	// Each kind has a bucket of its own, and so has each peer.
+	assert.True(test, limiter.Allow(sender, []byte("consensus/1")))
+	assert.True(test, limiter.Allow(libp2p_peer.ID("other"), []byte("consensus/0")))
+
+	now = now.Add(time.Second)
+	assert.True(test, limiter.Allow(sender, []byte("consensus/0")))
+	assert.True(test, limiter.Allow(sender, []byte("consensus/0")))
+	assert.False(test, limiter.Allow(sender, []byte("consensus/0")))
+
+	// A zero rate is no limit.
+	for i := 0; i < 1000; i++ {
+		assert.True(test, limiter.Allow(sender, []byte("node/0")))
+	}
+}
+
+func TestParseRateBudgets(test *testing.T) {
+	budgets, err := ParseRateBudgets("default=10:20, consensus=100:200,node/0=0.5:1")
+	assert.NoError(test, err)
+	assert.Equal(test, map[MessageKind]RateBudget{
+		"default":   {Rate: 10, Burst: 20},
+		"consensus": {Rate: 100, Burst: 200},
+		"node/0":    {Rate: 0.5, Burst: 1},
+	}, budgets)
+
+	for _, value := range []string{"consensus", "consensus=1", "consensus=a:1", "consensus=1:0"} {
+		_, err := ParseRateBudgets(value)
+		assert.Error(test, err, value)
+	}
+}
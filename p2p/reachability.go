@@ -0,0 +1,22 @@
+package p2p
+
+// ReachabilityStatus tells whether the peers can connect to a host.
+type ReachabilityStatus string
+
+// The reachability statuses
+const (
+	// ReachabilityPublic is a host listening on a public address.
+	ReachabilityPublic ReachabilityStatus = "public"
+	// ReachabilityRelayed is a host without a public address, reachable through a relay.
+	ReachabilityRelayed ReachabilityStatus = "relayed"
+	// ReachabilityPrivate is a host the peers outside its network cannot connect to.
+	ReachabilityPrivate ReachabilityStatus = "private"
+)
+
+// Reachability tells how the peers can reach a host, as far as the host can tell from its
+// addresses: a public address may still be firewalled.
+type Reachability struct {
+	Status      ReachabilityStatus `json:"status"`
+	PublicAddrs []string           `json:"publicAddrs"`
+	RelayAddrs  []string           `json:"relayAddrs"`
+}
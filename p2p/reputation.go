@@ -0,0 +1,237 @@
+package p2p
+
+import (
+	"sync"
+	"time"
+
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+)
+
+// Offense is a kind of misbehavior a peer is penalized for.
+type Offense uint8
+
+// The offenses
+const (
+	// OffenseMalformedMessage is a message which cannot be parsed.
+	OffenseMalformedMessage Offense = iota
+	// OffenseInvalidConsensusMessage is a consensus message failing validation.
+	OffenseInvalidConsensusMessage
+	// OffenseSpam is sending messages faster than the allowed rate.
+	OffenseSpam
+	// OffenseSyncMisbehavior is serving undecodable or unrequested blocks during state sync.
+	OffenseSyncMisbehavior
+)
+
+func (offense Offense) String() string {
+	switch offense {
+	case OffenseMalformedMessage:
+		return "MalformedMessage"
+	case OffenseInvalidConsensusMessage:
+		return "InvalidConsensusMessage"
+	case OffenseSpam:
+		return "Spam"
+	case OffenseSyncMisbehavior:
+		return "SyncMisbehavior"
+	}
+	return "Unknown"
+}
+
+// penalties of the offenses, in score points
+var offensePenalties = map[Offense]int{
+	OffenseMalformedMessage:        10,
+	OffenseInvalidConsensusMessage: 20,
+	OffenseSpam:                    5,
+	OffenseSyncMisbehavior:         20,
+}
+
+// Constants of the reputation manager
+const (
+	// ThrottleScore is the score at or below which a peer is throttled to ThrottledMessageRate.
+	ThrottleScore = -50
+	// BanScore is the score at which a peer is banned.
+	BanScore = -100
+
+	// DefaultBanDuration is how long a peer is banned for the first time.
+	DefaultBanDuration = time.Hour
+	// DefaultMessageRate is the number of messages per second a peer may send.
+	DefaultMessageRate = 100
+	// DefaultThrottledMessageRate is the number of messages per second a throttled peer may send.
+	DefaultThrottledMessageRate = 10
+
+	// a penalized peer recovers one point per period, up to a score of 0
+	scoreRecoveryPeriod = time.Minute
+)
+
+// PeerScore is a snapshot of the reputation of a peer.
+type PeerScore struct {
+	Score       int       `json:"score"`
+	Throttled   bool      `json:"throttled"`
+	Bans        int       `json:"bans"`
+	BannedUntil time.Time `json:"bannedUntil"`
+}
+
+// peerRecord is the reputation of a peer.
+type peerRecord struct {
+	score       int
+	recovered   time.Time // when the score last recovered
+	bans        int
+	bannedUntil time.Time
+	windowStart time.Time // start of the second the messages are counted in
+	numMessages int
+}
+
+// Reputation scores the peers on their misbehavior. Peers at or below ThrottleScore may send
+// fewer messages, and peers reaching BanScore are banned, each ban of a peer lasting one
+// BanDuration longer than its previous one.
+type Reputation struct {
+	mutex sync.Mutex
+	peers map[libp2p_peer.ID]*peerRecord
+
+	BanDuration          time.Duration
+	MessageRate          int
+	ThrottledMessageRate int
+	// Optional function called when a peer gets banned, e.g. to disconnect it
+	OnBan func(id libp2p_peer.ID)
+
+	now     func() time.Time
+	cleaned time.Time // when the records of the well-behaved peers were last dropped
+}
+
+// NewReputation creates a reputation manager with the default settings.
+func NewReputation() *Reputation {
+	return &Reputation{
+		peers:                make(map[libp2p_peer.ID]*peerRecord),
+		BanDuration:          DefaultBanDuration,
+		MessageRate:          DefaultMessageRate,
+		ThrottledMessageRate: DefaultThrottledMessageRate,
+		now:                  time.Now,
+	}
+}
+
+// record returns the reputation of id, recovered up to now. Caller holds the mutex.
+func (r *Reputation) record(id libp2p_peer.ID, now time.Time) *peerRecord {
+	record, ok := r.peers[id]
+	if !ok {
+		record = &peerRecord{recovered: now}
+		r.peers[id] = record
+	}
+	if record.score < 0 {
+		points := int(now.Sub(record.recovered) / scoreRecoveryPeriod)
+		if points > 0 {
+			record.score += points
+			if record.score > 0 {
+				record.score = 0
+			}
+			record.recovered = record.recovered.Add(time.Duration(points) * scoreRecoveryPeriod)
+		}
+	} else {
+		record.recovered = now
+	}
+	return record
+}
+
+// Penalize lowers the score of id for offense, banning the peer if it reaches BanScore.
+func (r *Reputation) Penalize(id libp2p_peer.ID, offense Offense) {
+	r.mutex.Lock()
+	now := r.now()
+	record := r.record(id, now)
+	record.score -= offensePenalties[offense]
+	banned := false
+	if record.score <= BanScore && !now.Before(record.bannedUntil) {
+		record.bans++
+		record.bannedUntil = now.Add(time.Duration(record.bans) * r.BanDuration)
+		// A peer returns from its ban throttled.
+		record.score = ThrottleScore
+		record.recovered = record.bannedUntil
+		banned = true
+	}
+	onBan := r.OnBan
+	r.mutex.Unlock()
+
+	if banned && onBan != nil {
+		onBan(id)
+	}
+}
+
+// Score returns the score of id, 0 for a peer in good standing.
+func (r *Reputation) Score(id libp2p_peer.ID) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.peers[id]; !ok {
+		return 0
+	}
+	return r.record(id, r.now()).score
+}
+
+// IsBanned tells whether id is banned.
+func (r *Reputation) IsBanned(id libp2p_peer.ID) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	record, ok := r.peers[id]
+	return ok && r.now().Before(record.bannedUntil)
+}
+
+// AllowMessage counts a message from id and tells whether to process it. Messages of banned
+// peers are dropped, and so are those over the message rate of the peer, which is penalized
+// for spam once per second it exceeds the rate.
+func (r *Reputation) AllowMessage(id libp2p_peer.ID) bool {
+	r.mutex.Lock()
+	now := r.now()
+	record := r.record(id, now)
+	if now.Before(record.bannedUntil) {
+		r.mutex.Unlock()
+		return false
+	}
+	if now.Sub(record.windowStart) >= time.Second {
+		record.windowStart = now
+		record.numMessages = 0
+	}
+	record.numMessages++
+	rate := r.MessageRate
+	if record.score <= ThrottleScore {
+		rate = r.ThrottledMessageRate
+	}
+	allowed := rate == 0 || record.numMessages <= rate
+	penalize := !allowed && record.numMessages == rate+1
+	if now.Sub(r.cleaned) >= scoreRecoveryPeriod {
+		r.cleanup(now)
+	}
+	r.mutex.Unlock()
+
+	if penalize {
+		r.Penalize(id, OffenseSpam)
+	}
+	return allowed
+}
+
+// cleanup drops the records of the peers in good standing which sent nothing lately, so that
+// only the misbehaving peers are remembered. Caller holds the mutex.
+func (r *Reputation) cleanup(now time.Time) {
+	for id, record := range r.peers {
+		if record.score == 0 && record.bans == 0 && now.Sub(record.windowStart) >= time.Second {
+			delete(r.peers, id)
+		}
+	}
+	r.cleaned = now
+}
+
+// Scores returns the reputation of the peers which misbehaved.
+func (r *Reputation) Scores() map[libp2p_peer.ID]PeerScore {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	now := r.now()
+	scores := make(map[libp2p_peer.ID]PeerScore)
+	for id := range r.peers {
+		record := r.record(id, now)
+		if record.score == 0 && record.bans == 0 {
+			continue
+		}
+		scores[id] = PeerScore{
+			Score:       record.score,
+			Throttled:   record.score <= ThrottleScore,
+			Bans:        record.bans,
+			BannedUntil: record.bannedUntil,
+		}
+	}
+	return scores
+}
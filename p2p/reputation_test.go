@@ -0,0 +1,67 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	libp2p_peer "github.com/libp2p/go-libp2p-peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReputationBan(test *testing.T) {
+	now := time.Now()
+	reputation := NewReputation()
+	reputation.now = func() time.Time { return now }
+	var banned []libp2p_peer.ID
+	reputation.OnBan = func(id libp2p_peer.ID) { banned = append(banned, id) }
+	id := libp2p_peer.ID("peer")
+
+	for i := 0; i < 4; i++ {
+		reputation.Penalize(id, OffenseInvalidConsensusMessage)
+	}
+	assert.Equal(test, -80, reputation.Scores()[id].Score)
+	assert.Equal(test, -80, reputation.Score(id))
+	assert.Equal(test, 0, reputation.Score(libp2p_peer.ID("other")))
+	assert.True(test, reputation.Scores()[id].Throttled)
+	assert.False(test, reputation.IsBanned(id))
+
+	reputation.Penalize(id, OffenseInvalidConsensusMessage)
+	assert.True(test, reputation.IsBanned(id))
+	assert.False(test, reputation.AllowMessage(id))
+	assert.Equal(test, []libp2p_peer.ID{id}, banned)
+
+	// A repeat offender is banned for longer.
+	now = now.Add(DefaultBanDuration)
+	assert.False(test, reputation.IsBanned(id))
+	for i := 0; i < 3; i++ {
+		reputation.Penalize(id, OffenseSyncMisbehavior)
+	}
+	assert.Equal(test, 2, reputation.Scores()[id].Bans)
+	now = now.Add(DefaultBanDuration)
+	assert.True(test, reputation.IsBanned(id))
+	now = now.Add(DefaultBanDuration)
+	assert.False(test, reputation.IsBanned(id))
+}
+
+func TestReputationMessageRate(test *testing.T) {
+	now := time.Now()
+	reputation := NewReputation()
+	reputation.now = func() time.Time { return now }
+	id := libp2p_peer.ID("peer")
+
+	for i := 0; i < DefaultMessageRate; i++ {
+		assert.True(test, reputation.AllowMessage(id))
+	}
+	assert.False(test, reputation.AllowMessage(id))
+	assert.False(test, reputation.AllowMessage(id))
+	// Spam is penalized once per second.
+	assert.Equal(test, -offensePenalties[OffenseSpam], reputation.Scores()[id].Score)
+
+	now = now.Add(time.Second)
+	assert.True(test, reputation.AllowMessage(id))
+
+	// The score recovers over time.
+	now = now.Add(time.Duration(offensePenalties[OffenseSpam]) * scoreRecoveryPeriod)
+	_, penalized := reputation.Scores()[id]
+	assert.False(test, penalized)
+}